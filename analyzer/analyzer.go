@@ -21,13 +21,17 @@ package analyzer
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"math"
+	"math/bits"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/erh/gonmea/common"
@@ -51,13 +55,32 @@ type Analyzer struct {
 	length              int64
 	skip                bool
 	previousFieldValue  int64
+	emptyValue          int64 // Set with haveEmptyValue when a field holds an Unknown/ERROR/RESERVED value
+	haveEmptyValue      bool
 	ftf                 *pgnField
 
+	badLines              int // Input lines that could not be parsed
+	incompleteFastPackets int // Fast packets dropped because a frame was missed
+	jsonArrayLen          int // Messages written so far with ShowJSONArray
+
 	pb               printBuffer
 	fieldTypes       []fieldType
 	pgns             []pgnInfo
 	reassemblyBuffer [reassemblyBufferSize]packet
 	reader           *bufio.Reader
+	strictFormat     bool        // StrictFormat with a configured SelectedFormat
+	lineBuf          []byte      // The line being read by readLine
+	sampledLines     [][]byte    // Lines read ahead to detect the format
+	skipLF           bool        // The last line ended in '\r', so a '\n' right after it ends nothing
+	inFile           io.Reader   // The input being read
+	inFiles          []io.Reader // Inputs still to be read after the current one
+	fieldObserver    func(fieldName string, data []byte, startBit, bits int, value interface{}, ok bool)
+	flusher          *intervalWriter
+	timings          map[uint32]PGNTiming
+	line             []byte // The input line the last raw message was read from
+	partialMsgs      []*common.Message
+	lastFields       map[changesKey]map[string]interface{} // For ChangesOnly with Compact
+	lastPrinted      map[changesKey]string                 // For ChangesOnly otherwise
 }
 
 // NewAnalyzer returns a new analyzer using the given config.
@@ -71,7 +94,21 @@ func NewAnalyzer(conf *Config) (*Analyzer, error) {
 
 		fieldTypes: make([]fieldType, len(immutFieldTypes)),
 		pgns:       make([]pgnInfo, len(immutPGNs)),
-		reader:     bufio.NewReader(conf.InFile),
+		inFile:     conf.InFile,
+		inFiles:    conf.InFiles,
+	}
+	ana.reader = ana.newLineReader(conf.InFile)
+	ana.strictFormat = conf.StrictFormat && conf.SelectedFormat != RawFormatUnknown
+	if conf.Timezone != nil {
+		// The caller's logger may be shared, so the time zone goes on a copy
+		logger := *conf.Logger
+		logger.SetLocation(conf.Timezone)
+		ana.Logger = &logger
+	}
+
+	if conf.FlushInterval > 0 {
+		ana.flusher = newIntervalWriter(conf.OutFile, conf.FlushInterval)
+		ana.OutFile = ana.flusher
 	}
 
 	copy(ana.fieldTypes, immutFieldTypes)
@@ -82,7 +119,7 @@ func NewAnalyzer(conf *Config) (*Analyzer, error) {
 	}
 
 	ana.fillLookups()
-	if err := ana.fillFieldType(true); err != nil {
+	if err := ana.fillFieldType(!conf.definedUnits); err != nil {
 		return nil, err
 	}
 	if err := ana.checkPGNs(); err != nil {
@@ -96,10 +133,12 @@ func NewAnalyzer(conf *Config) (*Analyzer, error) {
 type Config struct {
 	ShowRaw        bool
 	ShowData       bool
+	ShowDataBase64 bool // With ShowData, also print the payload in unpadded base64
 	ShowBytes      bool
 	ShowJSON       bool
 	ShowJSONEmpty  bool
 	ShowJSONValue  bool
+	ShowJSONArray  bool // Write the JSON messages as elements of one array instead of one per line
 	ShowVersion    bool
 	showSI         bool
 	ShowGeo        geoFormat
@@ -108,12 +147,163 @@ type Config struct {
 	OnlyDst        int64
 	ClockSrc       int64
 	SelectedFormat RawFormat
-	multipackets   multipackets
+	multipackets   MultiPackets
+	definedUnits   bool // Keep the units of the PGN definitions instead of those for printing
 	CamelCase      *bool
+	FlushInterval  time.Duration // 0 flushes output after every message
+	DefaultDst     uint8         // Destination for formats without one: CHETCO, received MINIPLEX and lenient PLAIN messages
+	LenientPlain   bool          // Accept PLAIN lines without destination and length or without timestamp
 	InFile         io.Reader
+	InFiles        []io.Reader // Read in order after InFile
+	KeepReassembly bool        // Keep partial fast packets when moving on to the next input
+	closeInFiles   bool        // The inputs were opened by ParseArgs and are closed once read
+	MaxLineBytes   int         // Longer input lines are skipped; 0 means 64 KiB
 	OutFile        io.Writer
 	OutErrFile     io.Writer
 	Logger         *common.Logger
+
+	// AlwaysDecodeProprietary decodes fields that are only present for proprietary PGNs
+	// even when the referenced PGN is a standard one.
+	AlwaysDecodeProprietary bool
+
+	// PreserveSentinels makes converted messages hold a common.FieldSentinel for numeric
+	// fields at their Unknown, ERROR or RESERVED values instead of leaving them out.
+	PreserveSentinels bool
+
+	// StringFFIsTerminator ends strings at the first 0xff byte, both when printing and
+	// when converting, as some devices pad or terminate strings with it.
+	StringFFIsTerminator bool
+
+	// Validate makes Run decode the input without printing it and print a summary of
+	// what was found instead. Run fails with exit code 1 if there were errors.
+	Validate bool
+
+	// CodecCheckDir makes Run decode the captures in this directory and check that every
+	// message comes back equal from MarshalMessageBinary and UnmarshalMessageBinary,
+	// printing the ones that do not. This checks the binary codec, not an encoding back to
+	// NMEA 2000 bytes. Run fails with exit code 1 if there were any.
+	CodecCheckDir string
+
+	// FieldPrecision overrides the number of decimals printed for fractional numbers, keyed
+	// by field name ("Depth") or by PGN and field name ("128267:Depth").
+	FieldPrecision map[string]int
+
+	// ShowRawSequence adds the fast-packet sequence id to ShowRaw output, as a trailing
+	// "seq=<id>" column that is dropped again when such a line is read.
+	ShowRawSequence bool
+
+	// StringEncoding selects how the bytes of string fields are interpreted.
+	StringEncoding StringEncoding
+
+	// ForceMultiPackets, when set, overrides whether the input format claims to deliver
+	// fast-packet PGNs coalesced or as separate frames, for gateways that get it wrong.
+	ForceMultiPackets *MultiPackets
+
+	// CollectTimings makes the analyzer keep track of how long decoding each PGN takes;
+	// see Timings.
+	CollectTimings bool
+
+	// UnitConverter, when set, is offered every numeric value with a unit, together with
+	// the name of its physical quantity (e.g. "SPEED", "LENGTH"). If it returns ok, the
+	// value is shown in newUnit as newValue instead; this allows e.g. knots or feet.
+	UnitConverter func(quantity, unit string, value float64) (newUnit string, newValue float64, ok bool)
+
+	// Passthrough makes Run write the input lines of the messages that pass the source,
+	// destination and PGN filters unchanged, instead of decoding them. Every frame of a
+	// fast-packet PGN is its own line, so all frames of a matching PGN are written.
+	Passthrough bool
+
+	// FilterPrio, when not empty, limits the messages printed, those returned by
+	// ReadMessage and those converted by ConvertRawMessages to the ones with these CAN
+	// priorities.
+	FilterPrio []uint8
+
+	// DecodePartialFastPackets decodes what was received of a fast packet that is given up
+	// on, because its frames start over or the input ends, instead of dropping it. Only the
+	// frames without a gap from the first one are decoded, and the message is marked
+	// Partial. ReadMessage, ConvertRawMessages, FlushPartialFastPackets and DecodeChannel
+	// return such messages, Compact prints them and ValidateInput and CodecCheckDir check
+	// them like the others.
+	DecodePartialFastPackets bool
+
+	// ValidateRanges rejects latitudes beyond 90 and longitudes beyond 180 degrees instead
+	// of decoding them as bogus positions. Converted messages and JSON leave them out, like
+	// the reserved "not available" values; the text output shows them as ERROR.
+	ValidateRanges bool
+
+	// IncludeEmptyFields makes converted messages hold nil for fields that were sent
+	// empty and common.FieldNotPresent for fields the data ended before, instead of
+	// leaving both out. Reserved and spare fields are still left out.
+	IncludeEmptyFields bool
+
+	// StrictFormat, with SelectedFormat set, makes ReadRawMessage return ErrFormatMismatch
+	// for a line that does not parse in that format, instead of logging and skipping it
+	// or, for PLAIN, trying it as FAST.
+	StrictFormat bool
+
+	// Compact makes Run print each message as one line of key=value pairs, formatted by
+	// FormatMessageCompact.
+	Compact bool
+
+	// FastPaths makes ReadMessage and ConvertRawMessages decode Position, Rapid Update
+	// (129025) with dedicated code instead of the general field loop. The result is the same.
+	FastPaths bool
+
+	// Timezone is the time zone for the timestamps of formats that give times as seconds
+	// since the epoch or only a time of day: ACTISENSE_N2K_ASCII, CHETCO, GARMIN_CSV1 and
+	// YDWG02. It is set on a copy of Logger, which the parsers take it from, so the caller's
+	// logger is left alone. Nil means local time.
+	Timezone *time.Location
+
+	// PostProcessors are called in order on every converted message, and may add or change
+	// its fields, e.g. to derive values from other messages. An error from one is returned
+	// instead of the message. Nil entries are skipped.
+	PostProcessors []func(*common.Message) error
+
+	// Candump makes Run print the CAN frames of each message in the <canid>#<data> form
+	// that candump logs and cansend takes, one per line, splitting fast packets into frames.
+	Candump bool
+
+	// Normalize makes Run print each message in PLAIN format, one line per frame, whatever
+	// format it was read in.
+	Normalize bool
+
+	// FloatNaNAsEmpty makes FLOAT fields that hold NaN or an infinity empty, like other
+	// unknown values, instead of NaN or +Inf. Without it JSON output shows them as null, as
+	// JSON has no such numbers.
+	FloatNaNAsEmpty bool
+
+	// SkipUnprintableFields makes converting a message log and leave out a field whose type
+	// has no convert function, instead of failing the whole message. Printing always does.
+	SkipUnprintableFields bool
+
+	// ChangesOnly makes Run print a message only when its fields are not all the same as
+	// those of the last message of its PGN from the same source. Fields are compared as
+	// printed, or as decoded with Compact, so a changing SID counts as a change. The
+	// ShowData dump is still printed for every message.
+	ChangesOnly bool
+
+	// UnknownLookupFormat, when set, is the fmt format that lookup fields show a value
+	// they have no name for with, such as "UNKNOWN(%d)"; the value is its only argument.
+	// For BITLOOKUP fields it is given the value of the bit. By default the bare value is
+	// shown.
+	UnknownLookupFormat string
+
+	// IncludeReservedFields makes converted messages hold the bits of every reserved and
+	// spare field as a []byte, and not only of those that do not hold their expected
+	// value. A field whose name an earlier one of the message already has is labeled with
+	// its order after the name, e.g. "Reserved9".
+	IncludeReservedFields bool
+
+	// OnFormatDetected, when set, is called with the format detected from the input, once,
+	// when no SelectedFormat was configured.
+	OnFormatDetected func(format RawFormat)
+
+	// HeaderOnly makes converted messages hold only the header and the description of
+	// their PGN, with nil Fields, for when only which messages were sent matters, e.g. to
+	// index a large capture. Fast packets are still reassembled. PostProcessors are not
+	// called.
+	HeaderOnly bool
 }
 
 // NewConfigForCLI returns a config for use with a CLI.
@@ -147,22 +337,33 @@ func newConfig(
 		OnlySrc:        int64(-1),
 		OnlyDst:        int64(-1),
 		ClockSrc:       int64(-1),
+		DefaultDst:     255,
 		SelectedFormat: RawFormatUnknown,
-		multipackets:   multipacketsSeparate,
+		multipackets:   MultiPacketsSeparate,
 		Logger:         logger,
 		OutFile:        outFile,
 		OutErrFile:     outErrFile,
+
+		StringFFIsTerminator: true,
 	}
 }
 
 // ParseArgs parses the args of a CLI program into a Config.
 func ParseArgs(args []string) (*Config, bool, error) {
+	return ParseArgsWithLogger(args, common.NewLoggerForCLI(os.Stderr))
+}
+
+// ParseArgsWithLogger is like ParseArgs but logs to the given logger, which is also
+// the one -d and -q change the level of.
+func ParseArgsWithLogger(args []string, logger *common.Logger) (*Config, bool, error) {
 	progNameAsExeced := args[0]
 
 	conf := NewConfigForCLI()
+	conf.Logger = logger
 	conf.Logger.SetProgName(progNameAsExeced)
 
 	conf.InFile = os.Stdin
+	fileCount := 0
 	for argIdx := 1; argIdx < len(args); argIdx++ {
 		arg := args[argIdx]
 		hasNext := argIdx < len(args)-1
@@ -180,6 +381,19 @@ func ParseArgs(args []string) (*Config, bool, error) {
 			conf.CamelCase = &trueValue
 		} else if strings.EqualFold(arg, "-raw") {
 			conf.ShowRaw = true
+		} else if strings.EqualFold(arg, "-passthrough") {
+			conf.Passthrough = true
+		} else if strings.EqualFold(arg, "-compact") {
+			conf.Compact = true
+		} else if strings.EqualFold(arg, "-changes-only") {
+			conf.ChangesOnly = true
+		} else if strings.EqualFold(arg, "-candump") {
+			conf.Candump = true
+		} else if strings.EqualFold(arg, "-normalize") {
+			conf.Normalize = true
+		} else if strings.EqualFold(arg, "-seq") {
+			conf.ShowRaw = true
+			conf.ShowRawSequence = true
 		} else if strings.EqualFold(arg, "-debug") {
 			conf.ShowJSONEmpty = true
 			conf.ShowBytes = true
@@ -199,6 +413,18 @@ func ParseArgs(args []string) (*Config, bool, error) {
 				return nil, false, usage(progNameAsExeced, nextArg, conf.OutFile)
 			}
 			argIdx++
+		} else if hasNext && strings.EqualFold(arg, "-string-encoding") {
+			nextArg := args[argIdx+1]
+			if strings.EqualFold(nextArg, "utf8") {
+				conf.StringEncoding = StringEncodingUTF8
+			} else if strings.EqualFold(nextArg, "ascii") {
+				conf.StringEncoding = StringEncodingASCII
+			} else if strings.EqualFold(nextArg, "latin1") {
+				conf.StringEncoding = StringEncodingLatin1
+			} else {
+				return nil, false, usage(progNameAsExeced, nextArg, conf.OutFile)
+			}
+			argIdx++
 		} else if strings.EqualFold(arg, "-si") {
 			conf.showSI = true
 		} else if strings.EqualFold(arg, "-nosi") {
@@ -211,8 +437,14 @@ func ParseArgs(args []string) (*Config, bool, error) {
 		} else if strings.EqualFold(arg, "-nv") {
 			conf.ShowJSONValue = true
 			conf.ShowJSON = true
+		} else if strings.EqualFold(arg, "-json-array") {
+			conf.ShowJSONArray = true
+			conf.ShowJSON = true
 		} else if strings.EqualFold(arg, "-data") {
 			conf.ShowData = true
+		} else if strings.EqualFold(arg, "-data-base64") {
+			conf.ShowData = true
+			conf.ShowDataBase64 = true
 		} else if hasNext && strings.EqualFold(arg, "-fixtime") {
 			nextArg := args[argIdx+1]
 			conf.Logger.SetFixedTimestamp(nextArg)
@@ -230,33 +462,70 @@ func ParseArgs(args []string) (*Config, bool, error) {
 			//nolint:errcheck
 			conf.OnlyDst, _ = strconv.ParseInt(nextArg, 10, 64)
 			argIdx++
+		} else if hasNext && strings.EqualFold(arg, "-prio") {
+			nextArg := args[argIdx+1]
+			for _, prioStr := range strings.Split(nextArg, ",") {
+				prio, err := strconv.ParseUint(prioStr, 10, 3)
+				if err != nil {
+					return nil, false, usage(progNameAsExeced, nextArg, conf.OutFile)
+				}
+				conf.FilterPrio = append(conf.FilterPrio, uint8(prio))
+			}
+			argIdx++
 		} else if hasNext && strings.EqualFold(arg, "-Clocksrc") {
 			nextArg := args[argIdx+1]
 			//nolint:errcheck
 			conf.ClockSrc, _ = strconv.ParseInt(nextArg, 10, 64)
 			argIdx++
-		} else if hasNext && strings.EqualFold(arg, "-file") {
+		} else if hasNext && strings.EqualFold(arg, "-flush") {
 			nextArg := args[argIdx+1]
 			var err error
+			conf.FlushInterval, err = time.ParseDuration(nextArg)
+			if err != nil || conf.FlushInterval < 0 {
+				return nil, false, usage(progNameAsExeced, nextArg, conf.OutFile)
+			}
+			argIdx++
+		} else if hasNext && strings.EqualFold(arg, "-file") {
+			nextArg := args[argIdx+1]
 			//nolint:gosec
-			conf.InFile, err = os.OpenFile(nextArg, os.O_RDONLY, 0)
+			file, err := os.OpenFile(nextArg, os.O_RDONLY, 0)
 			if err != nil {
 				return nil, false, conf.Logger.Abort("Cannot open file %s\n", nextArg)
 			}
+			if fileCount == 0 {
+				conf.InFile = file
+			} else {
+				conf.InFiles = append(conf.InFiles, file)
+			}
+			conf.closeInFiles = true
+			fileCount++
+			argIdx++
+		} else if strings.EqualFold(arg, "-lenient") {
+			conf.LenientPlain = true
+		} else if strings.EqualFold(arg, "-continue-reassembly") {
+			conf.KeepReassembly = true
+		} else if strings.EqualFold(arg, "-validate") {
+			conf.Validate = true
+		} else if hasNext && strings.EqualFold(arg, "-codec-check-dir") {
+			conf.CodecCheckDir = args[argIdx+1]
 			argIdx++
+		} else if strings.EqualFold(arg, "-strict") {
+			conf.StrictFormat = true
 		} else if hasNext && strings.EqualFold(arg, "-format") {
 			nextArg := args[argIdx+1]
+			found := false
 			for _, format := range RawFormats {
 				if strings.EqualFold(nextArg, string(format)) {
 					conf.SelectedFormat = format
-					if conf.SelectedFormat != RawFormatPlain && conf.SelectedFormat != RawFormatPlainOrFast {
-						conf.multipackets = multipacketsCoalesced
+					if detected, ok := detectedFormats[format]; ok {
+						conf.multipackets = detected.multipackets
 					}
+					found = true
 					break
 				}
-				if conf.SelectedFormat == RawFormatUnknown {
-					return nil, false, conf.Logger.Abort("Unknown message format '%s'\n", nextArg)
-				}
+			}
+			if !found {
+				return nil, false, conf.Logger.Abort("Unknown message format '%s'\n", nextArg)
 			}
 			argIdx++
 		} else {
@@ -272,22 +541,60 @@ func ParseArgs(args []string) (*Config, bool, error) {
 	return conf, true, nil
 }
 
-// ReadMessage returns the next message read or io.EOF.
+// ReadMessage returns the next message read or io.EOF. Frames of a fast-packet PGN
+// are consumed until the PGN is complete.
 func (ana *Analyzer) ReadMessage() (*common.Message, error) {
-	rawMsg, err := ana.ReadRawMessage()
-	if err != nil {
-		return nil, err
+	for {
+		if len(ana.partialMsgs) > 0 {
+			msg := ana.partialMsgs[0]
+			ana.partialMsgs = ana.partialMsgs[1:]
+			return msg, nil
+		}
+		rawMsg, err := ana.ReadRawMessage()
+		if errors.Is(err, io.EOF) && ana.DecodePartialFastPackets && ana.decodePartialPackets() {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !ana.matchesPrio(rawMsg) {
+			continue
+		}
+		msg, err := ana.convertRawMessage(rawMsg)
+		if errors.Is(err, ErrFastPacketIncomplete) {
+			continue
+		}
+		return msg, err
 	}
-	return ana.convertRawMessage(rawMsg)
+}
+
+// DetectedFormat returns the format the input is read in and whether it is known yet,
+// that is, whether it was configured or has been detected from the input.
+func (ana *Analyzer) DetectedFormat() (RawFormat, bool) {
+	return ana.SelectedFormat, ana.SelectedFormat != RawFormatUnknown
 }
 
 // ReadRawMessage returns the next raw message read or io.EOF.
 func (ana *Analyzer) ReadRawMessage() (*common.RawMessage, error) {
 	for {
-		msg, isPrefix, err := ana.reader.ReadLine()
-		if err != nil || isPrefix {
+		msg, isPrefix, err := ana.nextLine()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			if ana.nextInFile() {
+				continue
+			}
 			return nil, io.EOF
 		}
+		if isPrefix {
+			//nolint:errcheck
+			ana.Logger.Error("Skipping line longer than %d bytes\n", ana.reader.Size())
+			for isPrefix && err == nil {
+				_, isPrefix, err = ana.readLine()
+			}
+			continue
+		}
 		var m common.RawMessage
 
 		if len(msg) == 0 || msg[0] == '\r' || msg[0] == '\n' || msg[0] == '#' {
@@ -300,29 +607,38 @@ func (ana *Analyzer) ReadRawMessage() (*common.RawMessage, error) {
 			continue
 		}
 
+		ana.line = append(ana.line[:0], msg...)
+		msg, seq, _ := common.SplitSequence(msg)
+
 		if ana.SelectedFormat == RawFormatUnknown {
-			ana.SelectedFormat = ana.detectFormat(string(msg))
+			ana.SelectedFormat = ana.detectFormatFromInput(msg)
 			if ana.SelectedFormat == RawFormatGarminCSV1 || ana.SelectedFormat == RawFormatGarminCSV2 {
 				// Skip first line containing header line
 				continue
 			}
+			// Reading ahead reused the buffer msg was in
+			msg, seq, _ = common.SplitSequence(ana.line)
 		}
 
 		var r int
 		switch ana.SelectedFormat {
 		case RawFormatPlainOrFast:
-			ana.multipackets = multipacketsSeparate
+			ana.multipackets = MultiPacketsSeparate
 			r = common.ParseRawFormatPlain(msg, &m, ana.ShowJSON, ana.Logger)
 			ana.Logger.Debug("plain_or_fast: plain r=%d\n", r)
 			if r < 0 {
-				ana.multipackets = multipacketsCoalesced
+				ana.multipackets = MultiPacketsCoalesced
 				r = common.ParseRawFormatFast(msg, &m, ana.ShowJSON, ana.Logger)
 				ana.Logger.Debug("plain_or_fast: fast r=%d\n", r)
 			}
 
 		case RawFormatPlain:
-			r = common.ParseRawFormatPlain(msg, &m, ana.ShowJSON, ana.Logger)
-			if r >= 0 {
+			if ana.LenientPlain {
+				r = common.ParseRawFormatPlainLenient(msg, &m, ana.ShowJSON, ana.DefaultDst, ana.Logger)
+			} else {
+				r = common.ParseRawFormatPlain(msg, &m, ana.ShowJSON, ana.Logger)
+			}
+			if r >= 0 || ana.strictFormat {
 				break
 			}
 			// Else fall through to fast!
@@ -332,7 +648,7 @@ func (ana *Analyzer) ReadRawMessage() (*common.RawMessage, error) {
 			r = common.ParseRawFormatFast(msg, &m, ana.ShowJSON, ana.Logger)
 			if r >= 0 && ana.SelectedFormat == RawFormatPlain {
 				ana.Logger.Info("Detected normal format with all frames on one line\n")
-				ana.multipackets = multipacketsCoalesced
+				ana.multipackets = MultiPacketsCoalesced
 				ana.SelectedFormat = RawFormatFast
 			}
 
@@ -340,7 +656,7 @@ func (ana *Analyzer) ReadRawMessage() (*common.RawMessage, error) {
 			r = common.ParseRawFormatAirmar(msg, &m, ana.ShowJSON, ana.Logger)
 
 		case RawFormatChetco:
-			r = common.ParseRawFormatChetco(msg, &m, ana.ShowJSON, ana.Logger)
+			r = common.ParseRawFormatChetcoWithDst(msg, &m, ana.ShowJSON, ana.DefaultDst, ana.Logger)
 
 		case RawFormatGarminCSV1, RawFormatGarminCSV2:
 			r = common.ParseRawFormatGarminCSV(msg, &m, ana.ShowJSON, ana.SelectedFormat == RawFormatGarminCSV2, ana.Logger)
@@ -349,11 +665,18 @@ func (ana *Analyzer) ReadRawMessage() (*common.RawMessage, error) {
 			r = common.ParseRawFormatYDWG02(msg, &m, ana.Logger)
 
 		case RawFormatNavLink2:
+			if bytes.HasPrefix(msg, navLink2StatusPrefix) {
+				// ASCII status and configuration lines carry no PGN
+				continue
+			}
 			r = common.ParseRawFormatNavLink2(msg, &m, ana.Logger)
 
 		case RawFormatActisenseN2KASCII:
 			r = common.ParseRawFormatActisenseN2KAscii(msg, &m, ana.ShowJSON, ana.Logger)
 
+		case RawFormatMiniPlex:
+			r = common.ParseRawFormatMiniPlex(msg, &m, ana.ShowJSON, ana.DefaultDst, ana.Logger)
+
 		case RawFormatUnknown:
 			fallthrough
 		default:
@@ -361,18 +684,131 @@ func (ana *Analyzer) ReadRawMessage() (*common.RawMessage, error) {
 		}
 
 		if r == 0 {
+			m.Sequence = seq
 			return &m, nil
 		}
+		if ana.strictFormat {
+			ana.badLines++
+			return nil, fmt.Errorf("%w: %s line '%s'", ErrFormatMismatch, ana.SelectedFormat, msg)
+		}
 		//nolint:errcheck
 		ana.Logger.Error("Unknown message error %d: '%s'\n", r, msg)
+		ana.badLines++
+	}
+}
+
+// navLink2StatusPrefix starts the ASCII status lines that NavLink2 gateways send in
+// between the "!PDGY" data lines.
+var navLink2StatusPrefix = []byte("$PDGY,")
+
+// defaultMaxLineBytes fits the longest FAST line, a 223 byte payload in hex, many times over.
+const defaultMaxLineBytes = 64 * 1024
+
+func (ana *Analyzer) newLineReader(r io.Reader) *bufio.Reader {
+	size := ana.MaxLineBytes
+	if size <= 0 {
+		size = defaultMaxLineBytes
+	}
+	return bufio.NewReaderSize(r, size)
+}
+
+// readLine is like bufio.Reader.ReadLine but ends lines at "\r", "\n" or "\r\n" alike, as
+// some gateways end their lines with a bare carriage return. It scans what the reader has
+// buffered rather than reading byte by byte; ReadSlice('\n') cannot be used as it would
+// wait for a "\n" after a line that ends in a bare "\r". The line is valid until the next
+// call.
+func (ana *Analyzer) readLine() ([]byte, bool, error) {
+	if ana.skipLF {
+		ana.skipLF = false
+		if next, err := ana.reader.Peek(1); err == nil && next[0] == '\n' {
+			//nolint:errcheck
+			ana.reader.Discard(1)
+		}
+	}
+	scanned := 0
+	for {
+		// Ask for one byte more than was scanned, so that a live input is not waited on
+		// for more than the rest of the line.
+		n := ana.reader.Buffered()
+		if n <= scanned {
+			n = scanned + 1
+		}
+		buf, err := ana.reader.Peek(n)
+		if end := bytes.IndexAny(buf[scanned:], "\r\n"); end >= 0 {
+			end += scanned
+			ana.skipLF = buf[end] == '\r'
+			ana.lineBuf = append(ana.lineBuf[:0], buf[:end]...)
+			//nolint:errcheck
+			ana.reader.Discard(end + 1)
+			return ana.lineBuf, false, nil
+		}
+		scanned = len(buf)
+		if err == nil {
+			continue
+		}
+		isPrefix := errors.Is(err, bufio.ErrBufferFull)
+		if len(buf) == 0 || !isPrefix && !errors.Is(err, io.EOF) {
+			return nil, false, err
+		}
+		// A line longer than the buffer, or the last line without a line end
+		ana.lineBuf = append(ana.lineBuf[:0], buf...)
+		//nolint:errcheck
+		ana.reader.Discard(len(buf))
+		return ana.lineBuf, isPrefix, nil
+	}
+}
+
+// nextInFile closes the input that was read to its end and switches to the next one, if any.
+func (ana *Analyzer) nextInFile() bool {
+	if closer, ok := ana.inFile.(io.Closer); ok && ana.closeInFiles {
+		//nolint:errcheck
+		closer.Close()
+		// Keep returning io.EOF rather than reading from the closed file.
+		ana.reader = ana.newLineReader(bytes.NewReader(nil))
+	}
+	ana.inFile = nil
+	if len(ana.inFiles) == 0 {
+		return false
 	}
+	ana.inFile = ana.inFiles[0]
+	ana.reader = ana.newLineReader(ana.inFile)
+	ana.inFiles = ana.inFiles[1:]
+	ana.skipLF = false
+	if !ana.KeepReassembly {
+		for i := range ana.reassemblyBuffer {
+			ana.reassemblyBuffer[i].used = false
+			ana.reassemblyBuffer[i].frames = 0
+		}
+	}
+	return true
 }
 
 // Run performs analysis.
 func (ana *Analyzer) Run() error {
+	return ana.RunContext(context.Background())
+}
+
+// RunContext performs analysis until the input ends or the context is done, in
+// which case the context's error is returned. The context is checked between messages.
+func (ana *Analyzer) RunContext(ctx context.Context) error {
+	if ana.flusher != nil {
+		//nolint:errcheck
+		defer ana.flusher.Flush()
+	}
+	if ana.Validate {
+		return ana.runValidate(ctx)
+	}
+	if ana.CodecCheckDir != "" {
+		return ana.runCodecCheck(ctx)
+	}
+	if ana.ShowJSON && ana.ShowJSONArray {
+		// Keep the output valid JSON when stopped by a read error or the context too.
+		defer ana.closeJSONArray(ana.OutFile)
+	}
+
 	if !ana.ShowJSON {
 		ana.Logger.Info("N2K packet analyzer\n" + common.Copyright)
-	} else if ana.ShowVersion {
+	} else if ana.ShowVersion && !ana.ShowJSONArray {
 		siStr := "si"
 		if !ana.showSI {
 			siStr = "std"
@@ -389,13 +825,39 @@ func (ana *Analyzer) Run() error {
 	}
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		rawMsg, err := ana.ReadRawMessage()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
+				if ana.Compact {
+					for _, msg := range ana.FlushPartialFastPackets() {
+						ana.printCompactMessage(msg)
+					}
+				}
 				return nil
 			}
 			return err
 		}
+		if ana.Passthrough {
+			if ana.matchesFilters(rawMsg) {
+				fmt.Fprintf(ana.OutFile, "%s\n", ana.line)
+			}
+			continue
+		}
+		if ana.Compact {
+			ana.printCompact(rawMsg)
+			continue
+		}
+		if ana.Candump {
+			ana.printCandump(rawMsg)
+			continue
+		}
+		if ana.Normalize {
+			ana.printNormalized(rawMsg)
+			continue
+		}
 		if err := ana.printCanFormat(rawMsg, ana.OutFile); err != nil {
 			return err
 		}
@@ -403,6 +865,33 @@ func (ana *Analyzer) Run() error {
 	}
 }
 
+// printCompact prints the message the raw message completes, if any, as FormatMessageCompact does.
+func (ana *Analyzer) printCompact(rawMsg *common.RawMessage) {
+	if !ana.matchesFilters(rawMsg) {
+		return
+	}
+	msg, err := ana.convertRawMessage(rawMsg)
+	for _, partial := range ana.takePartialMsgs() {
+		ana.printCompactMessage(partial)
+	}
+	if err != nil {
+		if !errors.Is(err, ErrFastPacketIncomplete) {
+			//nolint:errcheck
+			ana.Logger.Error("PGN %d from %d: %v\n", rawMsg.PGN, rawMsg.Src, err)
+		}
+		return
+	}
+	ana.printCompactMessage(msg)
+}
+
+// printCompactMessage prints the message unless ChangesOnly skips it.
+func (ana *Analyzer) printCompactMessage(msg *common.Message) {
+	if ana.ChangesOnly && !ana.fieldsChanged(uint32(msg.Pgn), uint8(msg.Src), msg.Fields) {
+		return
+	}
+	fmt.Fprintf(ana.OutFile, "%s\n", FormatMessageCompact(msg))
+}
+
 // RawFormat is the format that raw data is serialized into.
 type RawFormat string
 
@@ -419,6 +908,7 @@ const (
 	RawFormatYDWG02            RawFormat = "YDWG02"
 	RawFormatNavLink2          RawFormat = "NAVLINK2"
 	RawFormatActisenseN2KASCII RawFormat = "ACTISENSE_N2K_ASCII"
+	RawFormatMiniPlex          RawFormat = "MINIPLEX"
 )
 
 // RawFormats is the list of all supported/known raw formats.
@@ -434,6 +924,7 @@ var RawFormats = []RawFormat{
 	RawFormatYDWG02,
 	RawFormatNavLink2,
 	RawFormatActisenseN2KASCII,
+	RawFormatMiniPlex,
 }
 
 type geoFormat byte
@@ -444,25 +935,51 @@ const (
 	geoFormatDMS
 )
 
-type multipackets byte
+// StringEncoding is how the bytes of a string field are interpreted.
+type StringEncoding byte
 
 const (
-	multipacketsCoalesced multipackets = iota
-	multipacketsSeparate
+	// StringEncodingUTF8 interprets strings as UTF-8; invalid sequences print as U+FFFD.
+	StringEncodingUTF8 StringEncoding = iota
+	// StringEncodingASCII interprets strings as 7-bit ASCII; other bytes become '?'.
+	StringEncodingASCII
+	// StringEncodingLatin1 interprets strings as ISO 8859-1, mapping every byte to the
+	// rune with the same value.
+	StringEncodingLatin1
 )
 
+// MultiPackets is how fast-packet PGNs arrive: already reassembled into one message
+// (coalesced) or as the separate CAN frames.
+type MultiPackets byte
+
+// The ways fast-packet PGNs can arrive.
+const (
+	MultiPacketsCoalesced MultiPackets = iota
+	MultiPacketsSeparate
+)
+
+// multiPackets returns how fast-packet PGNs arrive: as forced by ForceMultiPackets, or
+// else as claimed by the input format.
+func (ana *Analyzer) multiPackets() MultiPackets {
+	if ana.ForceMultiPackets != nil {
+		return *ana.ForceMultiPackets
+	}
+	return ana.multipackets
+}
+
 //nolint:lll
 func usage(progNameAsExeced, invalidArgName string, writer io.Writer) error {
 	fmt.Fprintf(writer, "Unknown or invalid argument %s\n", invalidArgName)
-	fmt.Fprintf(writer, "Usage: %s [[-raw] [-json [-empty] [-nv] [-camel | -upper-camel]] [-data] [-debug] [-d] [-q] [-si] [-geo {dd|dm|dms}] "+
-		"-format <fmt> "+
-		"[-src <src> | -dst <dst> | <pgn>]] ["+
-		"-Clocksrc <src> | "+
+	fmt.Fprintf(writer, "Usage: %s [[-raw | -seq] [-json [-empty] [-nv] [-json-array] [-camel | -upper-camel]] [-data | -data-base64] [-debug] [-d] [-q] [-si] [-geo {dd|dm|dms}] [-string-encoding {utf8|ascii|latin1}] "+
+		"[-format <fmt> [-strict]] [-changes-only] "+
+		"[-src <src> | -dst <dst> | -prio <prio>[,<prio>...] | <pgn>]] [-file <file> ... [-continue-reassembly]] ["+
+		"-Clocksrc <src> | -flush <interval> | -validate | -codec-check-dir <dir> | -passthrough | -compact | -candump | -normalize | "+
 		"-version\n",
 		progNameAsExeced)
 	fmt.Fprintf(writer, "     -json             Output in json format, for program consumption. Empty values are skipped\n")
 	fmt.Fprintf(writer, "     -empty            Modified json format where empty values are shown as NULL\n")
 	fmt.Fprintf(writer, "     -nv               Modified json format where lookup values are shown as name, value pair\n")
+	fmt.Fprintf(writer, "     -json-array       Modified json format where all messages are elements of a single array\n")
 	fmt.Fprintf(writer, "     -camel            Show fieldnames in normalCamelCase\n")
 	fmt.Fprintf(writer, "     -upper-camel      Show fieldnames in UpperCamelCase\n")
 	fmt.Fprintf(writer, "     -d                Print logging from level ERROR, INFO and DEBUG\n")
@@ -471,16 +988,34 @@ func usage(progNameAsExeced, invalidArgName string, writer io.Writer) error {
 	fmt.Fprintf(writer, "     -geo dd           Print geographic format in dd.dddddd format\n")
 	fmt.Fprintf(writer, "     -geo dm           Print geographic format in dd.mm.mmm format\n")
 	fmt.Fprintf(writer, "     -geo dms          Print geographic format in dd.mm.sss format\n")
+	fmt.Fprintf(writer, "     -string-encoding utf8   Interpret string fields as UTF-8 (default)\n")
+	fmt.Fprintf(writer, "     -string-encoding ascii  Interpret string fields as ASCII, showing other bytes as '?'\n")
+	fmt.Fprintf(writer, "     -string-encoding latin1 Interpret string fields as Latin-1 (ISO 8859-1)\n")
+	fmt.Fprintf(writer, "     -prio <list>      Only show messages with one of these comma separated priorities (0-7)\n")
 	fmt.Fprintf(writer, "     -Clocksrc         Set the systemclock from time info from this NMEA source address\n")
+	fmt.Fprintf(writer, "     -flush <interval> Batch output and flush it every interval (e.g. 500ms) instead of per message\n")
+	fmt.Fprintf(writer, "     -file <file>      Read from file instead of stdin; repeat to read several files in order\n")
+	fmt.Fprintf(writer, "     -continue-reassembly Keep partial fast packets when moving on to the next file\n")
+	fmt.Fprintf(writer, "     -lenient          Accept PLAIN lines that leave out the destination and length, or the timestamp\n")
+	fmt.Fprintf(writer, "     -passthrough      Write the input lines of matching messages unchanged instead of decoding them\n")
+	fmt.Fprintf(writer, "     -compact          Print each message on one line as pgn=<pgn> src=<src> followed by camelCase field=value pairs\n")
+	fmt.Fprintf(writer, "     -changes-only     Only print a message when its fields differ from the last one of its PGN from its source\n")
+	fmt.Fprintf(writer, "     -candump          Print the CAN frames of each message as <canid>#<data>, as cansend takes them\n")
+	fmt.Fprintf(writer, "     -normalize        Print each message in PLAIN format, one line per frame, whatever the input format\n")
+	fmt.Fprintf(writer, "     -validate         Decode all input without printing it, then report statistics and errors\n")
+	fmt.Fprintf(writer, "     -codec-check-dir <dir> Check that every message in the files in dir comes back equal from the binary codec\n")
 	fmt.Fprintf(writer, "     -format <fmt>     Select a particular format, either: ")
 	for _, format := range RawFormats {
 		fmt.Fprintf(writer, "%s, ", format)
 	}
 	fmt.Fprintf(writer, "\n")
+	fmt.Fprintf(writer, "     -strict           With -format, stop at the first line not in that format instead of skipping it\n")
 	fmt.Fprintf(writer, "     -version          Print the version of the program and quit\n")
 	fmt.Fprintf(writer, "\nThe following options are used to debug the analyzer:\n")
 	fmt.Fprintf(writer, "     -raw              Print the PGN in a format suitable to be fed to analyzer again (in standard raw format)\n")
+	fmt.Fprintf(writer, "     -seq              As -raw, with the fast-packet sequence id in a trailing seq= column\n")
 	fmt.Fprintf(writer, "     -data             Print the PGN three times: in hex, ascii and analyzed\n")
+	fmt.Fprintf(writer, "     -data-base64      As -data, and also print the PGN in base64\n")
 	fmt.Fprintf(writer, "     -debug            Print raw value per field\n")
 	fmt.Fprintf(writer, "     -fixtime str      Print str as timestamp in logging\n")
 	fmt.Fprintf(writer, "\n")
@@ -495,6 +1030,42 @@ type packet struct {
 	pgn       int
 	src       int
 	used      bool
+
+	// The header of the first frame, to decode a partial packet with.
+	timestamp string
+	prio      uint8
+	dst       uint8
+	seq       int
+}
+
+// receivedData returns the data received without a gap from the first frame, or nil
+// when the first frame is missing.
+func (p *packet) receivedData() []byte {
+	if p.frames&1 == 0 {
+		return nil
+	}
+	frames := bits.TrailingZeros32(^p.frames)
+	size := common.FastPacketBucket0Size + (frames-1)*common.FastPacketBucketNSize
+	if size > p.size {
+		size = p.size
+	}
+	return p.data[:size]
+}
+
+// checkFrames returns ErrFastPacketCorrupt when the first frame has been received and
+// the size it declares does not fit in a fast packet or the frames received go past it.
+func (p *packet) checkFrames() error {
+	if p.frames&1 == 0 {
+		return nil
+	}
+	if p.size > common.FastPacketMaxSize {
+		return fmt.Errorf("%w: size %d is more than %d", ErrFastPacketCorrupt, p.size, common.FastPacketMaxSize)
+	}
+	if extra := p.frames &^ p.allFrames; extra != 0 {
+		return fmt.Errorf("%w: frame %d is past the %d frames of size %d",
+			ErrFastPacketCorrupt, bits.Len32(extra)-1, bits.OnesCount32(p.allFrames), p.size)
+	}
+	return nil
 }
 
 const reassemblyBufferSize = 64
@@ -514,31 +1085,24 @@ func (ana *Analyzer) showBuffers() {
 	}
 }
 
-func (ana *Analyzer) detectFormat(msg string) RawFormat {
+// detectLineFormat returns the format the line looks to be in, or RawFormatUnknown.
+func detectLineFormat(msg string) RawFormat {
 	if msg[0] == '$' && msg == "$PCDIN" {
-		ana.Logger.Info("Detected Chetco protocol with all data on one line\n")
-		ana.multipackets = multipacketsCoalesced
 		return RawFormatChetco
 	}
 
 	if msg == "Sequence #,Timestamp,PGN,Name,Manufacturer,Remote Address,Local Address,Priority,Single Frame,Size,packet\n" {
-		ana.Logger.Info("Detected Garmin CSV protocol with relative timestamps\n")
-		ana.multipackets = multipacketsCoalesced
 		return RawFormatGarminCSV1
 	}
 
 	if msg ==
 		"Sequence #,Month_Day_Year_Hours_Minutes_Seconds_msTicks,PGN,Processed PGN,Name,Manufacturer,Remote Address,Local "+
 			"Address,Priority,Single Frame,Size,packet\n" {
-		ana.Logger.Info("Detected Garmin CSV protocol with absolute timestamps\n")
-		ana.multipackets = multipacketsCoalesced
 		return RawFormatGarminCSV2
 	}
 
 	p := strings.Index(msg, " ")
-	if p != -1 && (msg[p+1] == '-' || msg[p+2] == '-') {
-		ana.Logger.Info("Detected Airmar protocol with all data on one line\n")
-		ana.multipackets = multipacketsCoalesced
+	if p != -1 && ((p+1 < len(msg) && msg[p+1] == '-') || (p+2 < len(msg) && msg[p+2] == '-')) {
 		return RawFormatAirmar
 	}
 
@@ -547,105 +1111,102 @@ func (ana *Analyzer) detectFormat(msg string) RawFormat {
 		var e rune
 		r, _ := fmt.Sscanf(msg, "%d:%d:%d.%d %c %02X ", &a, &b, &c, &d, &e, &f)
 		if r == 6 && (e == 'R' || e == 'T') {
-			ana.Logger.Info("Detected YDWG-02 protocol with one line per frame\n")
-			ana.multipackets = multipacketsSeparate
 			return RawFormatYDWG02
 		}
 	}
 
 	{
-		var a, b, c, d int
-		var e float64
-		var f string
-		r, _ := fmt.Sscanf(msg, "!PDGY,%d,%d,%d,%d,%f,%s ", &a, &b, &c, &d, &e, &f)
-		if r == 6 {
-			ana.Logger.Info("Detected Digital Yacht NavLink2 protocol with one line per frame\n")
-			ana.multipackets = multipacketsCoalesced
+		var rawMsg common.RawMessage
+		if common.ParseRawFormatNavLink2([]byte(msg), &rawMsg, common.NewLogger(io.Discard)) == 0 ||
+			strings.HasPrefix(msg, string(navLink2StatusPrefix)) {
 			return RawFormatNavLink2
 		}
 	}
 
+	if strings.HasPrefix(msg, "$MXPGN,") {
+		return RawFormatMiniPlex
+	}
+
 	{
 		var a, b, c, d int
 		r1, _ := fmt.Sscanf(msg, "A%d.%d %x %x ", &a, &b, &c, &d)
 		r2, _ := fmt.Sscanf(msg, "A%d %x %x ", &a, &b, &c)
 		if r1 == 4 || r2 == 3 {
-			ana.Logger.Info("Detected Actisense N2K Ascii protocol with all frames on one line\n")
-			ana.multipackets = multipacketsCoalesced
 			return RawFormatActisenseN2KASCII
 		}
 	}
 
 	p = strings.Index(msg, ",")
 	if p != -1 {
-		// NOTE(erd): this is a hacky af departure from the c code where it
-		// can somehow use sscanf to count the number of hexes with
+		// Count the hex values after the header, like the C code does with
 		// sscanf(p, ",%*u,%*u,%*u,%*u,%d,%*x,%*x,%*x,%*x,%*x,%*x,%*x,%*x,%*x", &len);
 		var a, b, c, d, e int
-		var hexes [9]hexScanner
-		r, _ := fmt.Sscanf(
-			msg[p:],
-			",%d,%d,%d,%d,%d,%x,%x,%x,%x,%x,%x,%x,%x,%x",
-			&a, &b, &c, &d, &e, &hexes[0], &hexes[1], &hexes[2], &hexes[3], &hexes[4], &hexes[5], &hexes[6], &hexes[7], &hexes[8],
-		)
+		scanner := common.NewScanner([]byte(msg[p:]))
+		r := common.ScanInts(&scanner, &a, &b, &c, &d, &e)
 		if r < 1 {
 			return RawFormatUnknown
 		}
 		var countHex int
-		for _, h := range hexes {
-			if h.isSet {
-				countHex++
+		for r == 5 && countHex < 9 && scanner.Literal(",") {
+			if _, ok := scanner.Hex(); !ok {
+				break
 			}
+			countHex++
 		}
 		if countHex > 8 {
-			ana.Logger.Info("Detected normal format with all frames on one line\n")
-			ana.multipackets = multipacketsCoalesced
 			return RawFormatFast
 		}
-		ana.Logger.Info("Assuming normal format with one line per frame\n")
-		ana.multipackets = multipacketsSeparate
 		return RawFormatPlain
 	}
 
 	return RawFormatUnknown
 }
 
-type hexScanner struct {
-	val   int
-	isSet bool
+// matchesFilters tells whether the message passes the source, destination, PGN and
+// priority filters.
+func (ana *Analyzer) matchesFilters(msg *common.RawMessage) bool {
+	if ana.OnlySrc >= 0 && ana.OnlySrc != int64(msg.Src) {
+		return false
+	}
+	if ana.OnlyDst >= 0 && ana.OnlyDst != int64(msg.Dst) {
+		return false
+	}
+	if ana.OnlyPgn > 0 && ana.OnlyPgn != int64(msg.PGN) {
+		return false
+	}
+	return ana.matchesPrio(msg)
 }
 
-func (h *hexScanner) Scan(state fmt.ScanState, _ rune) error {
-	n, err := fmt.Fscanf(state, "%x", &h.val)
-	if n > 0 {
-		h.isSet = true
+// matchesPrio tells whether the message has one of the priorities in FilterPrio, if set.
+func (ana *Analyzer) matchesPrio(msg *common.RawMessage) bool {
+	if len(ana.FilterPrio) == 0 {
+		return true
 	}
-	return err
+	for _, prio := range ana.FilterPrio {
+		if prio == msg.Prio {
+			return true
+		}
+	}
+	return false
 }
 
 func (ana *Analyzer) printCanFormat(
 	msg *common.RawMessage,
 	writer io.Writer,
 ) error {
-	if ana.OnlySrc >= 0 && ana.OnlySrc != int64(msg.Src) {
-		return nil
-	}
-	if ana.OnlyDst >= 0 && ana.OnlyDst != int64(msg.Dst) {
-		return nil
-	}
-	if ana.OnlyPgn > 0 && ana.OnlyPgn != int64(msg.PGN) {
+	if !ana.matchesFilters(msg) {
 		return nil
 	}
 
 	pgn, _ := ana.searchForPgn(msg.PGN)
-	if ana.multipackets == multipacketsSeparate && pgn == nil {
+	if ana.multiPackets() == MultiPacketsSeparate && pgn == nil {
 		var err error
 		pgn, err = ana.searchForUnknownPgn(msg.PGN)
 		if err != nil {
 			return err
 		}
 	}
-	if ana.multipackets == multipacketsCoalesced || pgn == nil || pgn.packetType != packetTypeFast {
+	if ana.multiPackets() == MultiPacketsCoalesced || pgn == nil || pgn.packetType != packetTypeFast {
 		// No reassembly needed
 		if err := ana.printPgn(msg, msg.Data[:msg.Len], writer); err != nil {
 			return err
@@ -707,13 +1268,20 @@ func (ana *Analyzer) printCanFormat(
 			p.frames = 0
 		}
 
-		if frame == 0 && p.frames == 0 {
+		if frame == 0 {
 			p.size = int(msg.Data[1])
 			p.allFrames = (1 << (1 + (p.size / 7))) - 1
 		}
 
 		copy(p.data[idx:], msg.Data[msgIdx:msgIdx+frameLen])
 		p.frames |= 1 << frame
+		if err := p.checkFrames(); err != nil {
+			//nolint:errcheck
+			ana.Logger.Error("PGN %d from source %d: %v\n", msg.PGN, msg.Src, err)
+			p.used = false
+			p.frames = 0
+			return nil
+		}
 
 		ana.Logger.Debug("Using buffer %d for reassembly of PGN %d: size %d frame %d sequence %d idx=%d frames=%x mask=%x\n",
 			buffer,
@@ -751,6 +1319,11 @@ func (ana *Analyzer) printPgn(
 	if pgn == nil {
 		return ana.Logger.Abort("No PGN definition found for PGN %d\n", msg.PGN)
 	}
+	if ana.CollectTimings {
+		defer ana.recordTiming(msg.PGN, time.Now())
+	}
+	description := pgnLabel(pgn)
+	start := ana.pb.Location()
 
 	if ana.ShowData {
 		f := ana.OutFile
@@ -759,13 +1332,13 @@ func (ana *Analyzer) printPgn(
 			f = ana.OutErrFile
 		}
 
-		fmt.Fprintf(f, "%s %d %3d %3d %6d %s: ", msg.Timestamp, msg.Prio, msg.Src, msg.Dst, msg.PGN, pgn.description)
+		fmt.Fprintf(f, "%s %d %3d %3d %6d %s: ", msg.Timestamp, msg.Prio, msg.Src, msg.Dst, msg.PGN, description)
 		for i := 0; i < len(data); i++ {
 			fmt.Fprintf(f, " %2.02X", data[i])
 		}
 		fmt.Fprint(f, '\n')
 
-		fmt.Fprintf(f, "%s %d %3d %3d %6d %s: ", msg.Timestamp, msg.Prio, msg.Src, msg.Dst, msg.PGN, pgn.description)
+		fmt.Fprintf(f, "%s %d %3d %3d %6d %s: ", msg.Timestamp, msg.Prio, msg.Src, msg.Dst, msg.PGN, description)
 		for i := 0; i < len(data); i++ {
 			char := '.'
 			if unicode.IsNumber(rune(data[i])) || unicode.IsLetter(rune(data[i])) {
@@ -774,8 +1347,18 @@ func (ana *Analyzer) printPgn(
 			fmt.Fprintf(f, "  %c", char)
 		}
 		fmt.Fprint(f, '\n')
+
+		if ana.ShowDataBase64 {
+			fmt.Fprintf(f, "%s %d %3d %3d %6d %s: %s\n",
+				msg.Timestamp, msg.Prio, msg.Src, msg.Dst, msg.PGN, description, base64.RawStdEncoding.EncodeToString(data))
+		}
 	}
 	if ana.ShowJSON {
+		// A keyed message must be wrapped in an object to be an element of the array
+		wrapKeyed := pgn.camelDescription != "" && ana.ShowJSONArray
+		if wrapKeyed {
+			ana.pb.Printf("{")
+		}
 		if pgn.camelDescription != "" {
 			ana.pb.Printf("\"%s\":", pgn.camelDescription)
 		}
@@ -785,13 +1368,17 @@ func (ana *Analyzer) printPgn(
 			msg.Src,
 			msg.Dst,
 			msg.PGN,
-			pgn.description)
+			description)
 		ana.closingBraces = "}"
+		if wrapKeyed {
+			ana.closingBraces = "}}"
+		}
 		ana.sep = ",\"fields\":{"
 	} else {
-		ana.pb.Printf("%s %d %3d %3d %6d %s:", msg.Timestamp, msg.Prio, msg.Src, msg.Dst, msg.PGN, pgn.description)
+		ana.pb.Printf("%s %d %3d %3d %6d %s:", msg.Timestamp, msg.Prio, msg.Src, msg.Dst, msg.PGN, description)
 		ana.sep = " "
 	}
+	fieldsStart := ana.pb.Location()
 
 	ana.Logger.Debug("fieldCount=%d repeatingStart1=%d\n", pgn.fieldCount, pgn.repeatingStart1)
 
@@ -896,6 +1483,13 @@ func (ana *Analyzer) printPgn(
 	ana.pb.Printf("\n")
 
 	if r {
+		if ana.ChangesOnly && !ana.printedChanged(msg.PGN, msg.Src, ana.pb.Since(fieldsStart)) {
+			ana.pb.Set(start)
+			return nil
+		}
+		if ana.ShowJSON && ana.ShowJSONArray {
+			ana.writeJSONArraySeparator(writer)
+		}
 		ana.pb.Write(writer)
 		if variableFields > 0 && ana.variableFieldRepeat[0] < math.MaxUint8 {
 			//nolint:errcheck
@@ -1012,7 +1606,7 @@ func (ana *Analyzer) printField(
 		field.proprietary,
 		ana.refPgn)
 
-	if field.proprietary {
+	if field.proprietary && !ana.AlwaysDecodeProprietary {
 		if (ana.refPgn >= 65280 && ana.refPgn <= 65535) ||
 			(ana.refPgn >= 126720 && ana.refPgn <= 126975) ||
 			(ana.refPgn >= 130816 && ana.refPgn <= 131071) {
@@ -1037,13 +1631,13 @@ func (ana *Analyzer) printField(
 				return false, err
 			}
 			if ana.ShowJSON {
-				ana.pb.Printf("%s\"%s\":", sep, fieldName)
+				ana.pb.Printf("%s\"%s\":", sep, fieldLabel(field, fieldName))
 				ana.sep = ","
 				if ana.ShowBytes || ana.ShowJSONValue {
 					location2 = ana.pb.Location()
 				}
 			} else {
-				ana.pb.Printf("%s %s = ", sep, fieldName)
+				ana.pb.Printf("%s %s = ", sep, fieldLabel(field, fieldName))
 				ana.sep = ";"
 			}
 		}
@@ -1184,13 +1778,7 @@ func fieldPrintVariable(
 func (ana *Analyzer) printCanRaw(msg *common.RawMessage) {
 	f := ana.OutFile
 
-	if ana.OnlySrc >= 0 && ana.OnlySrc != int64(msg.Src) {
-		return
-	}
-	if ana.OnlyDst >= 0 && ana.OnlyDst != int64(msg.Dst) {
-		return
-	}
-	if ana.OnlyPgn > 0 && ana.OnlyPgn != int64(msg.PGN) {
+	if !ana.matchesFilters(msg) {
 		return
 	}
 
@@ -1198,32 +1786,100 @@ func (ana *Analyzer) printCanRaw(msg *common.RawMessage) {
 		f = ana.OutErrFile
 	}
 
-	if ana.ShowRaw && (ana.OnlyPgn != 0 || ana.OnlyPgn == int64(msg.PGN)) {
+	if ana.ShowRaw && (ana.OnlyPgn == 0 || ana.OnlyPgn == int64(msg.PGN)) {
 		fmt.Fprintf(f, "%s,%d,%d,%d,%d,%d", msg.Timestamp, msg.Prio, msg.PGN, msg.Src, msg.Dst, msg.Len)
 		for i := uint8(0); i < msg.Len; i++ {
 			fmt.Fprintf(f, ",%02x", msg.Data[i])
 		}
+		if ana.ShowRawSequence {
+			fmt.Fprintf(f, ",seq=%d", ana.rawSequence(msg))
+		}
 		fmt.Fprintf(f, "\n")
 	}
 }
 
+// writeJSONArraySeparator writes what goes before the next message of a JSON array.
+func (ana *Analyzer) writeJSONArraySeparator(writer io.Writer) {
+	if ana.jsonArrayLen == 0 {
+		fmt.Fprint(writer, "[\n")
+	} else {
+		fmt.Fprint(writer, ",")
+	}
+	ana.jsonArrayLen++
+}
+
+// closeJSONArray ends the JSON array, which is empty when no message was written.
+func (ana *Analyzer) closeJSONArray(writer io.Writer) {
+	if ana.jsonArrayLen == 0 {
+		fmt.Fprint(writer, "[\n")
+	}
+	fmt.Fprint(writer, "]\n")
+}
+
+// rawSequence returns the fast-packet sequence id of a raw message: that of the frame
+// itself when frames are read separately, otherwise the one it was read with.
+func (ana *Analyzer) rawSequence(msg *common.RawMessage) uint8 {
+	if ana.multiPackets() == MultiPacketsSeparate && msg.Len > 0 {
+		if pgn, _ := ana.searchForPgn(msg.PGN); pgn != nil && pgn.packetType == packetTypeFast {
+			return msg.Data[0] >> 5
+		}
+	}
+	return msg.Sequence
+}
+
+// ConvertRawMessages converts the given raw messages in order, reassembling fast-packet
+// frames along the way, and returns the messages that were completed. Fast packets still
+// being reassembled carry over to the next call; see FlushPartialFastPackets for the end
+// of the input.
+func (ana *Analyzer) ConvertRawMessages(rawMsgs []*common.RawMessage) ([]*common.Message, error) {
+	msgs := make([]*common.Message, 0, len(rawMsgs))
+	for _, rawMsg := range rawMsgs {
+		if !ana.matchesPrio(rawMsg) {
+			continue
+		}
+		msg, err := ana.convertRawMessage(rawMsg)
+		msgs = append(msgs, ana.takePartialMsgs()...)
+		if err != nil {
+			if errors.Is(err, ErrFastPacketIncomplete) {
+				continue
+			}
+			return msgs, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
 func (ana *Analyzer) convertRawMessage(rawMsg *common.RawMessage) (*common.Message, error) {
 	pgn, _ := ana.searchForPgn(rawMsg.PGN)
-	if ana.multipackets == multipacketsSeparate && pgn == nil {
+	if ana.multiPackets() == MultiPacketsSeparate && pgn == nil {
 		var err error
 		pgn, err = ana.searchForUnknownPgn(rawMsg.PGN)
 		if err != nil {
 			return nil, err
 		}
 	}
-	if ana.multipackets == multipacketsCoalesced || pgn == nil || pgn.packetType != packetTypeFast {
+	if rawMsg.Len == 0 {
+		// A frame without data, such as a request logged by its CAN id only
+		return ana.convertPGN(rawMsg, rawMsg.Data[:0])
+	}
+	if ana.multiPackets() == MultiPacketsCoalesced || pgn == nil || pgn.packetType != packetTypeFast {
 		// No reassembly needed
+		if pgn != nil && uint32(rawMsg.Len)*8 < pgn.fieldList[0].size {
+			field := &pgn.fieldList[0]
+			return nil, fmt.Errorf("%w: PGN %d field '%s' at bit 0 needs %d bytes, %d present",
+				ErrInsufficientData, pgn.pgn, field.name, (field.size+7)/8, rawMsg.Len)
+		}
 		return ana.convertPGN(rawMsg, rawMsg.Data[:rawMsg.Len])
 	}
 
 	// Fast packet requires re-asssembly
 	// We only get here if we know for sure that the PGN is fast-packet
 	// Possibly it is of unknown length when the PGN is unknown.
+	if rawMsg.Data[0]&0x1f == 0 && rawMsg.Len < 2 {
+		// The first frame must at least carry the total size
+		return nil, ErrInsufficientData
+	}
 
 	var buffer int
 	var p *packet
@@ -1270,16 +1926,29 @@ func (ana *Analyzer) convertRawMessage(rawMsg *common.RawMessage) (*common.Messa
 		if (p.frames & (1 << frame)) != 0 {
 			//nolint:errcheck
 			ana.Logger.Error("Received incomplete fast packet PGN %d from source %d\n", rawMsg.PGN, rawMsg.Src)
+			ana.incompleteFastPackets++
+			if ana.DecodePartialFastPackets {
+				ana.decodePartialPacket(p)
+			}
 			p.frames = 0
 		}
 
-		if frame == 0 && p.frames == 0 {
+		if frame == 0 {
 			p.size = int(rawMsg.Data[1])
 			p.allFrames = (1 << (1 + (p.size / 7))) - 1
+			p.timestamp = rawMsg.Timestamp
+			p.prio = rawMsg.Prio
+			p.dst = rawMsg.Dst
+			p.seq = int(seq >> 5)
 		}
 
 		copy(p.data[idx:], rawMsg.Data[msgIdx:msgIdx+frameLen])
 		p.frames |= 1 << frame
+		if err := p.checkFrames(); err != nil {
+			p.used = false
+			p.frames = 0
+			return nil, err
+		}
 
 		ana.Logger.Debug("Using buffer %d for reassembly of PGN %d: size %d frame %d sequence %d idx=%d frames=%x mask=%x\n",
 			buffer,
@@ -1296,14 +1965,109 @@ func (ana *Analyzer) convertRawMessage(rawMsg *common.RawMessage) (*common.Messa
 			if err != nil {
 				return nil, err
 			}
+			msg.Frames = bits.OnesCount32(p.allFrames)
+			msg.Sequence = int(seq >> 5)
 			p.used = false
 			p.frames = 0
 			return msg, nil
 		}
 	}
-	return nil, errors.New("insufficient data")
+	return nil, ErrFastPacketIncomplete
+}
+
+// decodePartialPacket queues the decode of what was received of p for takePartialMsgs.
+func (ana *Analyzer) decodePartialPacket(p *packet) {
+	data := p.receivedData()
+	if data == nil {
+		return
+	}
+	rawMsg := &common.RawMessage{
+		Timestamp: p.timestamp,
+		Prio:      p.prio,
+		PGN:       uint32(p.pgn),
+		Dst:       p.dst,
+		Src:       uint8(p.src),
+		Len:       uint8(len(data)),
+	}
+	copy(rawMsg.Data[:], data)
+	msg, err := ana.convertPGN(rawMsg, data)
+	if err != nil {
+		//nolint:errcheck
+		ana.Logger.Error("Cannot decode partial fast packet PGN %d from source %d: %s\n", p.pgn, p.src, err)
+		return
+	}
+	msg.Frames = bits.TrailingZeros32(^p.frames)
+	msg.Sequence = p.seq
+	msg.Partial = true
+	ana.partialMsgs = append(ana.partialMsgs, msg)
+}
+
+// takePartialMsgs returns the partial fast packets queued since it was last called. Every
+// caller of convertRawMessage takes them, so that they are not lost.
+func (ana *Analyzer) takePartialMsgs() []*common.Message {
+	msgs := ana.partialMsgs
+	ana.partialMsgs = nil
+	return msgs
+}
+
+// FlushPartialFastPackets returns, with DecodePartialFastPackets, what was received of the
+// fast packets still being reassembled as Partial messages, and frees their buffers. Call
+// it at the end of the input given to ConvertRawMessages. ReadMessage, Run, DecodeChannel,
+// ValidateInput and CodecCheckDir do so themselves. Without DecodePartialFastPackets it
+// returns nil and leaves the fast packets be.
+func (ana *Analyzer) FlushPartialFastPackets() []*common.Message {
+	if !ana.DecodePartialFastPackets {
+		return nil
+	}
+	ana.decodePartialPackets()
+	return ana.takePartialMsgs()
+}
+
+// decodePartialPackets queues the decodes of all fast packets still being reassembled
+// and frees their buffers. It tells whether any message was queued.
+func (ana *Analyzer) decodePartialPackets() bool {
+	for i := range ana.reassemblyBuffer {
+		p := &ana.reassemblyBuffer[i]
+		if p.used {
+			ana.decodePartialPacket(p)
+			p.used = false
+			p.frames = 0
+		}
+	}
+	return len(ana.partialMsgs) > 0
+}
+
+// DropSource abandons the fast packets being reassembled from the given source and frees
+// their buffers, e.g. when the device has gone off the bus and will not send the rest.
+func (ana *Analyzer) DropSource(src uint8) {
+	for i := range ana.reassemblyBuffer {
+		p := &ana.reassemblyBuffer[i]
+		if p.used && p.src == int(src) {
+			p.used = false
+			p.frames = 0
+		}
+	}
 }
 
+var (
+	// ErrFastPacketIncomplete is returned for a fast-packet frame that was buffered
+	// because its PGN still needs more frames.
+	ErrFastPacketIncomplete = errors.New("insufficient data: fast packet incomplete")
+	// ErrInsufficientData is returned when a message is too short to be decoded at all;
+	// more frames will not help. This covers a first fast-packet frame without the total
+	// size, and a message of a known PGN with some data but less than its first field,
+	// which earlier versions decoded to a message without fields. A message without any
+	// data decodes to one without fields, and a message that holds at least its first
+	// field is decoded with the fields that fit.
+	ErrInsufficientData = errors.New("insufficient data: payload too short")
+	// ErrFastPacketCorrupt is returned for a fast-packet frame that does not fit the size
+	// the first frame declares; the frames received so far are dropped.
+	ErrFastPacketCorrupt = errors.New("corrupt fast packet")
+	// ErrFormatMismatch is returned with StrictFormat for an input line that is not in
+	// the selected format.
+	ErrFormatMismatch = errors.New("line not in the selected format")
+)
+
 func (ana *Analyzer) convertPGN(rawMsg *common.RawMessage, data []byte) (*common.Message, error) {
 	if rawMsg == nil {
 		return nil, errors.New("expected message")
@@ -1315,15 +2079,66 @@ func (ana *Analyzer) convertPGN(rawMsg *common.RawMessage, data []byte) (*common
 	if pgn == nil {
 		return nil, fmt.Errorf("no PGN definition found for PGN %d", rawMsg.PGN)
 	}
+	if ana.HeaderOnly {
+		return messageHeader(rawMsg, pgn), nil
+	}
+	if ana.CollectTimings {
+		defer ana.recordTiming(rawMsg.PGN, time.Now())
+	}
+	var msg *common.Message
+	if ana.FastPaths {
+		msg, _ = ana.convertFastPath(rawMsg, pgn, data)
+	}
+	if msg == nil {
+		if msg, err = ana.convertPGNWithInfo(rawMsg, pgn, data); err != nil {
+			return nil, err
+		}
+	}
+	for _, postProcess := range ana.PostProcessors {
+		if postProcess == nil {
+			continue
+		}
+		if err := postProcess(msg); err != nil {
+			return nil, fmt.Errorf("post-processing PGN %d: %w", rawMsg.PGN, err)
+		}
+	}
+	return msg, nil
+}
 
-	convertedMsg := &common.Message{
+// ConvertRawMessageAs converts the raw message using the first definition of the given PGN
+// instead of the one its own PGN and match fields select. The returned message reports the
+// given PGN. The raw message must hold the complete payload; fast-packet frames are not
+// reassembled.
+func (ana *Analyzer) ConvertRawMessageAs(rawMsg *common.RawMessage, pgn uint32) (*common.Message, error) {
+	if rawMsg == nil {
+		return nil, errors.New("expected message")
+	}
+	pgnInfo, _ := ana.searchForPgn(pgn)
+	if pgnInfo == nil {
+		return nil, fmt.Errorf("no PGN definition found for PGN %d", pgn)
+	}
+	forcedMsg := *rawMsg
+	forcedMsg.PGN = pgn
+	return ana.convertPGNWithInfo(&forcedMsg, pgnInfo, rawMsg.Data[:rawMsg.Len])
+}
+
+// messageHeader returns a message with the header of the raw message and the description
+// of the given PGN, and no fields.
+func messageHeader(rawMsg *common.RawMessage, pgn *pgnInfo) *common.Message {
+	return &common.Message{
 		Timestamp:   rawMsg.Timestamp,
 		Priority:    int(rawMsg.Prio),
 		Src:         int(rawMsg.Src),
 		Dst:         int(rawMsg.Dst),
 		Pgn:         int(rawMsg.PGN),
-		Description: pgn.description,
+		Description: pgnLabel(pgn),
+
+		CamelDescription: pgn.camelDescription,
 	}
+}
+
+func (ana *Analyzer) convertPGNWithInfo(rawMsg *common.RawMessage, pgn *pgnInfo, data []byte) (*common.Message, error) {
+	convertedMsg := messageHeader(rawMsg, pgn)
 	if pgn.fieldCount == 0 {
 		return convertedMsg, nil
 	}
@@ -1341,7 +2156,9 @@ func (ana *Analyzer) convertPGN(rawMsg *common.RawMessage, data []byte) (*common
 	variableFieldCount := 0
 	var repeatingList []interface{}
 	var repeatingListName string
-	for i := 0; (startBit >> 3) < len(data); i++ {
+	var repeatingEntry map[string]interface{} // The fields of the current repetition
+	var i int
+	for i = 0; (startBit >> 3) < len(data); i++ {
 		field := &pgn.fieldList[i]
 
 		if variableFields == 0 {
@@ -1351,7 +2168,7 @@ func (ana *Analyzer) convertPGN(rawMsg *common.RawMessage, data []byte) (*common
 		if pgn.repeatingCount1 > 0 && field.order == pgn.repeatingStart1 && repetition == 0 {
 			// Only now is ana.variableFieldRepeat set
 			variableFields = int64(pgn.repeatingCount1) * ana.variableFieldRepeat[0]
-			repeatingList = make([]interface{}, 0, variableFields)
+			repeatingList = make([]interface{}, 0, ana.variableFieldRepeat[0])
 			repeatingListName = "list"
 			variableFieldCount = int(pgn.repeatingCount1)
 			variableFieldStart = int(pgn.repeatingStart1)
@@ -1363,19 +2180,24 @@ func (ana *Analyzer) convertPGN(rawMsg *common.RawMessage, data []byte) (*common
 			if repeatingList != nil {
 				convertedMsg.Fields[repeatingListName] = repeatingList
 			}
-			repeatingList = make([]interface{}, 0, variableFields)
+			repeatingList = make([]interface{}, 0, ana.variableFieldRepeat[1])
 			repeatingListName = "list2"
 			variableFieldCount = int(pgn.repeatingCount2)
 			variableFieldStart = int(pgn.repeatingStart2)
 			repetition = 1
 		}
 
-		if variableFields > 0 {
+		repeating := variableFields > 0
+		if repeating {
 			if i+1 == variableFieldStart+variableFieldCount {
 				i = variableFieldStart - 1
 				field = &pgn.fieldList[i]
 				repetition++
 			}
+			if i+1 == variableFieldStart {
+				repeatingEntry = make(map[string]interface{}, variableFieldCount)
+				repeatingList = append(repeatingList, repeatingEntry)
+			}
 			ana.Logger.Debug("variableFields: repetition=%d field=%d variableFieldStart=%d variableFieldCount=%d remaining=%d\n",
 				repetition,
 				i+1,
@@ -1400,22 +2222,38 @@ func (ana *Analyzer) convertPGN(rawMsg *common.RawMessage, data []byte) (*common
 		if err != nil {
 			return nil, err
 		}
+		if ana.fieldObserver != nil {
+			ana.fieldObserver(fieldName, data, startBit, countBits, fieldValue, ok)
+		}
+		if !ok && ana.IncludeEmptyFields && countBits > 0 && !isReservedOrSpare(field) {
+			fieldValue, ok = nil, true
+		}
 		if ok {
-			if repeatingList == nil {
-				convertedMsg.Fields[fieldName] = fieldValue
+			label := fieldLabel(field, fieldName)
+			if ana.IncludeReservedFields && isReservedOrSpare(field) {
+				label = reservedFieldLabel(convertedMsg.Fields, repeatingEntry, label, field)
+			}
+			if repeating {
+				repeatingEntry[label] = fieldValue
 			} else {
-				repeatingList = append(repeatingList, map[string]interface{}{
-					fieldName: fieldValue,
-				})
+				convertedMsg.Fields[label] = fieldValue
 			}
 		}
 
 		startBit += countBits
 	}
+	if ana.IncludeEmptyFields && (startBit>>3) >= len(data) {
+		ana.addFieldsNotPresent(convertedMsg, pgn, i)
+	}
 
 	if repeatingList != nil {
 		convertedMsg.Fields[repeatingListName] = repeatingList
 	}
+	if variableFields > 0 && ana.variableFieldRepeat[0] < math.MaxUint8 {
+		// The count field declared more repetitions than there is data for
+		//nolint:errcheck
+		ana.Logger.Error("PGN %d has %d missing fields in repeating set\n", rawMsg.PGN, variableFields)
+	}
 
 	if rawMsg.PGN == 126992 && ana.currentDate < math.MaxUint16 && ana.currentTime < math.MaxUint32 && ana.ClockSrc == int64(rawMsg.Src) {
 		//nolint:errcheck
@@ -1424,6 +2262,52 @@ func (ana *Analyzer) convertPGN(rawMsg *common.RawMessage, data []byte) (*common
 	return convertedMsg, nil
 }
 
+// addFieldsNotPresent marks the fields from index first on, which the data ended before,
+// as common.FieldNotPresent. Fields of repeating sets are left out, as it is not known how
+// many repetitions there should have been.
+func (ana *Analyzer) addFieldsNotPresent(msg *common.Message, pgn *pgnInfo, first int) {
+	for i := first; i < len(pgn.fieldList); i++ {
+		field := &pgn.fieldList[i]
+		if field.camelName == "" && field.name == "" {
+			return
+		}
+		if isReservedOrSpare(field) || ana.skipProprietaryField(field) || pgn.isRepeatingField(field) {
+			continue
+		}
+		fieldName := field.name
+		if field.camelName != "" {
+			fieldName = field.camelName
+		}
+		msg.Fields[fieldLabel(field, fieldName)] = common.FieldNotPresent
+	}
+}
+
+func isReservedOrSpare(field *pgnField) bool {
+	return field.fieldType == "RESERVED" || field.fieldType == "SPARE"
+}
+
+// reservedFieldLabel returns the label for a reserved or spare field, which is followed by
+// the field's order when an earlier one of the message already has the label.
+func reservedFieldLabel(fields, repeatingEntry map[string]interface{}, label string, field *pgnField) string {
+	_, inFields := fields[label]
+	_, inEntry := repeatingEntry[label]
+	if inFields || inEntry {
+		return fmt.Sprintf("%s%d", label, field.order)
+	}
+	return label
+}
+
+// skipProprietaryField returns whether the field is one that is only present in
+// proprietary PGNs while the referenced PGN is a standard one.
+func (ana *Analyzer) skipProprietaryField(field *pgnField) bool {
+	if !field.proprietary || ana.AlwaysDecodeProprietary {
+		return false
+	}
+	return !((ana.refPgn >= 65280 && ana.refPgn <= 65535) ||
+		(ana.refPgn >= 126720 && ana.refPgn <= 126975) ||
+		(ana.refPgn >= 130816 && ana.refPgn <= 131071))
+}
+
 func (ana *Analyzer) convertField(
 	field *pgnField,
 	fieldName string,
@@ -1467,23 +2351,30 @@ func (ana *Analyzer) convertField(
 		field.proprietary,
 		ana.refPgn)
 
-	if field.proprietary {
-		if (ana.refPgn >= 65280 && ana.refPgn <= 65535) ||
-			(ana.refPgn >= 126720 && ana.refPgn <= 126975) ||
-			(ana.refPgn >= 130816 && ana.refPgn <= 131071) {
-			// proprietary, allow field
-		} else {
-			// standard PGN, skip field
-			*bits = 0
-			return nil, false, nil
-		}
+	if ana.skipProprietaryField(field) {
+		*bits = 0
+		return nil, false, nil
 	}
 
 	if field.ft != nil && field.ft.cf != nil {
 		ana.Logger.Debug(
 			"PGN %d: convertField <%s>, \"%s\": calling function for %s\n", field.pgn.pgn, field.name, fieldName, field.fieldType)
 		ana.skip = false
-		return field.ft.cf(ana, field, fieldName, data, startBit, bits)
+		ana.haveEmptyValue = false
+		cf := field.ft.cf
+		if ana.IncludeReservedFields && isReservedOrSpare(field) {
+			cf = convertFieldBinary
+		}
+		value, ok, err := cf(ana, field, fieldName, data, startBit, bits)
+		if !ok && err == nil && ana.PreserveSentinels && ana.haveEmptyValue {
+			return common.FieldSentinel(-ana.emptyValue), true, nil
+		}
+		return value, ok, err
+	}
+	if ana.SkipUnprintableFields {
+		//nolint:errcheck
+		ana.Logger.Error("PGN %d: no function found to convert field '%s'\n", field.pgn.pgn, fieldName)
+		return nil, false, nil
 	}
 	return nil, false, fmt.Errorf("PGN %d: no function found to convert field '%s'", field.pgn.pgn, fieldName)
 }
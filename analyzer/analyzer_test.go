@@ -0,0 +1,1282 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func newTestAnalyzer(t *testing.T, input string, format RawFormat) *Analyzer {
+	t.Helper()
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(input)
+	conf.SelectedFormat = format
+	conf.multipackets = MultiPacketsCoalesced
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	return ana
+}
+
+func TestDefaultDst(t *testing.T) {
+	const chetco = "$PCDIN,01F119,00000000,0F,2AAF00D1067414FF*59\n"
+
+	ana := newTestAnalyzer(t, chetco, RawFormatChetco)
+	raw, err := ana.ReadRawMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, raw.PGN, test.ShouldEqual, 127257)
+	test.That(t, raw.Src, test.ShouldEqual, 15)
+	test.That(t, raw.Dst, test.ShouldEqual, 255)
+
+	ana = newTestAnalyzer(t, chetco, RawFormatChetco)
+	ana.DefaultDst = 42
+	raw, err = ana.ReadRawMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, raw.Dst, test.ShouldEqual, 42)
+}
+
+func TestReadMessageReassembly(t *testing.T) {
+	const frames = `2022-09-28-11:36:59.668,3,129029,0,255,8,00,2f,e7,95,3d,00,73,d6
+2022-09-28-11:36:59.668,3,129029,0,255,8,01,29,00,da,04,73,db,c9
+2022-09-28-11:36:59.668,3,129029,0,255,8,02,e5,05,80,7d,02,28,5f
+2022-09-28-11:36:59.668,3,129029,0,255,8,03,d6,10,f6,9b,50,6c,05
+2022-09-28-11:36:59.668,3,129029,0,255,8,04,00,00,00,00,13,fc,08
+2022-09-28-11:36:59.668,3,129029,0,255,8,05,6f,00,be,00,dd,f2,ff
+2022-09-28-11:36:59.668,3,129029,0,255,8,06,ff,00,ff,ff,ff,ff,ff
+`
+	ana := newTestAnalyzer(t, frames+frames, RawFormatPlain)
+	ana.multipackets = MultiPacketsSeparate
+
+	for i := 0; i < 2; i++ {
+		msg, err := ana.ReadMessage()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, msg.Pgn, test.ShouldEqual, 129029)
+		test.That(t, msg.Fields["Number of SVs"], test.ShouldEqual, 8)
+	}
+	_, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldEqual, io.EOF)
+}
+
+func TestMultipleInFiles(t *testing.T) {
+	const first = `2022-09-28-11:36:59.668,3,129029,0,255,8,00,2f,e7,95,3d,00,73,d6
+2022-09-28-11:36:59.668,3,129029,0,255,8,01,29,00,da,04,73,db,c9
+2022-09-28-11:36:59.668,3,129029,0,255,8,02,e5,05,80,7d,02,28,5f
+2022-11-14T01:47:30.890Z,2,127251,14,255,8,ff,51,bf,ff,ff,ff,ff,ff
+`
+	const second = `2022-09-28-11:36:59.668,3,129029,0,255,8,03,d6,10,f6,9b,50,6c,05
+2022-09-28-11:36:59.668,3,129029,0,255,8,04,00,00,00,00,13,fc,08
+2022-09-28-11:36:59.668,3,129029,0,255,8,05,6f,00,be,00,dd,f2,ff
+2022-09-28-11:36:59.668,3,129029,0,255,8,06,ff,00,ff,ff,ff,ff,ff
+2022-11-14T01:47:30.891Z,2,127251,14,255,8,ff,51,bf,ff,ff,ff,ff,ff
+`
+	readAll := func(t *testing.T, keepReassembly bool) []int {
+		t.Helper()
+		ana := newTestAnalyzer(t, first, RawFormatPlain)
+		ana.multipackets = MultiPacketsSeparate
+		ana.inFiles = []io.Reader{strings.NewReader(second)}
+		ana.KeepReassembly = keepReassembly
+
+		var pgns []int
+		for {
+			msg, err := ana.ReadMessage()
+			if err != nil {
+				test.That(t, err, test.ShouldEqual, io.EOF)
+				return pgns
+			}
+			pgns = append(pgns, msg.Pgn)
+		}
+	}
+
+	test.That(t, readAll(t, false), test.ShouldResemble, []int{127251, 127251})
+	test.That(t, readAll(t, true), test.ShouldResemble, []int{127251, 129029, 127251})
+}
+
+func TestInFileReadError(t *testing.T) {
+	errRead := errors.New("read failed")
+	ana := newTestAnalyzer(t, "", RawFormatPlain)
+	ana.reader = ana.newLineReader(iotest.ErrReader(errRead))
+	ana.inFiles = []io.Reader{strings.NewReader("2022-11-14T01:47:30.890Z,2,127251,14,255,8,ff,51,bf,ff,ff,ff,ff,ff\n")}
+
+	_, err := ana.ReadRawMessage()
+	test.That(t, err, test.ShouldEqual, errRead)
+	test.That(t, ana.inFiles, test.ShouldHaveLength, 1)
+}
+
+func TestInFilesClosed(t *testing.T) {
+	dir := t.TempDir()
+	var args []string
+	for _, name := range []string{"first.txt", "second.txt"} {
+		path := filepath.Join(dir, name)
+		line := "2022-11-14T01:47:30.890Z,2,127251,14,255,8,ff,51,bf,ff,ff,ff,ff,ff\n"
+		test.That(t, os.WriteFile(path, []byte(line), 0o600), test.ShouldBeNil)
+		args = append(args, "-file", path)
+	}
+	conf, _, err := ParseArgs(append([]string{"analyzer"}, args...))
+	test.That(t, err, test.ShouldBeNil)
+	conf.OutFile = io.Discard
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+
+	for i := 0; i < 2; i++ {
+		_, err := ana.ReadRawMessage()
+		test.That(t, err, test.ShouldBeNil)
+	}
+	for i := 0; i < 2; i++ {
+		_, err = ana.ReadRawMessage()
+		test.That(t, err, test.ShouldEqual, io.EOF)
+	}
+	for _, in := range append([]io.Reader{conf.InFile}, conf.InFiles...) {
+		test.That(t, errors.Is(in.(*os.File).Close(), os.ErrClosed), test.ShouldBeTrue)
+	}
+}
+
+func TestLenientPlain(t *testing.T) {
+	const abbreviated = "2022-11-14T01:47:30.890Z,2,127251,14,00,51,bf,ff,ff,ff,ff,ff\n"
+
+	ana := newTestAnalyzer(t, abbreviated, RawFormatPlain)
+	_, err := ana.ReadRawMessage()
+	test.That(t, err, test.ShouldEqual, io.EOF)
+
+	ana = newTestAnalyzer(t, abbreviated+"2022-11-14T01:47:30.891Z,2,127251,14,7,8,01,51,bf,ff,ff,ff,ff,ff\n", RawFormatPlain)
+	ana.LenientPlain = true
+	raw, err := ana.ReadRawMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, raw.Timestamp, test.ShouldEqual, "2022-11-14T01:47:30.890Z")
+	test.That(t, raw.Prio, test.ShouldEqual, 2)
+	test.That(t, raw.PGN, test.ShouldEqual, 127251)
+	test.That(t, raw.Src, test.ShouldEqual, 14)
+	test.That(t, raw.Dst, test.ShouldEqual, 255)
+	test.That(t, raw.Data[:raw.Len], test.ShouldResemble, []byte{0x00, 0x51, 0xbf, 0xff, 0xff, 0xff, 0xff, 0xff})
+
+	// Complete lines still parse as usual.
+	raw, err = ana.ReadRawMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, raw.Dst, test.ShouldEqual, 7)
+	test.That(t, raw.Data[0], test.ShouldEqual, 0x01)
+}
+
+func TestLenientPlainWithoutTimestamp(t *testing.T) {
+	const timestampless = "2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff\n"
+
+	ana := newTestAnalyzer(t, timestampless, RawFormatPlain)
+	ana.LenientPlain = true
+	ana.Logger.SetFixedTimestamp("test")
+	raw, err := ana.ReadRawMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, raw.Timestamp, test.ShouldEqual, "2022-12-31T23:00:00.000Z")
+	test.That(t, raw.Prio, test.ShouldEqual, 2)
+	test.That(t, raw.PGN, test.ShouldEqual, 127251)
+	test.That(t, raw.Src, test.ShouldEqual, 14)
+	test.That(t, raw.Dst, test.ShouldEqual, 255)
+	test.That(t, raw.Data[:raw.Len], test.ShouldResemble, []byte{0x00, 0x51, 0xbf, 0xff, 0xff, 0xff, 0xff, 0xff})
+
+	msg, err := ana.convertRawMessage(raw)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Description, test.ShouldEqual, "Rate of Turn")
+}
+
+func TestStrictFormat(t *testing.T) {
+	const (
+		plain = "2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff\n"
+		ydwg  = "16:29:27.082 R 09F8017F 50 C3 B8 13 47 D8 2B C6\n"
+	)
+
+	// By default the line that is not PLAIN is logged and skipped
+	ana := newTestAnalyzer(t, plain+ydwg, RawFormatPlain)
+	_, err := ana.ReadRawMessage()
+	test.That(t, err, test.ShouldBeNil)
+	_, err = ana.ReadRawMessage()
+	test.That(t, err, test.ShouldEqual, io.EOF)
+
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(plain + ydwg + plain)
+	conf.SelectedFormat = RawFormatPlain
+	conf.StrictFormat = true
+	ana, err = NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	raw, err := ana.ReadRawMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, raw.PGN, test.ShouldEqual, 127251)
+	_, err = ana.ReadRawMessage()
+	test.That(t, errors.Is(err, ErrFormatMismatch), test.ShouldBeTrue)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "09F8017F")
+
+	// Nor does PLAIN switch to FAST for a line with all frames of a fast packet
+	const fast = "2022-11-14T01:47:30.890Z,3,126992,2,255,10,00,f0,d8,4a,80,1e,10,0e,a2,0f\n"
+	ana = newTestAnalyzer(t, fast, RawFormatPlain)
+	raw, err = ana.ReadRawMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, raw.Len, test.ShouldEqual, 10)
+
+	conf.InFile = strings.NewReader(fast)
+	ana, err = NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	_, err = ana.ReadRawMessage()
+	test.That(t, errors.Is(err, ErrFormatMismatch), test.ShouldBeTrue)
+}
+
+func TestConvertRawMessages(t *testing.T) {
+	// The same 129029 fast packet from two sources, with the frames interleaved.
+	frames := [][]byte{
+		{0x00, 0x2f, 0xe7, 0x95, 0x3d, 0x00, 0x73, 0xd6},
+		{0x01, 0x29, 0x00, 0xda, 0x04, 0x73, 0xdb, 0xc9},
+		{0x02, 0xe5, 0x05, 0x80, 0x7d, 0x02, 0x28, 0x5f},
+		{0x03, 0xd6, 0x10, 0xf6, 0x9b, 0x50, 0x6c, 0x05},
+		{0x04, 0x00, 0x00, 0x00, 0x00, 0x13, 0xfc, 0x08},
+		{0x05, 0x6f, 0x00, 0xbe, 0x00, 0xdd, 0xf2, 0xff},
+		{0x06, 0xff, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}
+	var rawMsgs []*common.RawMessage
+	for _, frame := range frames {
+		for _, src := range []uint8{3, 7} {
+			rawMsg := &common.RawMessage{Prio: 3, PGN: 129029, Src: src, Dst: 255, Len: 8}
+			copy(rawMsg.Data[:], frame)
+			rawMsgs = append(rawMsgs, rawMsg)
+		}
+	}
+
+	ana := newTestAnalyzer(t, "", RawFormatPlain)
+	ana.multipackets = MultiPacketsSeparate
+	msgs, err := ana.ConvertRawMessages(rawMsgs)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msgs, test.ShouldHaveLength, 2)
+	test.That(t, msgs[0].Src, test.ShouldEqual, 3)
+	test.That(t, msgs[1].Src, test.ShouldEqual, 7)
+	for _, msg := range msgs {
+		test.That(t, msg.Pgn, test.ShouldEqual, 129029)
+		test.That(t, msg.Fields["Latitude"], test.ShouldAlmostEqual, 42.4967684, 1e-7)
+	}
+}
+
+func TestDropSource(t *testing.T) {
+	firstFrame := func(src uint8) *common.RawMessage {
+		rawMsg := &common.RawMessage{Prio: 3, PGN: 129029, Src: src, Dst: 255, Len: 8}
+		copy(rawMsg.Data[:], []byte{0x00, 0x2f, 0xe7, 0x95, 0x3d, 0x00, 0x73, 0xd6})
+		return rawMsg
+	}
+
+	// Fill every reassembly buffer with a partial packet from its own source
+	ana := newTestAnalyzer(t, "", RawFormatPlain)
+	ana.multipackets = MultiPacketsSeparate
+	for src := 0; src < reassemblyBufferSize; src++ {
+		_, err := ana.convertRawMessage(firstFrame(uint8(src)))
+		test.That(t, errors.Is(err, ErrFastPacketIncomplete), test.ShouldBeTrue)
+	}
+	_, err := ana.convertRawMessage(firstFrame(100))
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "out of reassembly buffers")
+
+	ana.DropSource(5)
+	test.That(t, ana.reassemblyBuffer[5].used, test.ShouldBeFalse)
+	test.That(t, ana.reassemblyBuffer[4].used, test.ShouldBeTrue)
+	test.That(t, ana.reassemblyBuffer[6].used, test.ShouldBeTrue)
+
+	_, err = ana.convertRawMessage(firstFrame(100))
+	test.That(t, errors.Is(err, ErrFastPacketIncomplete), test.ShouldBeTrue)
+	test.That(t, ana.reassemblyBuffer[5].used, test.ShouldBeTrue)
+	test.That(t, ana.reassemblyBuffer[5].src, test.ShouldEqual, 100)
+}
+
+func TestAlwaysDecodeProprietary(t *testing.T) {
+	// Read Fields group function for standard PGN 126998, carrying manufacturer fields anyway.
+	const line = "2020-04-19T00:35:55.571Z,2,126208,0,67,9,03,16,f0,01,e5,98,01,00,00\n"
+
+	ana := newTestAnalyzer(t, line, RawFormatFast)
+	msg, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Description, test.ShouldEqual, "NMEA - Read Fields group function")
+	test.That(t, msg.Fields["PGN"], test.ShouldEqual, 126998)
+	_, ok := msg.Fields["Manufacturer Code"]
+	test.That(t, ok, test.ShouldBeFalse)
+
+	ana = newTestAnalyzer(t, line, RawFormatFast)
+	ana.AlwaysDecodeProprietary = true
+	msg, err = ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Fields["PGN"], test.ShouldEqual, 126998)
+	test.That(t, msg.Fields["Manufacturer Code"], test.ShouldEqual, "Garmin")
+	test.That(t, msg.Fields["Industry Code"], test.ShouldEqual, "Marine")
+	test.That(t, msg.Fields["Unique ID"], test.ShouldEqual, 1)
+}
+
+func TestFastPacketFramesAndSequence(t *testing.T) {
+	// 14 bytes of PGN 130577 take 3 frames, sent with sequence id 5.
+	const frames = `2022-09-28-11:36:59.668,3,130577,9,255,8,a0,0e,00,ff,ff,ff,ff,ff
+2022-09-28-11:36:59.668,3,130577,9,255,8,a1,ff,ff,ff,ff,ff,ff,ff
+2022-09-28-11:36:59.668,3,130577,9,255,8,a2,ff,ff,ff,ff,ff,ff,ff
+`
+	ana := newTestAnalyzer(t, frames, RawFormatPlain)
+	ana.multipackets = MultiPacketsSeparate
+	msg, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Pgn, test.ShouldEqual, 130577)
+	test.That(t, msg.Frames, test.ShouldEqual, 3)
+	test.That(t, msg.Sequence, test.ShouldEqual, 5)
+}
+
+type cancelingWriter struct {
+	cancel context.CancelFunc
+	writes int
+}
+
+func (cw *cancelingWriter) Write(p []byte) (int, error) {
+	cw.writes++
+	cw.cancel()
+	return len(p), nil
+}
+
+func TestRunContextCancel(t *testing.T) {
+	const line = "2022-11-14T01:47:30.890Z,2,127251,14,255,8,ff,51,bf,ff,ff,ff,ff,ff\n"
+	input := strings.NewReader(strings.Repeat(line, 1000))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := &cancelingWriter{cancel: cancel}
+
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = input
+	conf.OutFile = out
+	conf.ShowJSON = true
+	conf.ShowVersion = false
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+
+	err = ana.RunContext(ctx)
+	test.That(t, errors.Is(err, context.Canceled), test.ShouldBeTrue)
+	test.That(t, out.writes, test.ShouldEqual, 1)
+}
+
+func TestShowDataBase64(t *testing.T) {
+	var out bytes.Buffer
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader("2022-11-14T01:47:30.890Z,2,127251,14,255,8,ff,51,bf,ff,ff,ff,ff,ff\n")
+	conf.OutFile = &out
+	conf.ShowVersion = false
+	conf.ShowData = true
+	conf.ShowDataBase64 = true
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ana.Run(), test.ShouldBeNil)
+
+	payload := []byte{0xff, 0x51, 0xbf, 0xff, 0xff, 0xff, 0xff, 0xff}
+	test.That(t, out.String(), test.ShouldContainSubstring,
+		"2022-11-14T01:47:30.890Z 2  14 255 127251 Rate of Turn: "+base64.RawStdEncoding.EncodeToString(payload)+"\n")
+	test.That(t, out.String(), test.ShouldContainSubstring, " FF 51 BF FF FF FF FF FF")
+}
+
+func TestShowRaw(t *testing.T) {
+	const rateOfTurn = "2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff"
+	const heading = "2022-11-14T01:47:30.990Z,2,127250,14,255,8,00,fc,69,97,00,ff,7f,fd"
+	for _, tc := range []struct {
+		onlyPgn int64
+		want    []string
+		notWant []string
+	}{
+		{0, []string{rateOfTurn, heading}, nil},
+		{127251, []string{rateOfTurn}, []string{heading}},
+	} {
+		var out bytes.Buffer
+		conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+		conf.InFile = strings.NewReader(rateOfTurn + "\n" + heading + "\n")
+		conf.OutFile = &out
+		conf.ShowVersion = false
+		conf.ShowRaw = true
+		conf.OnlyPgn = tc.onlyPgn
+		ana, err := NewAnalyzer(conf)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, ana.Run(), test.ShouldBeNil)
+		for _, line := range tc.want {
+			test.That(t, out.String(), test.ShouldContainSubstring, line+"\n")
+		}
+		for _, line := range tc.notWant {
+			test.That(t, out.String(), test.ShouldNotContainSubstring, line)
+		}
+	}
+}
+
+func TestConvertRawMessageAs(t *testing.T) {
+	ana, err := NewAnalyzer(NewConfigForLibrary(common.NewLogger(io.Discard)))
+	test.That(t, err, test.ShouldBeNil)
+
+	// A Vessel Heading payload sent under a proprietary single-frame PGN
+	rawMsg := &common.RawMessage{Prio: 2, PGN: 65300, Src: 17, Dst: 255, Len: 8}
+	copy(rawMsg.Data[:], []byte{0x01, 0x10, 0x27, 0xff, 0x7f, 0xff, 0x7f, 0xfd})
+
+	msg, err := ana.convertRawMessage(rawMsg)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Description, test.ShouldNotEqual, "Vessel Heading")
+
+	msg, err = ana.ConvertRawMessageAs(rawMsg, 127250)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Pgn, test.ShouldEqual, 127250)
+	test.That(t, msg.Src, test.ShouldEqual, 17)
+	test.That(t, msg.Description, test.ShouldEqual, "Vessel Heading")
+	test.That(t, msg.Fields["Heading"], test.ShouldAlmostEqual, 57.2958, 0.001)
+	test.That(t, rawMsg.PGN, test.ShouldEqual, 65300)
+
+	_, err = ana.ConvertRawMessageAs(rawMsg, 1)
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestLongFastLine(t *testing.T) {
+	var line strings.Builder
+	line.WriteString("2022-11-14T01:47:30.890Z,3,130816,35,255,223")
+	for i := 0; i < 223; i++ {
+		fmt.Fprintf(&line, ",%02x", i)
+	}
+	line.WriteString("\n")
+	const next = "2022-11-14T01:47:30.890Z,2,127251,14,255,8,ff,51,bf,ff,ff,ff,ff,ff\n"
+
+	ana := newTestAnalyzer(t, line.String()+next, RawFormatFast)
+	rawMsg, err := ana.ReadRawMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, rawMsg.PGN, test.ShouldEqual, 130816)
+	test.That(t, rawMsg.Len, test.ShouldEqual, 223)
+	test.That(t, rawMsg.Data[222], test.ShouldEqual, 222)
+
+	// A line that does not fit is skipped as a whole rather than ending the input
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(line.String() + next)
+	conf.SelectedFormat = RawFormatFast
+	conf.MaxLineBytes = 256
+	ana, err = NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	rawMsg, err = ana.ReadRawMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, rawMsg.PGN, test.ShouldEqual, 127251)
+}
+
+func TestLineEndings(t *testing.T) {
+	const (
+		first  = "2022-11-14T01:47:30.890Z,2,127251,15,255,8,01,10,27,00,00,ff,ff,ff"
+		second = "2022-11-14T01:47:30.891Z,2,127251,15,255,8,02,10,27,00,00,ff,ff,ff"
+		third  = "2022-11-14T01:47:30.892Z,2,127251,15,255,8,03,10,27,00,00,ff,ff,ff"
+	)
+	for _, input := range []string{
+		first + "\r" + second + "\r" + third + "\r",
+		first + "\r\n" + second + "\r\n" + third,
+		first + "\n" + second + "\r" + third + "\r\n",
+	} {
+		// Reading a byte at a time splits "\r\n" across reads like a slow socket would
+		for _, in := range []io.Reader{strings.NewReader(input), iotest.OneByteReader(strings.NewReader(input))} {
+			conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+			conf.InFile = in
+			conf.SelectedFormat = RawFormatFast
+			ana, err := NewAnalyzer(conf)
+			test.That(t, err, test.ShouldBeNil)
+			for sid := 1; sid <= 3; sid++ {
+				rawMsg, err := ana.ReadRawMessage()
+				test.That(t, err, test.ShouldBeNil)
+				test.That(t, rawMsg.Data[0], test.ShouldEqual, sid)
+			}
+			_, err = ana.ReadRawMessage()
+			test.That(t, err, test.ShouldEqual, io.EOF)
+			test.That(t, ana.badLines, test.ShouldEqual, 0)
+		}
+	}
+}
+
+func TestPreserveSentinels(t *testing.T) {
+	// Vessel Heading with Deviation at its unknown (0x7fff) and Variation at its error (0x7ffe) value
+	rawMsg := &common.RawMessage{Prio: 2, PGN: 127250, Src: 17, Dst: 255, Len: 8}
+	copy(rawMsg.Data[:], []byte{0x01, 0x10, 0x27, 0xff, 0x7f, 0xfe, 0x7f, 0xfd})
+
+	ana, err := NewAnalyzer(NewConfigForLibrary(common.NewLogger(io.Discard)))
+	test.That(t, err, test.ShouldBeNil)
+	msg, err := ana.convertRawMessage(rawMsg)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "Deviation")
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "Variation")
+
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.PreserveSentinels = true
+	ana, err = NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	msg, err = ana.convertRawMessage(rawMsg)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Fields["Deviation"], test.ShouldEqual, common.FieldUnknown)
+	test.That(t, msg.Fields["Variation"], test.ShouldEqual, common.FieldError)
+	test.That(t, msg.Fields["Heading"], test.ShouldAlmostEqual, 57.2958, 0.001)
+
+	data, err := json.Marshal(msg.Fields["Variation"])
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(data), test.ShouldEqual, `"ERROR"`)
+}
+
+func TestShowJSONArray(t *testing.T) {
+	const input = `2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff
+2022-11-14T01:47:30.890Z,2,127250,17,255,8,01,10,27,ff,7f,ff,7f,fd
+2022-11-14T01:47:30.990Z,2,127251,14,255,8,01,52,bf,ff,ff,ff,ff,ff
+`
+	run := func(t *testing.T, configure func(conf *Config)) []map[string]interface{} {
+		t.Helper()
+		var out bytes.Buffer
+		conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+		conf.InFile = strings.NewReader(input)
+		conf.OutFile = &out
+		conf.ShowJSON = true
+		conf.ShowJSONArray = true
+		configure(conf)
+		ana, err := NewAnalyzer(conf)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, ana.Run(), test.ShouldBeNil)
+		test.That(t, strings.HasSuffix(out.String(), "]\n"), test.ShouldBeTrue)
+
+		var msgs []map[string]interface{}
+		test.That(t, json.Unmarshal(out.Bytes(), &msgs), test.ShouldBeNil)
+		return msgs
+	}
+
+	msgs := run(t, func(conf *Config) {})
+	test.That(t, msgs, test.ShouldHaveLength, 3)
+	test.That(t, msgs[1]["pgn"], test.ShouldEqual, 127250)
+
+	msgs = run(t, func(conf *Config) { conf.OnlyPgn = 127251 })
+	test.That(t, msgs, test.ShouldHaveLength, 2)
+	test.That(t, msgs[1]["fields"].(map[string]interface{})["SID"], test.ShouldEqual, 1)
+
+	msgs = run(t, func(conf *Config) { conf.OnlyPgn = 129029 })
+	test.That(t, msgs, test.ShouldHaveLength, 0)
+
+	upper := true
+	msgs = run(t, func(conf *Config) { conf.CamelCase = &upper })
+	test.That(t, msgs, test.ShouldHaveLength, 3)
+	test.That(t, msgs[0], test.ShouldContainKey, "RateOfTurn")
+
+	errRead := errors.New("read failed")
+	var out bytes.Buffer
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = io.MultiReader(strings.NewReader(input), iotest.ErrReader(errRead))
+	conf.OutFile = &out
+	conf.ShowJSON = true
+	conf.ShowJSONArray = true
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ana.Run(), test.ShouldEqual, errRead)
+	test.That(t, json.Unmarshal(out.Bytes(), &msgs), test.ShouldBeNil)
+	test.That(t, msgs, test.ShouldHaveLength, 3)
+}
+
+func TestCamelDescription(t *testing.T) {
+	const line = "2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff\n"
+
+	msg, err := newTestAnalyzer(t, line, RawFormatFast).ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.CamelDescription, test.ShouldBeEmpty)
+
+	for _, tc := range []struct {
+		upper bool
+		camel string
+	}{
+		{false, "rateOfTurn"},
+		{true, "RateOfTurn"},
+	} {
+		conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+		conf.InFile = strings.NewReader(line)
+		conf.SelectedFormat = RawFormatFast
+		conf.CamelCase = &tc.upper
+		ana, err := NewAnalyzer(conf)
+		test.That(t, err, test.ShouldBeNil)
+		msg, err := ana.ReadMessage()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, msg.Description, test.ShouldEqual, "Rate of Turn")
+		test.That(t, msg.CamelDescription, test.ShouldEqual, tc.camel)
+	}
+}
+
+func TestShowRawSequence(t *testing.T) {
+	// 14 bytes of PGN 130577 take 3 frames, sent with sequence id 5.
+	const frames = `2022-09-28-11:36:59.668,3,130577,9,255,8,a0,0e,00,ff,ff,ff,ff,ff
+2022-09-28-11:36:59.668,3,130577,9,255,8,a1,ff,ff,ff,ff,ff,ff,ff
+2022-09-28-11:36:59.668,3,130577,9,255,8,a2,ff,ff,ff,ff,ff,ff,ff
+`
+	var out bytes.Buffer
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(frames)
+	conf.OutFile = &out
+	conf.ShowVersion = false
+	conf.ShowRaw = true
+	conf.ShowRawSequence = true
+	conf.multipackets = MultiPacketsSeparate
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ana.Run(), test.ShouldBeNil)
+	test.That(t, out.String(), test.ShouldContainSubstring,
+		"2022-09-28-11:36:59.668,3,130577,9,255,8,a1,ff,ff,ff,ff,ff,ff,ff,seq=5\n")
+
+	var rawLines strings.Builder
+	for _, line := range strings.SplitAfter(out.String(), "\n") {
+		if strings.Contains(line, ",seq=") {
+			rawLines.WriteString(line)
+		}
+	}
+	test.That(t, strings.Count(rawLines.String(), "\n"), test.ShouldEqual, 3)
+
+	ana = newTestAnalyzer(t, rawLines.String(), RawFormatPlain)
+	for i, line := range strings.Split(strings.TrimSuffix(frames, "\n"), "\n") {
+		raw, err := ana.ReadRawMessage()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, raw.Sequence, test.ShouldEqual, 5)
+		test.That(t, raw.Data[0], test.ShouldEqual, 0xa0|i)
+
+		expected := newTestAnalyzer(t, line+"\n", RawFormatPlain)
+		expectedRaw, err := expected.ReadRawMessage()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, raw.Data, test.ShouldResemble, expectedRaw.Data)
+		test.That(t, raw.Len, test.ShouldEqual, expectedRaw.Len)
+	}
+}
+
+func TestForceMultiPackets(t *testing.T) {
+	// The FAST format claims to deliver fast packets coalesced, but these are the 3 frames
+	// of a 14 byte PGN 130577.
+	const frames = `2022-09-28-11:36:59.668,3,130577,9,255,8,a0,0e,00,ff,ff,ff,ff,ff
+2022-09-28-11:36:59.668,3,130577,9,255,8,a1,ff,ff,ff,ff,ff,ff,ff
+2022-09-28-11:36:59.668,3,130577,9,255,8,a2,ff,ff,ff,ff,ff,ff,ff
+`
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(frames)
+	conf.SelectedFormat = RawFormatFast
+	separate := MultiPacketsSeparate
+	conf.ForceMultiPackets = &separate
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+
+	msg, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Pgn, test.ShouldEqual, 130577)
+	test.That(t, msg.Frames, test.ShouldEqual, 3)
+	test.That(t, msg.Sequence, test.ShouldEqual, 5)
+	_, err = ana.ReadMessage()
+	test.That(t, errors.Is(err, io.EOF), test.ShouldBeTrue)
+
+	ana = newTestAnalyzer(t, frames, RawFormatFast)
+	for i := 0; i < 3; i++ {
+		msg, err := ana.ReadMessage()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, msg.Frames, test.ShouldEqual, 0)
+	}
+}
+
+func TestDecodePartialFastPackets(t *testing.T) {
+	// Frames 0 and 1 of a 14 byte PGN 130577, which hold all but the last byte.
+	const partial = `2022-09-28-11:36:59.668,3,130577,9,255,8,a0,0e,c0,07,5c,3d,f4,01
+2022-09-28-11:36:59.669,3,130577,9,255,8,a1,0c,7b,c2,01,10,27,32
+`
+	const complete = `2022-09-28-11:36:59.770,3,130577,9,255,8,c0,0e,c0,08,5c,3d,f4,01
+2022-09-28-11:36:59.771,3,130577,9,255,8,c1,0c,7b,c2,01,10,27,32
+2022-09-28-11:36:59.772,3,130577,9,255,8,c2,00,ff,ff,ff,ff,ff,ff
+`
+	checkPartial := func(t *testing.T, msg *common.Message) {
+		t.Helper()
+		test.That(t, msg.Partial, test.ShouldBeTrue)
+		test.That(t, msg.Timestamp, test.ShouldEqual, "2022-09-28-11:36:59.668")
+		test.That(t, msg.Frames, test.ShouldEqual, 2)
+		test.That(t, msg.Sequence, test.ShouldEqual, 5)
+		test.That(t, msg.Fields["SID"], test.ShouldEqual, 7)
+		test.That(t, msg.Fields["Set"], test.ShouldAlmostEqual, 1*radianToDegree, 1e-9)
+		data, err := MarshalMessageJSON(msg)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, string(data), test.ShouldEndWith, `"frames":2,"sequence":5,"partial":true}`)
+	}
+	newAnalyzer := func(t *testing.T, input string, decodePartial bool) *Analyzer {
+		t.Helper()
+		conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+		conf.InFile = strings.NewReader(input)
+		conf.SelectedFormat = RawFormatPlain
+		conf.DecodePartialFastPackets = decodePartial
+		ana, err := NewAnalyzer(conf)
+		test.That(t, err, test.ShouldBeNil)
+		return ana
+	}
+
+	t.Run("end of input", func(t *testing.T) {
+		ana := newAnalyzer(t, partial, true)
+		msg, err := ana.ReadMessage()
+		test.That(t, err, test.ShouldBeNil)
+		checkPartial(t, msg)
+		_, err = ana.ReadMessage()
+		test.That(t, errors.Is(err, io.EOF), test.ShouldBeTrue)
+	})
+
+	t.Run("next packet", func(t *testing.T) {
+		ana := newAnalyzer(t, partial+complete, true)
+		msg, err := ana.ReadMessage()
+		test.That(t, err, test.ShouldBeNil)
+		checkPartial(t, msg)
+		msg, err = ana.ReadMessage()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, msg.Partial, test.ShouldBeFalse)
+		test.That(t, msg.Fields["SID"], test.ShouldEqual, 8)
+		test.That(t, msg.Fields["Drift"], test.ShouldAlmostEqual, 0.5, 1e-9)
+		_, err = ana.ReadMessage()
+		test.That(t, errors.Is(err, io.EOF), test.ShouldBeTrue)
+	})
+
+	t.Run("compact", func(t *testing.T) {
+		var out bytes.Buffer
+		ana := newAnalyzer(t, partial+complete, true)
+		ana.OutFile = &out
+		ana.Compact = true
+		test.That(t, ana.Run(), test.ShouldBeNil)
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		test.That(t, lines, test.ShouldHaveLength, 2)
+		test.That(t, lines[0], test.ShouldContainSubstring, "sid=7")
+		test.That(t, lines[1], test.ShouldContainSubstring, "sid=8")
+		test.That(t, ana.partialMsgs, test.ShouldBeEmpty)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		ana := newAnalyzer(t, partial+complete, true)
+		report, err := ana.ValidateInput(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, report.Messages, test.ShouldEqual, 2)
+		test.That(t, report.PGNCounts[130577], test.ShouldEqual, 2)
+		test.That(t, ana.partialMsgs, test.ShouldBeEmpty)
+	})
+
+	t.Run("compact end of input", func(t *testing.T) {
+		var out bytes.Buffer
+		ana := newAnalyzer(t, partial, true)
+		ana.OutFile = &out
+		ana.Compact = true
+		test.That(t, ana.Run(), test.ShouldBeNil)
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		test.That(t, lines, test.ShouldHaveLength, 1)
+		test.That(t, lines[0], test.ShouldContainSubstring, "sid=7")
+	})
+
+	t.Run("convert end of input", func(t *testing.T) {
+		ana := newAnalyzer(t, partial, true)
+		var rawMsgs []*common.RawMessage
+		for {
+			rawMsg, err := ana.ReadRawMessage()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			test.That(t, err, test.ShouldBeNil)
+			rawMsgs = append(rawMsgs, rawMsg)
+		}
+		msgs, err := ana.ConvertRawMessages(rawMsgs)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, msgs, test.ShouldBeEmpty)
+		msgs = ana.FlushPartialFastPackets()
+		test.That(t, msgs, test.ShouldHaveLength, 1)
+		checkPartial(t, msgs[0])
+		test.That(t, ana.FlushPartialFastPackets(), test.ShouldBeEmpty)
+	})
+
+	t.Run("channel end of input", func(t *testing.T) {
+		ana := newAnalyzer(t, "", true)
+		in := make(chan Frame, 2)
+		for _, line := range strings.Split(strings.TrimSpace(partial), "\n") {
+			var data []byte
+			for _, b := range strings.Split(line, ",")[6:] {
+				var v byte
+				_, err := fmt.Sscanf(b, "%x", &v)
+				test.That(t, err, test.ShouldBeNil)
+				data = append(data, v)
+			}
+			in <- Frame{CanID: common.EncodeCanID(3, 130577, 9, 255), Data: data}
+		}
+		close(in)
+		var results []Result
+		for result := range ana.DecodeChannel(in) {
+			results = append(results, result)
+		}
+		test.That(t, results, test.ShouldHaveLength, 1)
+		test.That(t, results[0].Err, test.ShouldBeNil)
+		test.That(t, results[0].Message.Partial, test.ShouldBeTrue)
+		test.That(t, results[0].Message.Fields["SID"], test.ShouldEqual, 7)
+	})
+
+	t.Run("validate end of input", func(t *testing.T) {
+		ana := newAnalyzer(t, partial, true)
+		report, err := ana.ValidateInput(context.Background())
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, report.Messages, test.ShouldEqual, 1)
+		test.That(t, report.IncompleteFastPackets, test.ShouldEqual, 1)
+	})
+
+	t.Run("codec check end of input", func(t *testing.T) {
+		dir := t.TempDir()
+		test.That(t, os.WriteFile(filepath.Join(dir, "partial.txt"), []byte(partial), 0o600), test.ShouldBeNil)
+		ana := newAnalyzer(t, "", true)
+		report, err := ana.CheckBinaryCodec(context.Background(), dir)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, report.Messages, test.ShouldEqual, 1)
+		test.That(t, report.Mismatches, test.ShouldBeEmpty)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		ana := newAnalyzer(t, partial+complete, false)
+		msg, err := ana.ReadMessage()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, msg.Fields["SID"], test.ShouldEqual, 8)
+		_, err = ana.ReadMessage()
+		test.That(t, errors.Is(err, io.EOF), test.ShouldBeTrue)
+		test.That(t, ana.FlushPartialFastPackets(), test.ShouldBeNil)
+	})
+}
+
+func TestNavLink2StatusLines(t *testing.T) {
+	const data = "!PDGY,130567,6,200,255,25631.18,RgPczwYAQnYeAB4AAAADAAAAAABQbiMA\n"
+	const input = "$PDGY,000000,4,,5,482,1,0\n" + data + "$PDGY,000000,,,,,,\n" + data
+
+	ana := newTestAnalyzer(t, input, RawFormatUnknown)
+	for i := 0; i < 2; i++ {
+		msg, err := ana.ReadMessage()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, msg.Pgn, test.ShouldEqual, 130567)
+	}
+	_, err := ana.ReadMessage()
+	test.That(t, errors.Is(err, io.EOF), test.ShouldBeTrue)
+	test.That(t, ana.SelectedFormat, test.ShouldEqual, RawFormatNavLink2)
+	test.That(t, ana.badLines, test.ShouldEqual, 0)
+}
+
+func TestPassthrough(t *testing.T) {
+	const rateOfTurn = "2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff"
+	const frames = `2022-09-28-11:36:59.668,3,130577,9,255,8,a0,0e,00,ff,ff,ff,ff,ff
+2022-09-28-11:36:59.668,3,130577,9,255,8,a1,ff,ff,ff,ff,ff,ff,ff
+2022-09-28-11:36:59.668,3,130577,9,255,8,a2,ff,ff,ff,ff,ff,ff,ff
+`
+	input := rateOfTurn + "\n" + frames + rateOfTurn + "\n"
+
+	for _, tc := range []struct {
+		onlyPgn  int64
+		expected string
+	}{
+		{127251, rateOfTurn + "\n" + rateOfTurn + "\n"},
+		{130577, frames},
+		{0, input},
+	} {
+		var out bytes.Buffer
+		conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+		conf.InFile = strings.NewReader(input)
+		conf.OutFile = &out
+		conf.ShowVersion = false
+		conf.Passthrough = true
+		conf.OnlyPgn = tc.onlyPgn
+		ana, err := NewAnalyzer(conf)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, ana.Run(), test.ShouldBeNil)
+		test.That(t, out.String(), test.ShouldEqual, tc.expected)
+	}
+}
+
+func TestRepeatCountExceedsData(t *testing.T) {
+	// GNSS Sats in View declares 3 satellites but holds only one.
+	const line = "2022-11-14T01:47:30.890Z,6,129540,3,255,15,01,fc,03,0a,00,10,00,20,a0,0f,00,00,00,00,f2\n"
+
+	var logs bytes.Buffer
+	conf := NewConfigForLibrary(common.NewLogger(&logs))
+	conf.InFile = strings.NewReader(line)
+	conf.SelectedFormat = RawFormatFast
+	conf.multipackets = MultiPacketsCoalesced
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+
+	msg, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Fields["Sats in View"], test.ShouldEqual, 3)
+	list, ok := msg.Fields["list"].([]interface{})
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, list, test.ShouldHaveLength, 1)
+	test.That(t, list[0].(map[string]interface{})["PRN"], test.ShouldEqual, 10)
+	test.That(t, logs.String(), test.ShouldContainSubstring, "PGN 129540 has 14 missing fields in repeating set")
+
+	_, err = ana.ReadMessage()
+	test.That(t, errors.Is(err, io.EOF), test.ShouldBeTrue)
+}
+
+func TestFilterPrio(t *testing.T) {
+	const input = `2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff
+2022-11-14T01:47:30.890Z,3,128267,35,255,8,01,d2,04,00,00,f4,01,ff
+2022-11-14T01:47:30.990Z,6,130312,35,255,8,01,00,02,97,72,ff,ff,ff
+2022-11-14T01:47:31.890Z,3,128267,35,255,8,02,d2,04,00,00,f4,01,ff
+`
+	conf, cont, err := ParseArgs([]string{"analyzer", "-json", "-prio", "3"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cont, test.ShouldBeTrue)
+	test.That(t, conf.FilterPrio, test.ShouldResemble, []uint8{3})
+
+	var out bytes.Buffer
+	conf.InFile = strings.NewReader(input)
+	conf.OutFile = &out
+	conf.Logger = common.NewLogger(io.Discard)
+	conf.ShowVersion = false
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ana.Run(), test.ShouldBeNil)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	test.That(t, lines, test.ShouldHaveLength, 2)
+	for _, line := range lines {
+		var msg map[string]interface{}
+		test.That(t, json.Unmarshal([]byte(line), &msg), test.ShouldBeNil)
+		test.That(t, msg["prio"], test.ShouldEqual, 3)
+		test.That(t, msg["pgn"], test.ShouldEqual, 128267)
+	}
+
+	var rawMsgs []*common.RawMessage
+	reader := newTestAnalyzer(t, input, RawFormatPlain)
+	for {
+		rawMsg, err := reader.ReadRawMessage()
+		if err != nil {
+			break
+		}
+		rawMsgs = append(rawMsgs, rawMsg)
+	}
+	ana = newTestAnalyzer(t, "", RawFormatPlain)
+	ana.FilterPrio = []uint8{2, 6}
+	msgs, err := ana.ConvertRawMessages(rawMsgs)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msgs, test.ShouldHaveLength, 2)
+	test.That(t, msgs[0].Pgn, test.ShouldEqual, 127251)
+	test.That(t, msgs[1].Pgn, test.ShouldEqual, 130312)
+
+	ana = newTestAnalyzer(t, input, RawFormatPlain)
+	ana.FilterPrio = []uint8{6}
+	msg, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Pgn, test.ShouldEqual, 130312)
+	_, err = ana.ReadMessage()
+	test.That(t, err, test.ShouldEqual, io.EOF)
+}
+
+func TestParseArgsWithLogger(t *testing.T) {
+	var logs bytes.Buffer
+	logger := common.NewLogger(&logs)
+	conf, cont, err := ParseArgsWithLogger([]string{"analyzer", "-d"}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cont, test.ShouldBeTrue)
+	test.That(t, conf.Logger, test.ShouldEqual, logger)
+
+	_, err = NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, logs.String(), test.ShouldContainSubstring,
+		"[analyzer] Fieldtype 'ANGLE_FIX16' inherits unit 'rad' from physical type 'ANGLE'")
+
+	// Other loggers keep their own level.
+	var otherLogs bytes.Buffer
+	common.NewLogger(&otherLogs).Debug("not shown\n")
+	test.That(t, otherLogs.String(), test.ShouldBeEmpty)
+
+	logs.Reset()
+	_, _, err = ParseArgsWithLogger([]string{"analyzer", "-q"}, logger)
+	test.That(t, err, test.ShouldBeNil)
+	logger.Info("not shown\n")
+	test.That(t, logs.String(), test.ShouldBeEmpty)
+}
+
+func TestParseArgsFormat(t *testing.T) {
+	for _, format := range RawFormats {
+		conf, cont, err := ParseArgsWithLogger([]string{"analyzer", "-format", strings.ToLower(string(format))},
+			common.NewLogger(io.Discard))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, cont, test.ShouldBeTrue)
+		test.That(t, conf.SelectedFormat, test.ShouldEqual, format)
+	}
+
+	_, _, err := ParseArgsWithLogger([]string{"analyzer", "-format", "BOGUS"}, common.NewLogger(io.Discard))
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestParseArgsFormatReassembles(t *testing.T) {
+	// The two frames of 127506 DC Detailed Status, in a format with one line per frame
+	const capture = "16:29:27.082 R 19F21211 40 0B 01 00 00 57 5F 7D\n" +
+		"16:29:27.083 R 19F21211 41 00 05 00 B4 00 FF FF\n"
+
+	conf, cont, err := ParseArgsWithLogger([]string{"analyzer", "-json", "-format", "ydwg02"}, common.NewLogger(io.Discard))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cont, test.ShouldBeTrue)
+	var out bytes.Buffer
+	conf.InFile = strings.NewReader(capture)
+	conf.OutFile = &out
+	conf.ShowVersion = false
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ana.Run(), test.ShouldBeNil)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	test.That(t, lines, test.ShouldHaveLength, 1)
+	test.That(t, lines[0], test.ShouldContainSubstring, `"pgn":127506`)
+	test.That(t, lines[0], test.ShouldContainSubstring, `"State of Charge":87,"State of Health":95`)
+	test.That(t, lines[0], test.ShouldContainSubstring, `"Remaining capacity":180`)
+}
+
+func TestIncludeEmptyFields(t *testing.T) {
+	// Wind Data cut off after the wind angle, which is sent as unknown.
+	const line = "2022-11-14T01:47:30.890Z,2,130306,1,255,5,00,02,02,ff,ff\n"
+
+	read := func(t *testing.T, includeEmpty bool) *common.Message {
+		t.Helper()
+		ana := newTestAnalyzer(t, line, RawFormatFast)
+		ana.IncludeEmptyFields = includeEmpty
+		msg, err := ana.ReadMessage()
+		test.That(t, err, test.ShouldBeNil)
+		return msg
+	}
+
+	msg := read(t, false)
+	test.That(t, msg.Fields, test.ShouldResemble, map[string]interface{}{"SID": 0, "Wind Speed": 5.14})
+
+	msg = read(t, true)
+	test.That(t, msg.Fields, test.ShouldResemble, map[string]interface{}{
+		"SID":        0,
+		"Wind Speed": 5.14,
+		"Wind Angle": nil,
+		"Reference":  common.FieldNotPresent,
+	})
+
+	data, err := MarshalMessageJSON(msg)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(data), test.ShouldContainSubstring, `"Wind Angle":null`)
+	test.That(t, string(data), test.ShouldContainSubstring, `"Reference":{"notPresent":true}`)
+}
+
+func TestIncludeReservedFields(t *testing.T) {
+	// Magnetic Variation with 12,34 in its last two reserved bytes, where ff,ff is expected
+	const line = "2022-11-14T01:47:30.990Z,7,127258,35,255,8,00,f8,38,4a,c6,f5,12,34\n"
+
+	for _, include := range []bool{false, true} {
+		ana := newTestAnalyzer(t, line, RawFormatFast)
+		ana.IncludeReservedFields = include
+		msg, err := ana.ReadMessage()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, msg.Fields["Source"], test.ShouldEqual, "WMM 2020")
+		if !include {
+			// Only the reserved field that does not hold all ones
+			test.That(t, msg.Fields["Reserved"], test.ShouldResemble, []byte{0x12, 0x34})
+			test.That(t, msg.Fields, test.ShouldNotContainKey, "Reserved6")
+			continue
+		}
+		// The 4 bits after Source stay where they are in their byte
+		test.That(t, msg.Fields["Reserved"], test.ShouldResemble, []byte{0xf0})
+		test.That(t, msg.Fields["Reserved6"], test.ShouldResemble, []byte{0x12, 0x34})
+	}
+}
+
+func TestSkipUnprintableFields(t *testing.T) {
+	const line = "2022-11-14T01:47:30.890Z,2,130306,1,255,8,00,02,02,e8,03,fa,ff,ff\n"
+
+	for _, skip := range []bool{false, true} {
+		ana := newTestAnalyzer(t, line, RawFormatFast)
+		ana.SkipUnprintableFields = skip
+		// Take the convert function away from the type of Wind Speed
+		pgn, _ := ana.searchForPgn(130306)
+		test.That(t, pgn, test.ShouldNotBeNil)
+		field := &pgn.fieldList[1]
+		test.That(t, field.name, test.ShouldEqual, "Wind Speed")
+		ft := *field.ft
+		ft.cf = nil
+		field.ft = &ft
+
+		msg, err := ana.ReadMessage()
+		if !skip {
+			test.That(t, err, test.ShouldNotBeNil)
+			test.That(t, err.Error(), test.ShouldContainSubstring, "no function found to convert field 'Wind Speed'")
+			continue
+		}
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, msg.Fields, test.ShouldHaveLength, 3)
+		test.That(t, msg.Fields["SID"], test.ShouldEqual, 0)
+		test.That(t, msg.Fields["Wind Angle"], test.ShouldAlmostEqual, 5.7296, 0.0001)
+		test.That(t, msg.Fields["Reference"], test.ShouldEqual, "Apparent")
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	// The same instant, 1970-01-02T00:00:00.123Z, as milliseconds since the epoch in two formats
+	inputs := []struct {
+		format RawFormat
+		line   string
+	}{
+		{RawFormatChetco, "$PCDIN,01F119,05265C7B,0F,2AAF00D1067414FF*59\n"},
+		{RawFormatGarminCSV1, "0,86400123,127257,Attitude,Unknown,15,255,2,1,8,0x2AAF00D1067414FF\n"},
+	}
+	for _, tz := range []struct {
+		loc       *time.Location
+		timestamp string
+	}{
+		{time.UTC, "1970-01-02T00:00:00,123"},
+		{time.FixedZone("UTC+2", 2*60*60), "1970-01-02T02:00:00,123"},
+	} {
+		for _, in := range inputs {
+			conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+			conf.InFile = strings.NewReader(in.line)
+			conf.SelectedFormat = in.format
+			conf.multipackets = MultiPacketsCoalesced
+			conf.Timezone = tz.loc
+			ana, err := NewAnalyzer(conf)
+			test.That(t, err, test.ShouldBeNil)
+
+			raw, err := ana.ReadRawMessage()
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, raw.PGN, test.ShouldEqual, 127257)
+			test.That(t, raw.Timestamp, test.ShouldEqual, tz.timestamp)
+
+			// The time zone stays with the analyzer and does not leak to the caller's logger
+			test.That(t, conf.Logger.Location(), test.ShouldEqual, time.Local)
+		}
+	}
+}
+
+func TestPostProcessors(t *testing.T) {
+	// 10 m/s of apparent wind at 60 degrees
+	const wind = "2022-11-14T01:47:30.890Z,2,130306,35,255,8,00,e8,03,e8,28,fa,ff,ff\n"
+	headwind := func(msg *common.Message) error {
+		if msg.Pgn != 130306 {
+			return nil
+		}
+		speed, _ := msg.Fields["Wind Speed"].(float64)
+		angle, _ := msg.Fields["Wind Angle"].(float64)
+		msg.Fields["Headwind"] = speed * math.Cos(angle*math.Pi/180)
+		return nil
+	}
+
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(wind)
+	conf.SelectedFormat = RawFormatFast
+	conf.multipackets = MultiPacketsCoalesced
+	conf.PostProcessors = []func(*common.Message) error{nil, headwind}
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	msg, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Fields["Headwind"], test.ShouldAlmostEqual, 5, 1e-3)
+
+	var out bytes.Buffer
+	conf.InFile = strings.NewReader(wind)
+	conf.OutFile = &out
+	conf.ShowVersion = false
+	conf.Compact = true
+	ana, err = NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ana.Run(), test.ShouldBeNil)
+	test.That(t, out.String(), test.ShouldContainSubstring, "reference=Apparent headwind=4.999978793\n")
+
+	errStop := errors.New("stop")
+	conf.InFile = strings.NewReader(wind)
+	conf.PostProcessors = []func(*common.Message) error{func(*common.Message) error { return errStop }}
+	ana, err = NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	msg, err = ana.ReadMessage()
+	test.That(t, errors.Is(err, errStop), test.ShouldBeTrue)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "PGN 130306")
+	test.That(t, msg, test.ShouldBeNil)
+}
+
+func TestActisenseRawASCII(t *testing.T) {
+	// Captured from an Actisense W2K-1: a received Position, Rapid Update and a transmitted ISO Request
+	const capture = "13:40:12.345 R 09F80103 E0 2D 0F 17 A0 8F 3C D2\r\n" +
+		"13:40:12.412 T 18EAFF00 14 F0 01\r\n"
+
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(capture)
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+
+	msg, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	format, ok := ana.DetectedFormat()
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, format, test.ShouldEqual, RawFormatYDWG02)
+	test.That(t, msg.Pgn, test.ShouldEqual, 129025)
+	test.That(t, msg.Src, test.ShouldEqual, 3)
+	test.That(t, msg.Priority, test.ShouldEqual, 2)
+	test.That(t, msg.Timestamp, test.ShouldEndWith, "T13:40:12.345")
+	test.That(t, msg.Fields["Latitude"], test.ShouldAlmostEqual, 38.6870752)
+	test.That(t, msg.Fields["Longitude"], test.ShouldAlmostEqual, -76.7783008)
+
+	msg, err = ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Pgn, test.ShouldEqual, 59904)
+	test.That(t, msg.Src, test.ShouldEqual, 0)
+	test.That(t, msg.Dst, test.ShouldEqual, 255)
+	test.That(t, msg.Fields["PGN"], test.ShouldEqual, 126996)
+}
+
+func TestHeaderOnly(t *testing.T) {
+	// An AIS Class A Position Report in five frames, then a Rate of Turn
+	const capture = "2022-11-14T01:47:30.890Z,4,129038,43,255,8,00,1c,01,40,07,8d,0e,87\n" +
+		"2022-11-14T01:47:30.891Z,4,129038,43,255,8,01,30,75,02,87,68,11,1f\n" +
+		"2022-11-14T01:47:30.892Z,4,129038,43,255,8,02,79,5c,3d,02,02,a5,a5\n" +
+		"2022-11-14T01:47:30.893Z,4,129038,43,255,8,03,05,5c,3d,00,00,c0,f8\n" +
+		"2022-11-14T01:47:30.894Z,4,129038,43,255,8,04,00,ff,ff,ff,ff,ff,ff\n" +
+		"2022-11-14T01:47:30.990Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff\n"
+
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(capture)
+	conf.SelectedFormat = RawFormatPlain
+	conf.HeaderOnly = true
+	conf.PostProcessors = []func(*common.Message) error{func(*common.Message) error {
+		return errors.New("not called")
+	}}
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+
+	msg, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg, test.ShouldResemble, &common.Message{
+		Timestamp:   "2022-11-14T01:47:30.894Z",
+		Priority:    4,
+		Src:         43,
+		Dst:         255,
+		Pgn:         129038,
+		Description: "AIS Class A Position Report",
+		Frames:      5,
+	})
+
+	msg, err = ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Pgn, test.ShouldEqual, 127251)
+	test.That(t, msg.Description, test.ShouldEqual, "Rate of Turn")
+	test.That(t, msg.Fields, test.ShouldBeNil)
+}
+
+func BenchmarkHeaderOnly(b *testing.B) {
+	rawMsg := &common.RawMessage{
+		Timestamp: "2022-11-14T01:47:30.890Z", Prio: 4, PGN: 129038, Src: 43, Dst: 255, Len: 28,
+	}
+	copy(rawMsg.Data[:], []byte{
+		0x01, 0x40, 0x07, 0x8d, 0x0e, 0x87, 0x30, 0x75, 0x02, 0x87, 0x68, 0x11, 0x1f, 0x79,
+		0x5c, 0x3d, 0x02, 0x02, 0xa5, 0xa5, 0x05, 0x5c, 0x3d, 0x00, 0x00, 0xc0, 0xf8, 0x00,
+	})
+	for _, headerOnly := range []bool{false, true} {
+		name := "fields"
+		if headerOnly {
+			name = "header"
+		}
+		b.Run(name, func(b *testing.B) {
+			conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+			conf.multipackets = MultiPacketsCoalesced
+			conf.HeaderOnly = headerOnly
+			ana, err := NewAnalyzer(conf)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := ana.convertRawMessage(rawMsg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
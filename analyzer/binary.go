@@ -0,0 +1,298 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/erh/gonmea/common"
+)
+
+// The binary form of a message starts with a version byte, followed by the header as
+// varints (priority, source, destination, PGN, frames, sequence, partial) and strings
+// (timestamp, description, camelCase description), and then the fields as a map value.
+//
+// Every value starts with one of the tags below. Integers are varints, floats are 8 bytes
+// little endian, strings, byte slices and map keys are a uvarint length and the bytes,
+// lists a uvarint count and the values, and maps a uvarint count and the key and value
+// pairs in key order. Times are nanoseconds since the Unix epoch in UTC.
+const messageBinaryVersion = 1
+
+const (
+	binaryTagNil byte = iota
+	binaryTagInt
+	binaryTagInt64
+	binaryTagUint64
+	binaryTagFloat
+	binaryTagString
+	binaryTagBytes
+	binaryTagTime
+	binaryTagDuration
+	binaryTagList
+	binaryTagMap
+	binaryTagSentinel
+)
+
+// ErrMessageBinaryCorrupt is returned by UnmarshalMessageBinary for data that cannot be
+// decoded.
+var ErrMessageBinaryCorrupt = errors.New("corrupt binary message")
+
+// MarshalMessageBinary encodes a decoded message in a compact binary form that is
+// cheaper to produce and read back than JSON, e.g. to hand messages to another process.
+// UnmarshalMessageBinary turns it back into the same message.
+func MarshalMessageBinary(msg *common.Message) ([]byte, error) {
+	buf := make([]byte, 0, 64+len(msg.Timestamp)+len(msg.Description)+16*len(msg.Fields))
+	buf = append(buf, messageBinaryVersion)
+	for _, v := range []int{msg.Priority, msg.Src, msg.Dst, msg.Pgn, msg.Frames, msg.Sequence} {
+		buf = binary.AppendVarint(buf, int64(v))
+	}
+	partial := byte(0)
+	if msg.Partial {
+		partial = 1
+	}
+	buf = append(buf, partial)
+	buf = appendBinaryString(buf, msg.Timestamp)
+	buf = appendBinaryString(buf, msg.Description)
+	buf = appendBinaryString(buf, msg.CamelDescription)
+	if msg.Fields == nil {
+		return append(buf, binaryTagNil), nil
+	}
+	return appendBinaryValue(buf, msg.Fields)
+}
+
+func appendBinaryString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func appendBinaryValue(buf []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, binaryTagNil), nil
+	case int:
+		return binary.AppendVarint(append(buf, binaryTagInt), int64(v)), nil
+	case int64:
+		return binary.AppendVarint(append(buf, binaryTagInt64), v), nil
+	case uint64:
+		return binary.AppendUvarint(append(buf, binaryTagUint64), v), nil
+	case float64:
+		return binary.LittleEndian.AppendUint64(append(buf, binaryTagFloat), math.Float64bits(v)), nil
+	case string:
+		return appendBinaryString(append(buf, binaryTagString), v), nil
+	case []byte:
+		buf = binary.AppendUvarint(append(buf, binaryTagBytes), uint64(len(v)))
+		return append(buf, v...), nil
+	case time.Time:
+		return binary.AppendVarint(append(buf, binaryTagTime), v.UnixNano()), nil
+	case time.Duration:
+		return binary.AppendVarint(append(buf, binaryTagDuration), int64(v)), nil
+	case common.FieldSentinel:
+		return binary.AppendVarint(append(buf, binaryTagSentinel), int64(v)), nil
+	case []interface{}:
+		buf = binary.AppendUvarint(append(buf, binaryTagList), uint64(len(v)))
+		var err error
+		for _, elem := range v {
+			if buf, err = appendBinaryValue(buf, elem); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf = binary.AppendUvarint(append(buf, binaryTagMap), uint64(len(v)))
+		var err error
+		for _, key := range keys {
+			buf = appendBinaryString(buf, key)
+			if buf, err = appendBinaryValue(buf, v[key]); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("cannot encode field value of type %T", value)
+	}
+}
+
+// UnmarshalMessageBinary decodes a message encoded by MarshalMessageBinary. Maps and
+// lists hold the same types as in the encoded message, and times are in UTC.
+func UnmarshalMessageBinary(data []byte) (*common.Message, error) {
+	if len(data) == 0 || data[0] != messageBinaryVersion {
+		return nil, fmt.Errorf("%w: unknown version", ErrMessageBinaryCorrupt)
+	}
+	r := binaryReader{data: data[1:]}
+
+	msg := &common.Message{}
+	for _, v := range []*int{&msg.Priority, &msg.Src, &msg.Dst, &msg.Pgn, &msg.Frames, &msg.Sequence} {
+		*v = int(r.varint())
+	}
+	msg.Partial = r.byte() != 0
+	msg.Timestamp = r.string()
+	msg.Description = r.string()
+	msg.CamelDescription = r.string()
+	fields := r.value(0)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.data) != 0 {
+		return nil, fmt.Errorf("%w: %d bytes after the message", ErrMessageBinaryCorrupt, len(r.data))
+	}
+
+	switch f := fields.(type) {
+	case nil:
+	case map[string]interface{}:
+		msg.Fields = f
+	default:
+		return nil, fmt.Errorf("%w: fields are a %T", ErrMessageBinaryCorrupt, fields)
+	}
+	return msg, nil
+}
+
+// maxBinaryDepth bounds the nesting of lists and maps, which decoded messages only use
+// for repeating sets and bit lookups, so corrupt data cannot exhaust the stack.
+const maxBinaryDepth = 8
+
+// A binaryReader reads the parts of a binary message, remembering the first error so that
+// it can be checked once at the end.
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+func (r *binaryReader) fail(what string) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: bad %s", ErrMessageBinaryCorrupt, what)
+	}
+	r.data = nil
+}
+
+func (r *binaryReader) byte() byte {
+	if len(r.data) == 0 {
+		r.fail("byte")
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+func (r *binaryReader) varint() int64 {
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.fail("varint")
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail("uvarint")
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *binaryReader) bytes() []byte {
+	n := r.uvarint()
+	if n > uint64(len(r.data)) {
+		r.fail("length")
+		return nil
+	}
+	b := r.data[:n:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *binaryReader) string() string {
+	return string(r.bytes())
+}
+
+// count reads the number of elements of a list or map, each of which takes at least one
+// byte, so that a corrupt count cannot make it allocate more than the data could hold.
+func (r *binaryReader) count() int {
+	n := r.uvarint()
+	if n > uint64(len(r.data)) {
+		r.fail("count")
+		return 0
+	}
+	return int(n)
+}
+
+func (r *binaryReader) value(depth int) interface{} {
+	if depth > maxBinaryDepth {
+		r.fail("nesting")
+		return nil
+	}
+	switch tag := r.byte(); tag {
+	case binaryTagNil:
+		return nil
+	case binaryTagInt:
+		return int(r.varint())
+	case binaryTagInt64:
+		return r.varint()
+	case binaryTagUint64:
+		return r.uvarint()
+	case binaryTagFloat:
+		if len(r.data) < 8 {
+			r.fail("float")
+			return nil
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.data))
+		r.data = r.data[8:]
+		return v
+	case binaryTagString:
+		return r.string()
+	case binaryTagBytes:
+		return append([]byte(nil), r.bytes()...)
+	case binaryTagTime:
+		return time.Unix(0, r.varint()).UTC()
+	case binaryTagDuration:
+		return time.Duration(r.varint())
+	case binaryTagSentinel:
+		return common.FieldSentinel(r.varint())
+	case binaryTagList:
+		list := make([]interface{}, r.count())
+		for i := range list {
+			list[i] = r.value(depth + 1)
+		}
+		return list
+	case binaryTagMap:
+		n := r.count()
+		m := make(map[string]interface{}, n)
+		for i := 0; i < n && r.err == nil; i++ {
+			key := r.string()
+			m[key] = r.value(depth + 1)
+		}
+		return m
+	default:
+		r.fail(fmt.Sprintf("tag %d", tag))
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+package analyzer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func TestMessageBinaryRoundTrip(t *testing.T) {
+	msg := &common.Message{
+		Timestamp:        "2022-11-14T01:47:30.890Z",
+		Priority:         6,
+		Src:              3,
+		Dst:              255,
+		Pgn:              129540,
+		Description:      "GNSS Sats in View",
+		CamelDescription: "gnssSatsInView",
+		Frames:           6,
+		Sequence:         5,
+		Partial:          true,
+		Fields: map[string]interface{}{
+			"SID":        7,
+			"Latitude":   42.4967684,
+			"Name":       "Engine room",
+			"Empty":      nil,
+			"NAME":       uint64(0xc0a0000000000001),
+			"Data":       []byte{0x01, 0x02, 0xff},
+			"Date":       time.Date(2022, 11, 14, 0, 0, 0, 0, time.UTC),
+			"Time":       time.Duration(12*time.Hour + 34*time.Minute + 56*time.Second + 789*time.Millisecond),
+			"Depth":      common.FieldUnknown,
+			"Missing":    common.FieldNotPresent,
+			"Alarms":     []interface{}{"Low voltage", int64(1) << 40},
+			"Negative":   -120,
+			"Sats count": 0,
+			"list": []interface{}{
+				map[string]interface{}{"PRN": 5, "Elevation": 30.0, "Status": "Used"},
+				map[string]interface{}{"PRN": 12, "SNR": 38.5},
+			},
+		},
+	}
+
+	data, err := MarshalMessageBinary(msg)
+	test.That(t, err, test.ShouldBeNil)
+	decoded, err := UnmarshalMessageBinary(data)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, decoded, test.ShouldResemble, msg)
+
+	// Maps are written in key order, so the same message always encodes the same way
+	again, err := MarshalMessageBinary(decoded)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, again, test.ShouldResemble, data)
+
+	// A message without fields keeps nil fields
+	msg = &common.Message{Pgn: 59392, Description: "ISO Acknowledgement"}
+	data, err = MarshalMessageBinary(msg)
+	test.That(t, err, test.ShouldBeNil)
+	decoded, err = UnmarshalMessageBinary(data)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, decoded, test.ShouldResemble, msg)
+}
+
+func TestMessageBinaryDecoded(t *testing.T) {
+	const line = "2022-11-14T01:47:30.890Z,6,129540,3,255,39,07,fc,03," +
+		"05,74,14,5c,3d,94,11,00,00,00,00,f2," +
+		"0c,d1,06,66,99,0a,0f,88,ff,ff,ff,f1," +
+		"1d,22,33,88,cc,ff,ff,ff,ff,ff,7f,f0"
+
+	msg := decodeFast(t, line, false)
+	data, err := MarshalMessageBinary(msg)
+	test.That(t, err, test.ShouldBeNil)
+	decoded, err := UnmarshalMessageBinary(data)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, decoded, test.ShouldResemble, msg)
+}
+
+func TestMessageBinaryErrors(t *testing.T) {
+	_, err := MarshalMessageBinary(&common.Message{Fields: map[string]interface{}{"Bad": struct{}{}}})
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "struct {}")
+
+	data, err := MarshalMessageBinary(&common.Message{
+		Pgn:    127250,
+		Fields: map[string]interface{}{"Heading": 123.4, "Reference": "Magnetic"},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	for i := range data {
+		_, err = UnmarshalMessageBinary(data[:i])
+		test.That(t, errors.Is(err, ErrMessageBinaryCorrupt), test.ShouldBeTrue)
+	}
+	_, err = UnmarshalMessageBinary(append(data, 0))
+	test.That(t, errors.Is(err, ErrMessageBinaryCorrupt), test.ShouldBeTrue)
+}
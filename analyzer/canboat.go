@@ -0,0 +1,140 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/erh/gonmea/common"
+)
+
+// A Diff is a difference between a PGN definition here and the one in canboat's pgns.json.
+type Diff struct {
+	PGN         int
+	Description string // Of the PGN in pgns.json
+	Field       string // Empty when the difference is in the PGN as a whole
+	Reason      string
+}
+
+// canboatPGNs is the part of canboat's pgns.json that DiffAgainstCanboat compares.
+type canboatPGNs struct {
+	PGNs []struct {
+		PGN         int
+		Description string
+		Fields      []struct {
+			Name       string
+			BitLength  int
+			Resolution *float64
+			Offset     int
+			Signed     *bool
+		}
+	}
+}
+
+// DiffAgainstCanboat reads a canboat pgns.json and reports where the PGN definitions
+// here differ from it in the size, resolution, offset or sign of a field, or in the
+// number of fields. A definition is looked up by PGN and description, or by PGN alone
+// when there is one definition for it. Fields are compared in order, as defined, before
+// the units are converted for printing. Sizes of variable length fields in pgns.json and
+// the resolution and sign it leaves out are not compared.
+func DiffAgainstCanboat(r io.Reader) ([]Diff, error) {
+	var canboat canboatPGNs
+	if err := json.NewDecoder(r).Decode(&canboat); err != nil {
+		return nil, fmt.Errorf("reading canboat PGNs: %w", err)
+	}
+
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.definedUnits = true
+	ana, err := NewAnalyzer(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []Diff
+	for _, theirs := range canboat.PGNs {
+		diff := func(field, format string, args ...interface{}) {
+			diffs = append(diffs, Diff{
+				PGN:         theirs.PGN,
+				Description: theirs.Description,
+				Field:       field,
+				Reason:      fmt.Sprintf(format, args...),
+			})
+		}
+
+		ours := ana.canboatCounterpart(uint32(theirs.PGN), theirs.Description)
+		if ours == nil {
+			diff("", "no definition")
+			continue
+		}
+		if int(ours.fieldCount) != len(theirs.Fields) {
+			diff("", "%d fields, canboat has %d", ours.fieldCount, len(theirs.Fields))
+		}
+		for i := range theirs.Fields {
+			if i >= int(ours.fieldCount) {
+				break
+			}
+			field := &ours.fieldList[i]
+			their := &theirs.Fields[i]
+			if field.name != their.Name {
+				diff(field.name, "canboat has field '%s' here", their.Name)
+				continue
+			}
+			if their.BitLength != 0 && int(field.size) != their.BitLength {
+				diff(field.name, "size %d, canboat has %d", field.size, their.BitLength)
+			}
+			resolution := field.resolution
+			if resolution == 0 {
+				resolution = 1
+			}
+			if their.Resolution != nil && math.Abs(resolution-*their.Resolution) > 1e-9*math.Abs(*their.Resolution) {
+				diff(field.name, "resolution %g, canboat has %g", resolution, *their.Resolution)
+			}
+			if int(field.offset) != their.Offset {
+				diff(field.name, "offset %d, canboat has %d", field.offset, their.Offset)
+			}
+			if their.Signed != nil && field.hasSign != *their.Signed {
+				diff(field.name, "signed %t, canboat has %t", field.hasSign, *their.Signed)
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// canboatCounterpart returns the definition of the PGN with the given description, or the
+// only definition of the PGN, or nil.
+func (ana *Analyzer) canboatCounterpart(pgn uint32, description string) *pgnInfo {
+	var only *pgnInfo
+	count := 0
+	for i := range ana.pgns {
+		if ana.pgns[i].pgn != pgn {
+			continue
+		}
+		if ana.pgns[i].description == description {
+			return &ana.pgns[i]
+		}
+		only = &ana.pgns[i]
+		count++
+	}
+	if count == 1 {
+		return only
+	}
+	return nil
+}
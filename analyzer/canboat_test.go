@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestDiffAgainstCanboat(t *testing.T) {
+	// Vessel Heading as canboat has it, Temperature with a wrong resolution and sign,
+	// Rudder without its last field and a PGN that is not defined here.
+	const pgnsJSON = `{"Comment": "Curated from canboat", "PGNs": [
+{"PGN": 127250, "Id": "vesselHeading", "Description": "Vessel Heading", "Fields": [
+  {"Order": 1, "Id": "sid", "Name": "SID", "BitLength": 8, "Resolution": 1, "Signed": false},
+  {"Order": 2, "Id": "heading", "Name": "Heading", "BitLength": 16, "Resolution": 0.0001, "Signed": false, "Unit": "rad"},
+  {"Order": 3, "Id": "deviation", "Name": "Deviation", "BitLength": 16, "Resolution": 0.0001, "Signed": true, "Unit": "rad"},
+  {"Order": 4, "Id": "variation", "Name": "Variation", "BitLength": 16, "Resolution": 0.0001, "Signed": true, "Unit": "rad"},
+  {"Order": 5, "Id": "reference", "Name": "Reference", "BitLength": 2, "Resolution": 1, "Signed": false},
+  {"Order": 6, "Id": "reserved", "Name": "Reserved", "BitLength": 6, "Resolution": 1, "Signed": false}]},
+{"PGN": 130312, "Id": "temperature", "Description": "Temperature", "Fields": [
+  {"Order": 1, "Id": "sid", "Name": "SID", "BitLength": 8, "Resolution": 1, "Signed": false},
+  {"Order": 2, "Id": "instance", "Name": "Instance", "BitLength": 8, "Resolution": 1, "Signed": false},
+  {"Order": 3, "Id": "source", "Name": "Source", "BitLength": 8, "Resolution": 1, "Signed": false},
+  {"Order": 4, "Id": "actualTemperature", "Name": "Actual Temperature", "BitLength": 16, "Resolution": 0.1, "Signed": true, "Unit": "K"},
+  {"Order": 5, "Id": "setTemperature", "Name": "Set Temperature", "BitLength": 16, "Resolution": 0.01, "Signed": false, "Unit": "K"},
+  {"Order": 6, "Id": "reserved", "Name": "Reserved", "BitLength": 8, "Resolution": 1, "Signed": false}]},
+{"PGN": 127245, "Id": "rudder", "Description": "Rudder", "Fields": [
+  {"Order": 1, "Id": "instance", "Name": "Instance", "BitLength": 8, "Resolution": 1, "Signed": false}]},
+{"PGN": 130999, "Id": "notHere", "Description": "Not Here", "Fields": []}
+]}`
+
+	diffs, err := DiffAgainstCanboat(strings.NewReader(pgnsJSON))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, diffs, test.ShouldResemble, []Diff{
+		{130312, "Temperature", "Actual Temperature", "resolution 0.01, canboat has 0.1"},
+		{130312, "Temperature", "Actual Temperature", "signed false, canboat has true"},
+		{127245, "Rudder", "", "6 fields, canboat has 1"},
+		{130999, "Not Here", "", "no definition"},
+	})
+
+	_, err = DiffAgainstCanboat(strings.NewReader("{"))
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -0,0 +1,84 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+
+	"github.com/erh/gonmea/common"
+)
+
+// printCandump prints the CAN frames of the raw message, if it passes the filters, as
+// candumpFrames returns them.
+func (ana *Analyzer) printCandump(rawMsg *common.RawMessage) {
+	if !ana.matchesFilters(rawMsg) {
+		return
+	}
+	for _, frame := range ana.candumpFrames(rawMsg) {
+		fmt.Fprintf(ana.OutFile, "%s\n", frame)
+	}
+}
+
+// candumpFrames returns the CAN frames that carry the raw message as <canid>#<data>.
+func (ana *Analyzer) candumpFrames(rawMsg *common.RawMessage) []string {
+	canID := rawMsg.CanID()
+	var frames []string
+	for _, frame := range ana.canFrames(rawMsg) {
+		frames = append(frames, fmt.Sprintf("%08X#%X", canID, frame))
+	}
+	return frames
+}
+
+// canFrames returns the data of the CAN frames that carry the raw message. A message that
+// holds the whole payload of a fast-packet PGN is split into frames that start with the
+// sequence id and frame counter, padded with 0xff to 8 bytes; any other message already is
+// a single frame.
+func (ana *Analyzer) canFrames(rawMsg *common.RawMessage) [][]byte {
+	data := rawMsg.Data[:rawMsg.Len]
+
+	fast := false
+	if ana.multiPackets() == MultiPacketsCoalesced {
+		if pgn, _ := ana.searchForPgn(rawMsg.PGN); pgn != nil {
+			fast = pgn.packetType == packetTypeFast
+		} else {
+			fast = len(data) > 8 && common.AllowPGNFastPacket(rawMsg.PGN)
+		}
+	}
+	if !fast {
+		return [][]byte{data}
+	}
+
+	seq := (rawMsg.Sequence & 0x7) << 5
+	var frames [][]byte
+	for frame, start := 0, 0; frame == 0 || start < len(data); frame++ {
+		payload := []byte{seq | byte(frame)}
+		size := common.FastPacketBucketNSize
+		if frame == 0 {
+			payload = append(payload, byte(len(data)))
+			size = common.FastPacketBucket0Size
+		}
+		end := common.Min(start+size, len(data))
+		payload = append(payload, data[start:end]...)
+		for len(payload) < 8 {
+			payload = append(payload, 0xff)
+		}
+		frames = append(frames, payload)
+		start = end
+	}
+	return frames
+}
@@ -0,0 +1,76 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func TestCandump(t *testing.T) {
+	lines := []string{
+		"2022-11-14T01:47:30.890Z,6,128275,35,255,14,38,4a,d3,bd,ff,1a,70,d3,02,00,3c,07,00,00",
+		"2022-09-28-11:36:59.600,2,127250,1,255,8,00,fc,69,97,00,ff,7f,fd",
+		"2022-11-14T01:47:30.890Z,4,129038,43,255,28,01,40,07,8d,0e,87,30,75,02,87,68,11,1f,79,5c,3d," +
+			"02,02,a5,a5,05,5c,3d,00,00,c0,f8,00",
+	}
+
+	var out bytes.Buffer
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(strings.Join(lines, "\n") + "\n")
+	conf.OutFile = &out
+	conf.ShowVersion = false
+	conf.SelectedFormat = RawFormatFast
+	conf.multipackets = MultiPacketsCoalesced
+	conf.Candump = true
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ana.Run(), test.ShouldBeNil)
+
+	frames := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	test.That(t, frames[:4], test.ShouldResemble, []string{
+		"19F51323#000E384AD3BDFF1A",
+		"19F51323#0170D302003C0700",
+		"19F51323#0200FFFFFFFFFFFF",
+		"09F11201#00FC699700FF7FFD",
+	})
+	test.That(t, frames, test.ShouldHaveLength, 3+1+5)
+
+	// Sent as PLAIN frames, they reassemble into the messages they came from
+	var plain strings.Builder
+	for _, frame := range frames {
+		id, data, ok := strings.Cut(frame, "#")
+		test.That(t, ok, test.ShouldBeTrue)
+		canID, err := strconv.ParseUint(id, 16, 32)
+		test.That(t, err, test.ShouldBeNil)
+		payload, err := hex.DecodeString(data)
+		test.That(t, err, test.ShouldBeNil)
+		prio, pgn, src, dst := common.DecodeCanID(uint32(canID))
+		fmt.Fprintf(&plain, "2022-11-14T01:47:30.890Z,%d,%d,%d,%d,%d", prio, pgn, src, dst, len(payload))
+		for _, b := range payload {
+			fmt.Fprintf(&plain, ",%02x", b)
+		}
+		plain.WriteString("\n")
+	}
+	conf = NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(plain.String())
+	conf.SelectedFormat = RawFormatPlain
+	ana, err = NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	for _, line := range lines {
+		want := decodeFast(t, line, false)
+		msg, err := ana.ReadMessage()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, msg.Pgn, test.ShouldEqual, want.Pgn)
+		test.That(t, msg.Src, test.ShouldEqual, want.Src)
+		test.That(t, msg.Priority, test.ShouldEqual, want.Priority)
+		test.That(t, msg.Fields, test.ShouldResemble, want.Fields)
+	}
+}
@@ -0,0 +1,51 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"reflect"
+)
+
+// A changesKey is a PGN from one source, whose fields ChangesOnly compares.
+type changesKey struct {
+	pgn uint32
+	src uint8
+}
+
+// fieldsChanged tells whether fields differ from those of the last message of the PGN
+// from src, and remembers them to compare the next one with.
+func (ana *Analyzer) fieldsChanged(pgn uint32, src uint8, fields map[string]interface{}) bool {
+	key := changesKey{pgn: pgn, src: src}
+	last, seen := ana.lastFields[key]
+	if ana.lastFields == nil {
+		ana.lastFields = map[changesKey]map[string]interface{}{}
+	}
+	ana.lastFields[key] = fields
+	return !seen || !reflect.DeepEqual(last, fields)
+}
+
+// printedChanged is like fieldsChanged for the fields of a message as printed.
+func (ana *Analyzer) printedChanged(pgn uint32, src uint8, printed string) bool {
+	key := changesKey{pgn: pgn, src: src}
+	last, seen := ana.lastPrinted[key]
+	if ana.lastPrinted == nil {
+		ana.lastPrinted = map[changesKey]string{}
+	}
+	ana.lastPrinted[key] = printed
+	return !seen || last != printed
+}
@@ -0,0 +1,57 @@
+package analyzer
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func TestChangesOnly(t *testing.T) {
+	// Vessel Heading twice the same, then turned, then the same heading from another source
+	const input = `2022-11-14T01:47:30.890Z,2,127250,1,255,8,ff,10,27,ff,7f,ff,7f,fd
+2022-11-14T01:47:30.990Z,2,127250,1,255,8,ff,10,27,ff,7f,ff,7f,fd
+2022-11-14T01:47:31.090Z,2,127250,1,255,8,ff,20,4e,ff,7f,ff,7f,fd
+2022-11-14T01:47:31.190Z,2,127250,2,255,8,ff,20,4e,ff,7f,ff,7f,fd
+`
+	for _, mode := range []string{"", "-json", "-compact"} {
+		args := []string{"analyzer", "-changes-only", "-format", "FAST"}
+		if mode != "" {
+			args = append(args, mode)
+		}
+		conf, cont, err := ParseArgs(args)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, cont, test.ShouldBeTrue)
+		test.That(t, conf.ChangesOnly, test.ShouldBeTrue)
+
+		var out bytes.Buffer
+		conf.InFile = strings.NewReader(input)
+		conf.OutFile = &out
+		conf.Logger = common.NewLogger(io.Discard)
+		conf.ShowVersion = false
+		ana, err := NewAnalyzer(conf)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, ana.Run(), test.ShouldBeNil)
+
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		test.That(t, lines, test.ShouldHaveLength, 3)
+		switch mode {
+		case "-compact":
+			test.That(t, lines[0], test.ShouldStartWith, "pgn=127250 src=1 heading=57.29")
+			test.That(t, lines[1], test.ShouldStartWith, "pgn=127250 src=1 heading=114.59")
+			test.That(t, lines[2], test.ShouldStartWith, "pgn=127250 src=2 heading=114.59")
+		case "-json":
+			test.That(t, lines[0], test.ShouldStartWith, `{"timestamp":"2022-11-14T01:47:30.890Z","prio":2,"src":1,`)
+			test.That(t, lines[1], test.ShouldStartWith, `{"timestamp":"2022-11-14T01:47:31.090Z","prio":2,"src":1,`)
+			test.That(t, lines[2], test.ShouldStartWith, `{"timestamp":"2022-11-14T01:47:31.190Z","prio":2,"src":2,`)
+		default:
+			test.That(t, lines[0], test.ShouldStartWith, "2022-11-14T01:47:30.890Z 2   1 255 127250 Vessel Heading:")
+			test.That(t, lines[1], test.ShouldStartWith, "2022-11-14T01:47:31.090Z 2   1 255 127250 Vessel Heading:")
+			test.That(t, lines[2], test.ShouldStartWith, "2022-11-14T01:47:31.190Z 2   2 255 127250 Vessel Heading:")
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/erh/gonmea/common"
+)
+
+// A Frame is a single CAN frame as delivered by a CAN driver.
+type Frame struct {
+	CanID     uint32 // 29 bit extended identifier
+	Data      []byte // At most 8 bytes
+	Timestamp time.Time
+}
+
+// A Result is either a decoded message or the error decoding a frame ran into.
+type Result struct {
+	Message *common.Message
+	Err     error
+}
+
+// DecodeChannel decodes the frames received from in, reassembling fast packets, and
+// sends the messages on the returned channel, which is closed once in is closed and
+// everything is sent. Frames that only add to a fast packet produce no result. The
+// analyzer must not be used otherwise until the returned channel is closed.
+func (ana *Analyzer) DecodeChannel(in <-chan Frame) <-chan Result {
+	out := make(chan Result)
+	go func() {
+		defer close(out)
+		for frame := range in {
+			rawMsg, err := ana.frameToRawMessage(frame)
+			if err != nil {
+				out <- Result{Err: err}
+				continue
+			}
+			msgs, err := ana.ConvertRawMessages([]*common.RawMessage{rawMsg})
+			for _, msg := range msgs {
+				out <- Result{Message: msg}
+			}
+			if err != nil {
+				out <- Result{Err: err}
+			}
+		}
+		for _, msg := range ana.FlushPartialFastPackets() {
+			out <- Result{Message: msg}
+		}
+	}()
+	return out
+}
+
+// DecodeChannel is like Analyzer.DecodeChannel using an analyzer with the default configuration.
+func DecodeChannel(in <-chan Frame) <-chan Result {
+	ana, err := NewAnalyzer(NewConfigForLibrary(common.NewLogger(io.Discard)))
+	if err != nil {
+		out := make(chan Result, 1)
+		out <- Result{Err: err}
+		close(out)
+		return out
+	}
+	return ana.DecodeChannel(in)
+}
+
+func (ana *Analyzer) frameToRawMessage(frame Frame) (*common.RawMessage, error) {
+	if len(frame.Data) > 8 {
+		return nil, fmt.Errorf("CAN frame with id %08x has %d data bytes", frame.CanID, len(frame.Data))
+	}
+	if frame.CanID >= 1<<29 {
+		return nil, fmt.Errorf("CAN id %08x is more than 29 bits", frame.CanID)
+	}
+
+	timestamp := frame.Timestamp
+	if timestamp.IsZero() {
+		timestamp = ana.Logger.Now()
+	}
+	rawMsg := &common.RawMessage{
+		Timestamp: timestamp.UTC().Format("2006-01-02T15:04:05.000Z"),
+		Len:       uint8(len(frame.Data)),
+	}
+	rawMsg.Prio, rawMsg.PGN, rawMsg.Src, rawMsg.Dst = common.DecodeCanID(frame.CanID)
+	copy(rawMsg.Data[:], frame.Data)
+	return rawMsg, nil
+}
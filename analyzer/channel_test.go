@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+func TestDecodeChannel(t *testing.T) {
+	const (
+		gnssID        = 0x0df80500 // Priority 3, PGN 129029, source 0
+		rateOfTurnID  = 0x09f1130e // Priority 2, PGN 127251, source 14
+		isoRequestID  = 0x18ea2301 // Priority 6, PGN 59904 to 35, source 1
+		frameInterval = time.Millisecond
+	)
+	start := time.Date(2022, time.November, 14, 1, 47, 30, 890_000_000, time.UTC)
+
+	var frames []Frame
+	add := func(id uint32, data string) {
+		b, err := hex.DecodeString(data)
+		test.That(t, err, test.ShouldBeNil)
+		frames = append(frames, Frame{CanID: id, Data: b, Timestamp: start.Add(time.Duration(len(frames)) * frameInterval)})
+	}
+	add(gnssID, "002fe7953d0073d6")
+	add(gnssID, "012900da0473dbc9")
+	add(rateOfTurnID, "ff51bfffffffffff")
+	add(gnssID, "02e505807d02285f")
+	add(gnssID, "03d610f69b506c05")
+	add(gnssID, "040000000013fc08")
+	add(gnssID, "056f00be00ddf2ff")
+	add(0x7fffffff, "00")
+	add(gnssID, "06ff00ffffffffff")
+	add(isoRequestID, "14f001")
+
+	in := make(chan Frame)
+	go func() {
+		for _, frame := range frames {
+			in <- frame
+		}
+		close(in)
+	}()
+
+	var results []Result
+	for result := range DecodeChannel(in) {
+		results = append(results, result)
+	}
+	test.That(t, results, test.ShouldHaveLength, 4)
+
+	msg := results[0].Message
+	test.That(t, results[0].Err, test.ShouldBeNil)
+	test.That(t, msg.Pgn, test.ShouldEqual, 127251)
+	test.That(t, msg.Priority, test.ShouldEqual, 2)
+	test.That(t, msg.Src, test.ShouldEqual, 14)
+	test.That(t, msg.Timestamp, test.ShouldEqual, "2022-11-14T01:47:30.892Z")
+
+	test.That(t, results[1].Err, test.ShouldNotBeNil)
+
+	msg = results[2].Message
+	test.That(t, results[2].Err, test.ShouldBeNil)
+	test.That(t, msg.Pgn, test.ShouldEqual, 129029)
+	test.That(t, msg.Fields["Number of SVs"], test.ShouldEqual, 8)
+
+	msg = results[3].Message
+	test.That(t, results[3].Err, test.ShouldBeNil)
+	test.That(t, msg.Pgn, test.ShouldEqual, 59904)
+	test.That(t, msg.Src, test.ShouldEqual, 1)
+	test.That(t, msg.Dst, test.ShouldEqual, 35)
+	test.That(t, msg.Fields["PGN"], test.ShouldEqual, 126996)
+}
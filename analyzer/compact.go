@@ -0,0 +1,95 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erh/gonmea/common"
+)
+
+// FormatMessageCompact formats a message as one line of space separated key=value pairs:
+// the PGN and source followed by the fields under their camelCase names, in the order of
+// the PGN definition, e.g. "pgn=129025 src=1 latitude=42.3601 longitude=-71.0589".
+// Values with spaces are quoted and the fields of repeating sets follow each other.
+func FormatMessageCompact(msg *common.Message) string {
+	order := map[string]int{}
+	camelNames := map[string]string{}
+	forEachFieldKey(msg, func(key, camelName string, idx int) {
+		if _, ok := order[key]; !ok {
+			order[key] = idx
+			camelNames[key] = camelName
+		}
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "pgn=%d src=%d", msg.Pgn, msg.Src)
+	writeCompactFields(&b, msg.Fields, order, camelNames)
+	return b.String()
+}
+
+func writeCompactFields(b *strings.Builder, fields map[string]interface{}, order map[string]int, camelNames map[string]string) {
+	for _, key := range sortedFieldKeys(fields, order) {
+		switch v := fields[key].(type) {
+		case map[string]interface{}:
+			writeCompactFields(b, v, order, camelNames)
+		case []interface{}:
+			for _, elem := range v {
+				if elemFields, ok := elem.(map[string]interface{}); ok {
+					writeCompactFields(b, elemFields, order, camelNames)
+				}
+			}
+		default:
+			name, ok := camelNames[key]
+			if !ok {
+				name = camelize(key, false, 0)
+			}
+			b.WriteByte(' ')
+			b.WriteString(name)
+			b.WriteByte('=')
+			b.WriteString(compactValue(v))
+		}
+	}
+}
+
+func compactValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case float64:
+		return strconv.FormatFloat(v, 'g', 10, 64)
+	case []byte:
+		return hex.EncodeToString(v)
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339Nano)
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" || strings.ContainsAny(s, " \t=\"") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func TestFormatMessageCompact(t *testing.T) {
+	msg, err := ParseMessageWithFormat([]byte("$MXPGN,01F801,2801,0316A48E1F706BD5*6C"), RawFormatMiniPlex)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, FormatMessageCompact(msg), test.ShouldEqual, "pgn=129025 src=1 latitude=52.7461333 longitude=5.1815566")
+
+	// Keys already in camelCase are kept, as are ones that are not in the definition.
+	msg.Fields = map[string]interface{}{"longitude": -71.0589, "latitude": 42.3601, "extra": "a b", "Not Defined": nil}
+	test.That(t, FormatMessageCompact(msg), test.ShouldEqual,
+		`pgn=129025 src=1 latitude=42.3601 longitude=-71.0589 notDefined=null extra="a b"`)
+}
+
+func TestRunCompact(t *testing.T) {
+	const input = `2022-11-14T01:47:30.890Z,2,129025,1,255,8,d5,6b,70,1f,8e,a4,16,03
+2022-11-14T01:47:30.890Z,2,130306,1,255,8,00,02,02,ae,1e,fb,ff,ff
+`
+	conf, cont, err := ParseArgs([]string{"analyzer", "-compact", "-format", "FAST"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cont, test.ShouldBeTrue)
+	test.That(t, conf.Compact, test.ShouldBeTrue)
+
+	var out bytes.Buffer
+	conf.InFile = strings.NewReader(input)
+	conf.OutFile = &out
+	conf.Logger = common.NewLogger(io.Discard)
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ana.Run(), test.ShouldBeNil)
+	test.That(t, out.String(), test.ShouldEqual,
+		"pgn=129025 src=1 latitude=52.7461333 longitude=5.1815566\n"+
+			`pgn=130306 src=1 sid=0 windSpeed=5.14 windAngle=45.00010523 reference="True (boat referenced)"`+"\n")
+}
@@ -25,6 +25,7 @@ import (
 	"time"
 	"unicode"
 	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/erh/gonmea/common"
 )
@@ -63,10 +64,21 @@ func convertFieldNumber(
 		field.unitOffset,
 		logUnit)
 	if resolution == 1.0 && field.unitOffset == 0.0 {
+		if _, converted, ok := ana.convertUnit(field, float64(value)); ok {
+			return converted, true, nil
+		}
 		ana.Logger.Debug("convertFieldNumber <%s> print as integer %d\n", fieldName, value)
+		if value < 0 && *bits >= 64 && !field.hasSign {
+			// A 64 bit unsigned field, such as a NAME, can go past math.MaxInt64
+			return uint64(value), true, nil
+		}
 		return int(value), true, nil
 	}
-	return float64(value)*field.resolution + field.unitOffset, true, nil
+	a := scaleNumber(value, field.resolution, *bits) + field.unitOffset
+	if _, converted, ok := ana.convertUnit(field, a); ok {
+		return converted, true, nil
+	}
+	return a, true, nil
 }
 
 // Note(UNTESTED): See README.md.
@@ -83,7 +95,7 @@ func convertFieldFloat(
 		return nil, false, nil
 	}
 
-	if *bits != 4 || startBit != 0 {
+	if *bits != 32 || startBit != 0 {
 		//nolint:errcheck
 		ana.Logger.Error("field '%s' FLOAT value unhandled bits=%d startBit=%d\n", fieldName, *bits, startBit)
 		return nil, false, nil
@@ -92,7 +104,31 @@ func convertFieldFloat(
 		return nil, false, nil
 	}
 
-	return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), true, nil
+	value := math.Float32frombits(binary.LittleEndian.Uint32(data))
+	if ana.FloatNaNAsEmpty && isNaNOrInf(float64(value)) {
+		return nil, false, nil
+	}
+	return float64(value), true, nil
+}
+
+// scaleNumber returns value times resolution. A 64 bit value, such as GEO_FIX64 and
+// DISTANCE_FIX64 hold, can have more digits than a float64, so when the resolution is one
+// over a power of ten its whole and fractional parts are scaled apart, which keeps the
+// digits of the fraction.
+func scaleNumber(value int64, resolution float64, bits int) float64 {
+	if bits >= 64 && resolution < 1 {
+		scale := math.Round(1 / resolution)
+		if scale < math.MaxInt64 && 1/scale == resolution {
+			divisor := int64(scale)
+			return float64(value/divisor) + float64(value%divisor)/scale
+		}
+	}
+	return float64(value) * resolution
+}
+
+// isNaNOrInf tells whether value is no number to show, such as a FLOAT field can hold.
+func isNaNOrInf(value float64) bool {
+	return math.IsNaN(value) || math.IsInf(value, 0)
 }
 
 // Note(UNTESTED): See README.md.
@@ -191,6 +227,9 @@ func convertFieldLookup(
 		}
 		// BIT is handled in convertFieldBitLookup
 	}
+	if s == "" && field.lookup.lookupType != lookupTypeNone {
+		s = ana.unknownLookupName(value, maxValue, *bits)
+	}
 
 	if s != "" {
 		return s, true, nil
@@ -234,6 +273,9 @@ func convertFieldBitLookup(
 		ana.Logger.Debug("RES_BITFIELD is bit %d value %d set? = %t\n", bit, bitValue, isSet)
 		if isSet {
 			s := field.lookup.functionPair(bit)
+			if s == "" && ana.UnknownLookupFormat != "" {
+				s = fmt.Sprintf(ana.UnknownLookupFormat, bitValue)
+			}
 
 			if s != "" {
 				values = append(values, s)
@@ -445,7 +487,10 @@ func convertFieldLatLon(
 		return nil, false, nil
 	}
 
-	dd = float64(value) * field.resolution
+	dd = scaleNumber(value, field.resolution, *bits)
+	if !ana.latLonInRange(field, dd) {
+		return nil, false, nil
+	}
 
 	return dd, true, nil
 }
@@ -486,26 +531,61 @@ func convertFieldDate(
 	return time.Unix(int64(d)*86400, 0).UTC(), true, nil
 }
 
-func convertString(data []byte) (string, bool) {
-	var lastbyte *byte
+func (ana *Analyzer) convertString(data []byte) (string, bool) {
+	dataLen := trimmedStringLen(data, ana.StringFFIsTerminator)
+	if dataLen == 0 {
+		return "", false
+	}
+
+	return ana.decodeString(data[:dataLen]), true
+}
+
+// decodeString turns the bytes of a string field into a string according to the
+// configured StringEncoding.
+func (ana *Analyzer) decodeString(data []byte) string {
+	switch ana.StringEncoding {
+	case StringEncodingASCII:
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			if b < utf8.RuneSelf {
+				runes[i] = rune(b)
+			} else {
+				runes[i] = '?'
+			}
+		}
+		return string(runes)
+	case StringEncodingLatin1:
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	case StringEncodingUTF8:
+		fallthrough
+	default:
+		return string(data)
+	}
+}
 
+// trimmedStringLen returns the length of data without the padding we see all sorts of at
+// the end: whitespace, NUL and '@'. When ffIsTerminator is set the string also ends at
+// the first 0xff, which has been seen on recent Simrad VHF systems with noise following.
+// Printing and converting both use it, so they show the same string.
+func trimmedStringLen(data []byte, ffIsTerminator bool) int {
 	dataLen := len(data)
-	if dataLen > 0 {
-		// rtrim funny stuff from end, we see all sorts
-		lastbyte = &data[len(data)-1]
-		lastbyteCount := 0
-		for dataLen > 0 && (*lastbyte == 0xff || unicode.IsSpace(rune(*lastbyte)) || *lastbyte == 0 || *lastbyte == '@') {
-			dataLen--
-			lastbyteCount++
-			lastbyte = &data[len(data)-1-lastbyteCount]
+	if ffIsTerminator {
+		if end := bytes.IndexByte(data, 0xff); end >= 0 {
+			dataLen = end
 		}
 	}
-
-	if dataLen == 0 {
-		return "", false
+	for dataLen > 0 {
+		lastbyte := data[dataLen-1]
+		if !unicode.IsSpace(rune(lastbyte)) && lastbyte != 0 && lastbyte != '@' {
+			break
+		}
+		dataLen--
 	}
-
-	return string(data[:dataLen]), true
+	return dataLen
 }
 
 /**
@@ -530,7 +610,7 @@ func convertFieldStringFix(
 
 	dataLen = common.Min(dataLen, len(data)) // Cap length to remaining bytes in message
 	*bits = 8 * dataLen
-	val, ok := convertString(data[:dataLen])
+	val, ok := ana.convertString(data[:dataLen])
 	if !ok {
 		return nil, false, nil
 	}
@@ -561,7 +641,7 @@ func convertFieldStringLZ(
 	specifiedDataLen = common.Min(specifiedDataLen, byte(dataLen-1))
 	*bits = int(8 * (specifiedDataLen + 1))
 
-	val, ok := convertString(data[:specifiedDataLen])
+	val, ok := ana.convertString(data[:specifiedDataLen])
 	if !ok {
 		return nil, false, nil
 	}
@@ -616,7 +696,7 @@ func convertFieldStringLAU(
 		return nil, false, nil
 	}
 
-	val, ok := convertString(data[:specifiedDataLen])
+	val, ok := ana.convertString(data[:specifiedDataLen])
 	if !ok {
 		return nil, false, nil
 	}
@@ -666,15 +746,16 @@ func convertFieldTime(
 
 	t = uint64(value)
 	seconds = uint32(t / unitspersecond)
+	units := t % unitspersecond
 	minutes = seconds / 60
 	seconds %= 60
 	hours = minutes / 60
 	minutes %= 60
 
-	// Note(erd): this loses the units remainder
 	dur := time.Hour*time.Duration(hours) +
 		time.Minute*time.Duration(minutes) +
-		time.Second*time.Duration(seconds)
+		time.Second*time.Duration(seconds) +
+		time.Second*time.Duration(units)/time.Duration(unitspersecond)
 	if !positive {
 		dur *= -1
 	}
@@ -0,0 +1,186 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/erh/gonmea/common"
+)
+
+// A FieldDescription describes where a field was found in the PGN data and what it
+// decoded to.
+type FieldDescription struct {
+	Name     string
+	StartBit int         // Offset of the first bit from the start of the data
+	Bits     int         // Number of bits the field takes up
+	Raw      []byte      // The bytes the field covers, unmasked
+	RawValue int64       // The unscaled value for fields of at most 64 bits
+	Value    interface{} // The decoded value; nil when the field is empty
+}
+
+// DescribeDecode decodes the data of a single (reassembled) PGN and describes each
+// field in the order it was decoded, including the fields of repeating sets.
+func (ana *Analyzer) DescribeDecode(pgn uint32, data []byte) ([]FieldDescription, error) {
+	var descs []FieldDescription
+	ana.fieldObserver = func(fieldName string, data []byte, startBit, bits int, value interface{}, ok bool) {
+		desc := FieldDescription{
+			Name:     fieldName,
+			StartBit: startBit,
+			Bits:     bits,
+		}
+		if bits > 0 {
+			desc.Raw = data[startBit/8 : (startBit+bits+7)/8]
+		}
+		if bits > 0 && bits <= 64 {
+			var maxValue int64
+			extractNumber(nil, data, startBit, bits, &desc.RawValue, &maxValue, ana.Logger)
+		}
+		if ok {
+			desc.Value = value
+		}
+		descs = append(descs, desc)
+	}
+	defer func() {
+		ana.fieldObserver = nil
+	}()
+
+	rawMsg := &common.RawMessage{PGN: pgn, Dst: 255}
+	if _, err := ana.convertPGN(rawMsg, data); err != nil {
+		return nil, err
+	}
+	return descs, nil
+}
+
+// DescribeDecode is like Analyzer.DescribeDecode using an analyzer with the default configuration.
+func DescribeDecode(pgn uint32, data []byte) ([]FieldDescription, error) {
+	ana, err := NewAnalyzer(NewConfigForLibrary(common.NewLogger(io.Discard)))
+	if err != nil {
+		return nil, err
+	}
+	return ana.DescribeDecode(pgn, data)
+}
+
+// A PGNFieldInfo describes one field of an ad hoc layout for DecodeWithFields. Fields
+// follow each other without gaps; use a "RESERVED" field to skip bits.
+type PGNFieldInfo struct {
+	Name       string
+	FieldType  string  // A field type such as "UINT8", "NUMBER" or "STRING_FIX"; empty means "NUMBER"
+	Bits       int     // Size in bits; may be left 0 for field types with a fixed size
+	Resolution float64 // Scale of a number; 0 means 1 unless the field type has its own
+	Signed     bool    // Whether a number is signed, for field types that leave it open
+	Unit       string
+	UnitOffset float64 // Added to a number after scaling, as for temperatures shown in C
+	Lookup     string  // Name of a LOOKUP enumeration, which makes the field a LOOKUP field
+}
+
+// DecodeWithFields decodes data against the given field layout instead of a PGN definition.
+func (ana *Analyzer) DecodeWithFields(data []byte, fields []PGNFieldInfo) (map[string]interface{}, error) {
+	info := &pgnInfo{
+		description:     "Ad hoc",
+		packetType:      packetTypeFast,
+		repeatingField1: 255,
+		repeatingField2: 255,
+	}
+	if len(fields) > len(info.fieldList) {
+		return nil, fmt.Errorf("at most %d fields are supported, got %d", len(info.fieldList), len(fields))
+	}
+	for i, spec := range fields {
+		field := pgnField{
+			name:       spec.Name,
+			fieldType:  spec.FieldType,
+			size:       uint32(spec.Bits),
+			resolution: spec.Resolution,
+			hasSign:    spec.Signed,
+			unit:       spec.Unit,
+			unitOffset: spec.UnitOffset,
+		}
+		if spec.Lookup != "" {
+			field.fieldType = "LOOKUP"
+			field.lookup = lookupInfo{
+				lookupType:   lookupTypePair,
+				functionPair: lookupFunctionPairForTyp[spec.Lookup],
+				name:         spec.Lookup,
+			}
+			if field.lookup.functionPair == nil {
+				return nil, fmt.Errorf("field '%s' has unknown lookup '%s'", spec.Name, spec.Lookup)
+			}
+		}
+		if field.fieldType == "" {
+			field.fieldType = "NUMBER"
+		}
+		if err := ana.fillAdHocField(&field, info, i); err != nil {
+			return nil, err
+		}
+		info.fieldList[i] = field
+	}
+	info.fieldCount = uint32(len(fields))
+
+	msg, err := ana.convertPGNWithInfo(&common.RawMessage{Dst: 255}, info, data)
+	if err != nil {
+		return nil, err
+	}
+	return msg.Fields, nil
+}
+
+// fillAdHocField completes a field the way fillFieldType does for the built-in PGNs.
+func (ana *Analyzer) fillAdHocField(field *pgnField, info *pgnInfo, idx int) error {
+	ft, _ := ana.getFieldType(field.fieldType)
+	if ft == nil {
+		return fmt.Errorf("field '%s' has unknown field type '%s'", field.name, field.fieldType)
+	}
+	field.ft = ft
+
+	if ft.hasSign != nil {
+		field.hasSign = *ft.hasSign
+	}
+	if ft.resolution != 0.0 {
+		field.resolution = ft.resolution
+	} else if field.resolution == 0.0 {
+		field.resolution = 1
+	}
+	if ft.size != 0 {
+		if field.size != 0 && field.size != ft.size {
+			return fmt.Errorf("field '%s' of type '%s' must be %d bits", field.name, ft.name, ft.size)
+		}
+		field.size = ft.size
+	}
+	if field.size == 0 {
+		return fmt.Errorf("field '%s' needs a size", field.name)
+	}
+	field.offset = ft.offset
+	if field.unit == "" {
+		field.unit = ft.unit
+	}
+	if field.unit != "" {
+		ana.fixupUnit(field)
+	}
+	field.pgn = info
+	field.order = uint8(idx + 1)
+	return nil
+}
+
+// DecodeWithFields is like Analyzer.DecodeWithFields using an analyzer with the default configuration.
+func DecodeWithFields(data []byte, fields []PGNFieldInfo) (map[string]interface{}, error) {
+	ana, err := NewAnalyzer(NewConfigForLibrary(common.NewLogger(io.Discard)))
+	if err != nil {
+		return nil, err
+	}
+	return ana.DecodeWithFields(data, fields)
+}
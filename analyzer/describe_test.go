@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestDescribeDecode(t *testing.T) {
+	// Vessel Heading: SID, Heading, Deviation, Variation, Reference, Reserved
+	data := []byte{0x01, 0x10, 0x27, 0xff, 0x7f, 0xff, 0x7f, 0xfd}
+	descs, err := DescribeDecode(127250, data)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, descs, test.ShouldHaveLength, 6)
+
+	nextBit := 0
+	for _, desc := range descs {
+		test.That(t, desc.StartBit, test.ShouldEqual, nextBit)
+		nextBit += desc.Bits
+	}
+	test.That(t, nextBit, test.ShouldEqual, len(data)*8)
+
+	test.That(t, descs[0].Name, test.ShouldEqual, "SID")
+	test.That(t, descs[0].RawValue, test.ShouldEqual, 1)
+	test.That(t, descs[1].Name, test.ShouldEqual, "Heading")
+	test.That(t, descs[1].Bits, test.ShouldEqual, 16)
+	test.That(t, descs[1].Raw, test.ShouldResemble, []byte{0x10, 0x27})
+	test.That(t, descs[1].RawValue, test.ShouldEqual, 10000)
+	test.That(t, descs[1].Value, test.ShouldAlmostEqual, 57.2958, 0.001)
+	test.That(t, descs[2].Name, test.ShouldEqual, "Deviation")
+	test.That(t, descs[2].Value, test.ShouldBeNil)
+	test.That(t, descs[4].Name, test.ShouldEqual, "Reference")
+	test.That(t, descs[4].RawValue, test.ShouldEqual, 1)
+}
+
+func TestDecodeWithFields(t *testing.T) {
+	data := []byte{0x2a, 0xd2, 0x04, 0x01, 'A', 'B', 'C', ' '}
+	fields, err := DecodeWithFields(data, []PGNFieldInfo{
+		{Name: "Id", FieldType: "UINT8"},
+		{Name: "Level", Bits: 16, Resolution: 0.01, Unit: "m"},
+		{Name: "Mode", Bits: 8, Lookup: "YES_NO"},
+		{Name: "Tag", FieldType: "STRING_FIX", Bits: 32},
+	})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, fields["Id"], test.ShouldEqual, 42)
+	test.That(t, fields["Level"], test.ShouldAlmostEqual, 12.34, 1e-9)
+	test.That(t, fields["Mode"], test.ShouldEqual, "Yes")
+	test.That(t, fields["Tag"], test.ShouldEqual, "ABC")
+
+	_, err = DecodeWithFields(data, []PGNFieldInfo{{Name: "Id", FieldType: "NOPE"}})
+	test.That(t, err, test.ShouldNotBeNil)
+	_, err = DecodeWithFields(data, []PGNFieldInfo{{Name: "Id"}})
+	test.That(t, err, test.ShouldNotBeNil)
+}
@@ -0,0 +1,140 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/erh/gonmea/common"
+)
+
+// formatSampleLines is how many lines are read ahead to detect the input format.
+const formatSampleLines = 20
+
+// detectedFormats holds how each format that can be detected delivers fast packets and
+// what is logged when it is detected.
+var detectedFormats = map[RawFormat]struct {
+	multipackets MultiPackets
+	message      string
+}{
+	RawFormatChetco:            {MultiPacketsCoalesced, "Detected Chetco protocol with all data on one line\n"},
+	RawFormatGarminCSV1:        {MultiPacketsCoalesced, "Detected Garmin CSV protocol with relative timestamps\n"},
+	RawFormatGarminCSV2:        {MultiPacketsCoalesced, "Detected Garmin CSV protocol with absolute timestamps\n"},
+	RawFormatAirmar:            {MultiPacketsCoalesced, "Detected Airmar protocol with all data on one line\n"},
+	RawFormatYDWG02:            {MultiPacketsSeparate, "Detected YDWG-02 protocol with one line per frame\n"},
+	RawFormatNavLink2:          {MultiPacketsCoalesced, "Detected Digital Yacht NavLink2 protocol with one line per frame\n"},
+	RawFormatMiniPlex:          {MultiPacketsSeparate, "Detected MiniPlex protocol with one line per frame\n"},
+	RawFormatActisenseN2KASCII: {MultiPacketsCoalesced, "Detected Actisense N2K Ascii protocol with all frames on one line\n"},
+	RawFormatFast:              {MultiPacketsCoalesced, "Detected normal format with all frames on one line\n"},
+	RawFormatPlain:             {MultiPacketsSeparate, "Assuming normal format with one line per frame\n"},
+}
+
+func (ana *Analyzer) setDetectedFormat(format RawFormat) {
+	if detected, ok := detectedFormats[format]; ok {
+		ana.Logger.Info(detected.message)
+		ana.multipackets = detected.multipackets
+		if ana.OnFormatDetected != nil {
+			ana.OnFormatDetected(format)
+		}
+	}
+}
+
+// DetectFormatFromSample detects the input format from several of its lines, which is
+// more reliable than going by the first line alone. Each line votes for the format it
+// looks to be in and the format with the most votes wins, where a line that looks PLAIN
+// also counts for FAST: single-frame PGNs look the same in both, and only the fast-packet
+// lines of FAST input show it is not PLAIN. Empty lines and comments are ignored.
+func DetectFormatFromSample(lines []string) (RawFormat, bool) {
+	votes := map[RawFormat]int{}
+	var order []RawFormat // In the order of the first vote, to break ties
+	for _, line := range lines {
+		if line == "" || line[0] == '\r' || line[0] == '\n' || line[0] == '#' {
+			continue
+		}
+		msg, _, _ := common.SplitSequence([]byte(line))
+		format := detectLineFormat(string(msg))
+		if format == RawFormatUnknown {
+			continue
+		}
+		if votes[format] == 0 {
+			order = append(order, format)
+		}
+		votes[format]++
+	}
+	if votes[RawFormatFast] > 0 {
+		votes[RawFormatFast] += votes[RawFormatPlain]
+		delete(votes, RawFormatPlain)
+	}
+
+	best := RawFormatUnknown
+	for _, format := range order {
+		if votes[format] > votes[best] {
+			best = format
+		}
+	}
+	return best, best != RawFormatUnknown
+}
+
+// detectFormatFromInput detects the input format from the given first line and the ones
+// after it, which are kept to be returned by nextLine. Only the complete lines that were
+// already read into the buffer are sampled, so a live stream is not held up waiting for
+// more input, be it more lines or the rest of a line.
+func (ana *Analyzer) detectFormatFromInput(first []byte) RawFormat {
+	lines := []string{string(first)}
+	//nolint:errcheck
+	buf, _ := ana.reader.Peek(ana.reader.Buffered())
+	for complete := bufferedLineCount(buf, ana.skipLF); complete > 0 && len(lines) < formatSampleLines; complete-- {
+		line, _, err := ana.readLine()
+		if err != nil {
+			break
+		}
+		ana.sampledLines = append(ana.sampledLines, append([]byte(nil), line...))
+		lines = append(lines, string(line))
+	}
+
+	format, _ := DetectFormatFromSample(lines)
+	ana.setDetectedFormat(format)
+	return format
+}
+
+// bufferedLineCount returns how many lines in buf have their line end in buf, counting
+// "\r\n" as one line end, like readLine. skipLF drops a leading "\n" that ends the line
+// before buf.
+func bufferedLineCount(buf []byte, skipLF bool) int {
+	if skipLF && len(buf) > 0 && buf[0] == '\n' {
+		buf = buf[1:]
+	}
+	count := 0
+	for i := 0; i < len(buf); i++ {
+		if buf[i] == '\r' || buf[i] == '\n' {
+			count++
+			if buf[i] == '\r' && i+1 < len(buf) && buf[i+1] == '\n' {
+				i++
+			}
+		}
+	}
+	return count
+}
+
+// nextLine returns the next input line: those read ahead by detectFormatFromInput first.
+func (ana *Analyzer) nextLine() ([]byte, bool, error) {
+	if len(ana.sampledLines) > 0 {
+		line := ana.sampledLines[0]
+		ana.sampledLines = ana.sampledLines[1:]
+		return line, false, nil
+	}
+	return ana.readLine()
+}
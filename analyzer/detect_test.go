@@ -0,0 +1,173 @@
+package analyzer
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func TestDetectFormatFromSample(t *testing.T) {
+	// FAST input that starts with single-frame PGNs looks PLAIN line by line
+	lines := []string{
+		"2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff",
+		"# A comment",
+		"2022-11-14T01:47:30.891Z,3,128267,35,255,8,01,d2,04,00,00,f4,01,ff",
+		"2022-11-14T01:47:30.892Z,6,126992,2,255,10,00,f0,d8,4a,80,1e,10,0e,a2,0f",
+		"2022-11-14T01:47:30.990Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff",
+	}
+	test.That(t, detectLineFormat(lines[0]), test.ShouldEqual, RawFormatPlain)
+	format, ok := DetectFormatFromSample(lines)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, format, test.ShouldEqual, RawFormatFast)
+
+	format, ok = DetectFormatFromSample([]string{lines[0], lines[2], lines[4]})
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, format, test.ShouldEqual, RawFormatPlain)
+
+	// A stray line in another format is outvoted
+	format, ok = DetectFormatFromSample([]string{
+		"$MXPGN,01F801,2801,C1004A2D7E44FE4D",
+		"16:29:27.082 R 09F8017F 50 C3 B8 13 47 D8 2B C6",
+		"16:29:27.083 R 09F8027F 00 FC FF FF 00 00 FF FF",
+	})
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, format, test.ShouldEqual, RawFormatYDWG02)
+
+	_, ok = DetectFormatFromSample([]string{"", "# nothing here"})
+	test.That(t, ok, test.ShouldBeFalse)
+
+	// The analyzer samples the first lines of its input, and still decodes all of them
+	ana := newTestAnalyzer(t, strings.Join(lines, "\n")+"\n", RawFormatUnknown)
+	for _, pgn := range []uint32{127251, 128267, 126992, 127251} {
+		raw, err := ana.ReadRawMessage()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, raw.PGN, test.ShouldEqual, pgn)
+		format, ok := ana.DetectedFormat()
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, format, test.ShouldEqual, RawFormatFast)
+	}
+	test.That(t, ana.multiPackets(), test.ShouldEqual, MultiPacketsCoalesced)
+}
+
+func TestDetectFormatFromLiveInput(t *testing.T) {
+	// A stream that delivers three lines and then waits for more
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go func() {
+		//nolint:errcheck
+		pw.Write([]byte("2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff\n" +
+			"2022-11-14T01:47:30.891Z,6,126992,2,255,10,00,f0,d8,4a,80,1e,10,0e,a2,0f\n" +
+			"2022-11-14T01:47:30.990Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff\n"))
+	}()
+
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = pr
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	pgns := make(chan uint32)
+	go func() {
+		for i := 0; i < 3; i++ {
+			raw, err := ana.ReadRawMessage()
+			if err != nil {
+				break
+			}
+			pgns <- raw.PGN
+		}
+		close(pgns)
+	}()
+	for _, want := range []uint32{127251, 126992, 127251} {
+		select {
+		case pgn := <-pgns:
+			test.That(t, pgn, test.ShouldEqual, want)
+		case <-time.After(5 * time.Second):
+			t.Fatal("reading waited for more input to detect the format")
+		}
+	}
+	format, _ := ana.DetectedFormat()
+	test.That(t, format, test.ShouldEqual, RawFormatFast)
+}
+
+func TestDetectFormatFromLiveInputMidLine(t *testing.T) {
+	// The first read ends in the middle of the third line
+	const third = "2022-11-14T01:47:30.990Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff\r\n"
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	rest := make(chan struct{})
+	go func() {
+		//nolint:errcheck
+		pw.Write([]byte("2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff\r\n" +
+			"2022-11-14T01:47:30.891Z,6,126992,2,255,10,00,f0,d8,4a,80,1e,10,0e,a2,0f\r\n" + third[:30]))
+		<-rest
+		//nolint:errcheck
+		pw.Write([]byte(third[30:]))
+	}()
+
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = pr
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	pgns := make(chan uint32)
+	go func() {
+		for i := 0; i < 3; i++ {
+			raw, err := ana.ReadRawMessage()
+			if err != nil {
+				break
+			}
+			pgns <- raw.PGN
+		}
+		close(pgns)
+	}()
+	for i, want := range []uint32{127251, 126992, 127251} {
+		if i == 2 {
+			close(rest)
+		}
+		select {
+		case pgn := <-pgns:
+			test.That(t, pgn, test.ShouldEqual, want)
+		case <-time.After(5 * time.Second):
+			t.Fatal("reading waited for the rest of a line to detect the format")
+		}
+	}
+	format, _ := ana.DetectedFormat()
+	test.That(t, format, test.ShouldEqual, RawFormatFast)
+}
+
+func TestOnFormatDetected(t *testing.T) {
+	const input = "16:29:27.082 R 09F8017F 50 C3 B8 13 47 D8 2B C6\n" +
+		"16:29:27.083 R 09F8027F 00 FC FF FF 00 00 FF FF\n"
+
+	var detected []RawFormat
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(input)
+	conf.OnFormatDetected = func(format RawFormat) {
+		detected = append(detected, format)
+	}
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	for {
+		if _, err := ana.ReadRawMessage(); err != nil {
+			test.That(t, err, test.ShouldEqual, io.EOF)
+			break
+		}
+	}
+	test.That(t, detected, test.ShouldResemble, []RawFormat{RawFormatYDWG02})
+
+	// Not called for a configured format, nor without a callback
+	detected = nil
+	conf.InFile = strings.NewReader(input)
+	conf.SelectedFormat = RawFormatYDWG02
+	ana, err = NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	_, err = ana.ReadRawMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, detected, test.ShouldBeNil)
+
+	ana = newTestAnalyzer(t, input, RawFormatUnknown)
+	_, err = ana.ReadRawMessage()
+	test.That(t, err, test.ShouldBeNil)
+}
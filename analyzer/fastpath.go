@@ -0,0 +1,72 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"encoding/binary"
+
+	"github.com/erh/gonmea/common"
+)
+
+// fastPathPositionRapidUpdate is the PGN of Position, Rapid Update, which GNSS receivers
+// send up to ten times a second.
+const fastPathPositionRapidUpdate = 129025
+
+// convertFastPath converts the high rate PGNs it knows without going through the general
+// field loop. It returns false when the message has to be left to convertPGNWithInfo,
+// which it must give the same result as.
+func (ana *Analyzer) convertFastPath(rawMsg *common.RawMessage, pgn *pgnInfo, data []byte) (*common.Message, bool) {
+	if pgn.pgn != fastPathPositionRapidUpdate || len(data) < 8 {
+		return nil, false
+	}
+	// These options need what the field loop does for every field
+	if ana.PreserveSentinels || ana.IncludeEmptyFields || ana.fieldObserver != nil {
+		return nil, false
+	}
+
+	msg := &common.Message{
+		Timestamp:   rawMsg.Timestamp,
+		Priority:    int(rawMsg.Prio),
+		Src:         int(rawMsg.Src),
+		Dst:         int(rawMsg.Dst),
+		Pgn:         int(rawMsg.PGN),
+		Description: pgnLabel(pgn),
+
+		CamelDescription: pgn.camelDescription,
+		Fields:           make(map[string]interface{}, pgn.fieldCount),
+	}
+	for i := range pgn.fieldList[:2] {
+		field := &pgn.fieldList[i]
+		value := int32(binary.LittleEndian.Uint32(data[4*i:]))
+		if value > 0x7ffffffd {
+			// The ERROR and Unknown values
+			continue
+		}
+
+		fieldName := field.name
+		if field.camelName != "" {
+			fieldName = field.camelName
+		}
+		dd := float64(value) * field.resolution
+		if !ana.latLonInRange(field, dd) {
+			continue
+		}
+		msg.Fields[fieldLabel(field, fieldName)] = dd
+	}
+	return msg, true
+}
@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"io"
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func positionRapidUpdate(data ...byte) *common.RawMessage {
+	rawMsg := &common.RawMessage{
+		Timestamp: "2022-11-14T01:47:30.890Z", Prio: 2, PGN: 129025, Src: 1, Dst: 255, Len: uint8(len(data)),
+	}
+	copy(rawMsg.Data[:], data)
+	return rawMsg
+}
+
+func TestFastPathPositionRapidUpdate(t *testing.T) {
+	rawMsgs := []*common.RawMessage{
+		// 42.3601N 71.0589W
+		positionRapidUpdate(0x02, 0xf2, 0x3f, 0x19, 0x22, 0x6d, 0xa4, 0xd5),
+		// Latitude unknown, longitude error
+		positionRapidUpdate(0xff, 0xff, 0xff, 0x7f, 0xfe, 0xff, 0xff, 0x7f),
+		// 100N, which ValidateRanges drops
+		positionRapidUpdate(0x00, 0xe4, 0x0b, 0x54, 0x22, 0x6d, 0xa4, 0xd5),
+		// Too short for the fast path
+		positionRapidUpdate(0x02, 0xf2, 0x3f, 0x19, 0x22, 0x6d, 0xa4),
+	}
+	camel := true
+
+	for _, configure := range []func(conf *Config){
+		func(conf *Config) {},
+		func(conf *Config) { conf.ValidateRanges = true },
+		func(conf *Config) { conf.CamelCase = &camel },
+		func(conf *Config) { conf.PreserveSentinels = true },
+	} {
+		newAnalyzer := func(fastPaths bool) *Analyzer {
+			conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+			configure(conf)
+			conf.FastPaths = fastPaths
+			ana, err := NewAnalyzer(conf)
+			test.That(t, err, test.ShouldBeNil)
+			return ana
+		}
+		general, err := newAnalyzer(false).ConvertRawMessages(rawMsgs)
+		test.That(t, err, test.ShouldBeNil)
+		fast, err := newAnalyzer(true).ConvertRawMessages(rawMsgs)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, fast, test.ShouldResemble, general)
+	}
+}
+
+func BenchmarkConvertPositionRapidUpdate(b *testing.B) {
+	rawMsg := positionRapidUpdate(0x02, 0xf2, 0x3f, 0x19, 0x22, 0x6d, 0xa4, 0xd5)
+	for _, fastPaths := range []bool{false, true} {
+		name := "general"
+		if fastPaths {
+			name = "fast"
+		}
+		b.Run(name, func(b *testing.B) {
+			conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+			conf.FastPaths = fastPaths
+			ana, err := NewAnalyzer(conf)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := ana.convertRawMessage(rawMsg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -327,7 +327,10 @@ func (ana *Analyzer) fixupUnit(f *pgnField) {
 			f.unitOffset = -273.15
 			f.rangeMin += -273.15
 			f.rangeMax += -275.15
-			f.precision = 2
+			if f.resolution >= 0.01 {
+				// The offset needs two decimals; finer resolutions already get more
+				f.precision = 2
+			}
 			f.unit = "C"
 			ana.Logger.Debug("fixup <%s> to '%s'\n", f.name, f.unit)
 		case "rad":
@@ -347,6 +350,18 @@ func (ana *Analyzer) fixupUnit(f *pgnField) {
 	}
 }
 
+// convertUnit offers a value of the field, in the field's unit, to the UnitConverter.
+func (ana *Analyzer) convertUnit(field *pgnField, value float64) (string, float64, bool) {
+	if ana.UnitConverter == nil || field.unit == "" || field.unit[0] == '=' {
+		return field.unit, value, false
+	}
+	var quantity string
+	if field.ft != nil && field.ft.physical != nil {
+		quantity = field.ft.physical.name
+	}
+	return ana.UnitConverter(quantity, field.unit, value)
+}
+
 func getMinRange(name string, size uint32, resolution float64, sign bool, offset int32, logger *common.Logger) float64 {
 	highbit := size
 	if sign && offset == 0 {
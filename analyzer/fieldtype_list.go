@@ -742,7 +742,7 @@ func initFieldTypes() {
 			name:          "PEUKERT_EXPONENT",
 			description:   "Effect of discharge rate on usable battery capacity",
 			resolution:    0.002,
-			offset:        500, // = 1 / resolution
+			offset:        500, // = 1 / resolution, so raw 0 is an exponent of 1.0
 			url:           "https://en.wikipedia.org/wiki/Peukert's_law",
 			baseFieldType: "UFIX8",
 		},
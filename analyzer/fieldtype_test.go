@@ -0,0 +1,116 @@
+package analyzer
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func TestUnitConverter(t *testing.T) {
+	const lines = `2022-11-14T01:47:30.890Z,3,128267,35,255,8,01,d2,04,00,00,f4,01,ff
+2023-01-01T00:00:00.000Z,3,130577,20,255,14,c0,07,5c,3d,f4,01,0c,7b,c2,01,10,27,32,00
+`
+	imperial := func(quantity, unit string, value float64) (string, float64, bool) {
+		switch {
+		case quantity == "SPEED" && unit == "m/s":
+			return "kn", value * 3600 / 1852, true
+		case quantity == "LENGTH" && unit == "m":
+			return "ft", value / 0.3048, true
+		default:
+			return unit, value, false
+		}
+	}
+
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(lines)
+	conf.SelectedFormat = RawFormatFast
+	coalesced := MultiPacketsCoalesced
+	conf.ForceMultiPackets = &coalesced
+	conf.UnitConverter = imperial
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	msg, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Fields["Depth"], test.ShouldAlmostEqual, 12.34/0.3048, 1e-9)
+	msg, err = ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Fields["SOG"], test.ShouldAlmostEqual, 5*3600.0/1852, 1e-9)
+	test.That(t, msg.Fields["COG"], test.ShouldAlmostEqual, 90, 1e-3)
+
+	out := runText(t, lines, func(conf *Config) { conf.UnitConverter = imperial })
+	test.That(t, out, test.ShouldContainSubstring, "Depth = 40.49 ft")
+	test.That(t, out, test.ShouldContainSubstring, "COG = 90.0 deg; SOG = 9.72 kn;")
+}
+
+func TestFieldTypeByName(t *testing.T) {
+	temp, ok := FieldTypeByName("TEMPERATURE")
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, temp.Resolution, test.ShouldEqual, 0.01)
+	test.That(t, temp.Unit, test.ShouldEqual, "K")
+	test.That(t, temp.BaseFieldType, test.ShouldEqual, "UFIX16")
+	test.That(t, temp.Bits, test.ShouldEqual, 16)
+	test.That(t, temp.Signed, test.ShouldBeFalse)
+	test.That(t, temp.RangeMin, test.ShouldEqual, 0)
+	test.That(t, temp.RangeMax, test.ShouldAlmostEqual, 655.33, 1e-9)
+	test.That(t, temp.Physical.Name, test.ShouldEqual, "TEMPERATURE")
+	test.That(t, temp.Physical.Unit, test.ShouldEqual, "Kelvin")
+
+	// The result is a copy.
+	temp.Unit = "C"
+	temp, _ = FieldTypeByName("TEMPERATURE")
+	test.That(t, temp.Unit, test.ShouldEqual, "K")
+
+	_, ok = FieldTypeByName("NO_SUCH_TYPE")
+	test.That(t, ok, test.ShouldBeFalse)
+
+	all := AllFieldTypes()
+	test.That(t, len(all), test.ShouldBeGreaterThan, 50)
+	for _, info := range all {
+		if info.BaseFieldType != "" {
+			_, ok := FieldTypeByName(info.BaseFieldType)
+			test.That(t, ok, test.ShouldBeTrue)
+		}
+	}
+}
+
+func TestFillFieldTypeSizeMismatch(t *testing.T) {
+	// A definition giving an UINT8 field 16 bits is reported rather than decoded wrongly.
+	ana := &Analyzer{
+		Config:     *NewConfigForLibrary(common.NewLogger(io.Discard)),
+		fieldTypes: make([]fieldType, len(immutFieldTypes)),
+		pgns: []pgnInfo{{
+			description: "Test",
+			pgn:         130816,
+			packetType:  packetTypeFast,
+			fieldList:   [33]pgnField{{name: "Count", size: 16, resolution: 1, fieldType: "UINT8"}},
+		}},
+	}
+	copy(ana.fieldTypes, immutFieldTypes)
+
+	var err error
+	test.That(t, func() { err = ana.fillFieldType(true) }, test.ShouldNotPanic)
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "Cannot overrule size 8 in 'UINT8' with 16 in PGN 130816 field 'Count'")
+}
+
+func TestOffsetFieldTypeRanges(t *testing.T) {
+	// The offset is added to the raw value before scaling by the resolution, whether it
+	// shifts a Peukert exponent up to 1.0 or is the excess-K bias of a temperature or power.
+	for _, tc := range []struct {
+		name               string
+		rangeMin, rangeMax float64
+	}{
+		{"PEUKERT_EXPONENT", 1.0, (253 + 500) * 0.002},
+		{"TEMPERATURE_UINT8_OFFSET", 233, 253 + 233},
+		{"POWER_FIX32_OFFSET", -2000000000, 4294967293 - 2000000000},
+	} {
+		ft, ok := FieldTypeByName(tc.name)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, ft.RangeMin, test.ShouldAlmostEqual, tc.rangeMin, 1e-9)
+		test.That(t, ft.RangeMax, test.ShouldAlmostEqual, tc.rangeMax, 1e-9)
+	}
+}
@@ -0,0 +1,123 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"io"
+	"sync"
+
+	"github.com/erh/gonmea/common"
+)
+
+// FieldTypeInfo describes a field type such as "TEMPERATURE" or "STRING_LAU", with what it
+// inherits from its base type and physical quantity filled in.
+type FieldTypeInfo struct {
+	Name                string // UPPERCASE_WITH_UNDERSCORE
+	Description         string
+	EncodingDescription string // How the value is encoded
+	Comment             string
+	URL                 string
+	Bits                int // Size in bits; 0 when the size varies per field or PGN
+	VariableSize        bool
+	BaseFieldType       string // The field type this one is a variation of, if any
+
+	// These are only set for numbers.
+	Unit       string
+	Offset     int     // Excess-K offset of the raw value
+	Resolution float64 // Scale of the raw value, 1 for integers
+	Signed     bool
+	RangeMin   float64 // NaN when the range is not known
+	RangeMax   float64 // NaN when the range is not known
+
+	Physical PhysicalQuantityInfo // Empty Name when the field type is not a physical quantity
+}
+
+// PhysicalQuantityInfo describes the physical quantity a field type measures.
+type PhysicalQuantityInfo struct {
+	Name         string // UPPERCASE_WITH_UNDERSCORE, e.g. "TEMPERATURE"
+	Description  string
+	Comment      string
+	Unit         string // e.g. "Kelvin"
+	Abbreviation string // e.g. "K"
+	URL          string
+}
+
+var (
+	fieldTypeInfosOnce sync.Once
+	fieldTypeInfos     []FieldTypeInfo
+)
+
+// AllFieldTypes returns all field types, in table order.
+func AllFieldTypes() []FieldTypeInfo {
+	infos := loadFieldTypeInfos()
+	return append(make([]FieldTypeInfo, 0, len(infos)), infos...)
+}
+
+// FieldTypeByName returns the field type with the given name and whether there is one.
+func FieldTypeByName(name string) (*FieldTypeInfo, bool) {
+	for _, info := range loadFieldTypeInfos() {
+		if info.Name == name {
+			return &info, true
+		}
+	}
+	return nil, false
+}
+
+func loadFieldTypeInfos() []FieldTypeInfo {
+	fieldTypeInfosOnce.Do(func() {
+		ana, err := NewAnalyzer(NewConfigForLibrary(common.NewLogger(io.Discard)))
+		if err != nil {
+			return
+		}
+		fieldTypeInfos = make([]FieldTypeInfo, 0, len(ana.fieldTypes))
+		for i := range ana.fieldTypes {
+			fieldTypeInfos = append(fieldTypeInfos, newFieldTypeInfo(&ana.fieldTypes[i]))
+		}
+	})
+	return fieldTypeInfos
+}
+
+func newFieldTypeInfo(ft *fieldType) FieldTypeInfo {
+	info := FieldTypeInfo{
+		Name:                ft.name,
+		Description:         ft.description,
+		EncodingDescription: ft.encodingDescription,
+		Comment:             ft.comment,
+		URL:                 ft.url,
+		Bits:                int(ft.size),
+		VariableSize:        ft.variableSize,
+		BaseFieldType:       ft.baseFieldType,
+		Unit:                ft.unit,
+		Offset:              int(ft.offset),
+		Resolution:          ft.resolution,
+		Signed:              ft.hasSign != nil && *ft.hasSign,
+		RangeMin:            ft.rangeMin,
+		RangeMax:            ft.rangeMax,
+	}
+	if ft.physical != nil {
+		info.Physical = PhysicalQuantityInfo{
+			Name:         ft.physical.name,
+			Description:  ft.physical.description,
+			Comment:      ft.physical.comment,
+			Unit:         ft.physical.unit,
+			Abbreviation: ft.physical.abbreviation,
+			URL:          ft.physical.url,
+		}
+	}
+	return info
+}
@@ -0,0 +1,86 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// flushThreshold is the amount of pending output that forces a flush
+// before the flush interval has elapsed.
+const flushThreshold = 64 * 1024
+
+// An intervalWriter batches writes to an underlying writer and flushes
+// them either when the interval elapses or when enough output is pending.
+type intervalWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	interval time.Duration
+	buf      bytes.Buffer
+	timer    *time.Timer
+}
+
+func newIntervalWriter(w io.Writer, interval time.Duration) *intervalWriter {
+	return &intervalWriter{w: w, interval: interval}
+}
+
+func (iw *intervalWriter) Write(p []byte) (int, error) {
+	iw.mu.Lock()
+	defer iw.mu.Unlock()
+
+	n, _ := iw.buf.Write(p)
+	if iw.buf.Len() >= flushThreshold {
+		return n, iw.flushLocked()
+	}
+	if iw.timer == nil {
+		iw.timer = time.AfterFunc(iw.interval, func() {
+			//nolint:errcheck
+			iw.Flush()
+		})
+	}
+	return n, nil
+}
+
+// Flush writes out all pending output.
+func (iw *intervalWriter) Flush() error {
+	iw.mu.Lock()
+	defer iw.mu.Unlock()
+	return iw.flushLocked()
+}
+
+func (iw *intervalWriter) flushLocked() error {
+	if iw.timer != nil {
+		iw.timer.Stop()
+		iw.timer = nil
+	}
+	if iw.buf.Len() == 0 {
+		return nil
+	}
+	_, err := iw.w.Write(iw.buf.Bytes())
+	iw.buf.Reset()
+	if syncer, ok := iw.w.(interface {
+		Sync() error
+	}); ok {
+		//nolint:errcheck
+		syncer.Sync()
+	}
+	return err
+}
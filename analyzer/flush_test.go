@@ -0,0 +1,49 @@
+package analyzer
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.writes++
+	return cw.Buffer.Write(p)
+}
+
+func TestFlushInterval(t *testing.T) {
+	input := strings.Repeat("2022-11-14T01:47:30.890Z,2,127251,14,255,8,ff,51,bf,ff,ff,ff,ff,ff\n", 5)
+
+	run := func(t *testing.T, interval time.Duration) *countingWriter {
+		t.Helper()
+		var out countingWriter
+		conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+		conf.InFile = strings.NewReader(input)
+		conf.OutFile = &out
+		conf.ShowJSON = true
+		conf.ShowVersion = false
+		conf.FlushInterval = interval
+		ana, err := NewAnalyzer(conf)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, ana.Run(), test.ShouldBeNil)
+		return &out
+	}
+
+	perMessage := run(t, 0)
+	test.That(t, perMessage.writes, test.ShouldEqual, 5)
+
+	batched := run(t, time.Hour)
+	test.That(t, batched.writes, test.ShouldEqual, 1)
+	test.That(t, batched.String(), test.ShouldEqual, perMessage.String())
+}
@@ -0,0 +1,205 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/erh/gonmea/common"
+)
+
+// MarshalMessageJSON marshals a message to JSON like encoding/json does, except that
+// the fields are emitted in the order in which they appear in the PGN definition
+// rather than alphabetically. Fields that are not part of the definition (e.g. "list")
+// follow in alphabetical order. The output for a given message is stable.
+func MarshalMessageJSON(msg *common.Message) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, kv := range []struct {
+		key   string
+		value interface{}
+	}{
+		{"timestamp", msg.Timestamp},
+		{"prio", msg.Priority},
+		{"src", msg.Src},
+		{"dst", msg.Dst},
+		{"pgn", msg.Pgn},
+		{"description", msg.Description},
+	} {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeJSONKeyValue(&buf, kv.key, kv.value, nil); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte(',')
+	if err := writeJSONKeyValue(&buf, "fields", msg.Fields, fieldOrderForMessage(msg)); err != nil {
+		return nil, err
+	}
+	for _, kv := range []struct {
+		key   string
+		value int
+	}{
+		{"frames", msg.Frames},
+		{"sequence", msg.Sequence},
+	} {
+		if kv.value == 0 {
+			continue
+		}
+		buf.WriteByte(',')
+		if err := writeJSONKeyValue(&buf, kv.key, kv.value, nil); err != nil {
+			return nil, err
+		}
+	}
+	if msg.Partial {
+		buf.WriteByte(',')
+		if err := writeJSONKeyValue(&buf, "partial", true, nil); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// fieldOrderForMessage maps every name a field of the message's PGN definition can be
+// known by (plain, camelCase and UpperCamelCase) to its position in the definition.
+func fieldOrderForMessage(msg *common.Message) map[string]int {
+	order := map[string]int{}
+	forEachFieldKey(msg, func(key, _ string, idx int) {
+		if _, ok := order[key]; !ok {
+			order[key] = idx
+		}
+	})
+	return order
+}
+
+// forEachFieldKey calls fn with every name a field of the message's PGN definition can
+// be known by (plain, camelCase and UpperCamelCase), its camelCase name and its position
+// in the definition.
+func forEachFieldKey(msg *common.Message, fn func(key, camelName string, idx int)) {
+	pgn, idx := searchPgns(immutPGNs, uint32(msg.Pgn))
+	if pgn == nil {
+		return
+	}
+	// Of the definitions that share the PGN, take the one the message was decoded with
+	for i := idx; i < len(immutPGNs) && immutPGNs[i].pgn == pgn.pgn; i++ {
+		if immutPGNs[i].description == msg.Description {
+			pgn = &immutPGNs[i]
+			break
+		}
+	}
+
+	haveEarlierSpareOrReserved := false
+	for j := 0; j < len(pgn.fieldList) && pgn.fieldList[j].name != ""; j++ {
+		name := pgn.fieldList[j].name
+		var camelOrder int
+		if haveEarlierSpareOrReserved {
+			camelOrder = j + 1
+		}
+		camelName := camelize(name, false, camelOrder)
+		for _, key := range []string{name, camelName, camelize(name, true, camelOrder)} {
+			fn(key, camelName, j)
+		}
+		if name == "Reserved" || name == "Spare" {
+			haveEarlierSpareOrReserved = true
+		}
+	}
+}
+
+// sortedFieldKeys returns the keys of fields in the order of the PGN definition given by
+// order, followed by the keys not in the definition in alphabetical order.
+func sortedFieldKeys(fields map[string]interface{}, order map[string]int) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		oi, iOK := order[keys[i]]
+		oj, jOK := order[keys[j]]
+		if iOK && jOK && oi != oj {
+			return oi < oj
+		}
+		if iOK != jOK {
+			return iOK
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func writeJSONKeyValue(buf *bytes.Buffer, key string, value interface{}, order map[string]int) error {
+	keyData, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	buf.Write(keyData)
+	buf.WriteByte(':')
+	return writeJSONValue(buf, value, order)
+}
+
+func writeJSONValue(buf *bytes.Buffer, value interface{}, order map[string]int) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('{')
+		for i, key := range sortedFieldKeys(v, order) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONKeyValue(buf, key, v[key], order); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case []interface{}:
+		if v == nil {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONValue(buf, elem, order); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case float64:
+		// JSON has no NaN or infinities
+		if isNaNOrInf(v) {
+			buf.WriteString("null")
+			return nil
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
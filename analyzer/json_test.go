@@ -0,0 +1,37 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestMarshalMessageJSON(t *testing.T) {
+	const line = "2011-04-25-06:25:03.603,3,129029,36,255,43,e6,f1,3a,80,9c,c6,0d,00,12,38,aa,49,eb,51,07,00,0c,44," +
+		"95,fb,15,b8,00,40,e1,33,00,00,00,00,00,13,fc,09,5a,00,8c,00,ff,ff,ff,7f,00"
+
+	first, err := MarshalMessageJSON(decodeFast(t, line, false))
+	test.That(t, err, test.ShouldBeNil)
+	second, err := MarshalMessageJSON(decodeFast(t, line, false))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(first), test.ShouldEqual, string(second))
+
+	// Fields follow the PGN definition order, not alphabetical order.
+	out := string(first)
+	test.That(t, out, test.ShouldStartWith,
+		`{"timestamp":"2011-04-25-06:25:03.603","prio":3,"src":36,"dst":255,"pgn":129029,`+
+			`"description":"GNSS Position Data","fields":{"SID":230,"Date":"2011-04-25T00:00:00Z","Time":`)
+	test.That(t, strings.Index(out, `"Latitude"`), test.ShouldBeLessThan, strings.Index(out, `"Longitude"`))
+	test.That(t, strings.Index(out, `"Longitude"`), test.ShouldBeLessThan, strings.Index(out, `"Altitude"`))
+
+	// The content is the same as encoding/json produces.
+	msg := decodeFast(t, line, false)
+	expected, err := json.Marshal(msg)
+	test.That(t, err, test.ShouldBeNil)
+	var a, b map[string]interface{}
+	test.That(t, json.Unmarshal(first, &a), test.ShouldBeNil)
+	test.That(t, json.Unmarshal(expected, &b), test.ShouldBeNil)
+	test.That(t, a, test.ShouldResemble, b)
+}
@@ -0,0 +1,62 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "strings"
+
+// A LabelProvider returns the label to show for a field of a PGN, or for the PGN itself
+// when field is empty. It returns false to keep the built-in name or description.
+type LabelProvider func(pgn uint32, field string) (string, bool)
+
+var labelProvider LabelProvider
+
+// SetLabelProvider installs a provider of custom PGN descriptions and field names, e.g. to
+// localize them. Fields are identified by their built-in name, not the camelCase form.
+// Pass nil to go back to the built-in labels. The provider is shared by all analyzers, so
+// set it before any analyzer is in use.
+func SetLabelProvider(provider LabelProvider) {
+	labelProvider = provider
+}
+
+// pgnLabel returns the description to show for the PGN.
+func pgnLabel(pgn *pgnInfo) string {
+	if labelProvider != nil {
+		if label, ok := labelProvider(pgn.pgn, ""); ok {
+			return label
+		}
+	}
+	return pgn.description
+}
+
+// fieldLabel returns the name to show for the field, given the name the field would
+// otherwise be shown with. A repetition suffix on that name is kept.
+func fieldLabel(field *pgnField, fieldName string) string {
+	if labelProvider == nil || field.pgn == nil {
+		return fieldName
+	}
+	label, ok := labelProvider(field.pgn.pgn, field.name)
+	if !ok {
+		return fieldName
+	}
+	for _, base := range []string{field.camelName, field.name} {
+		if base != "" && strings.HasPrefix(fieldName, base) {
+			return label + fieldName[len(base):]
+		}
+	}
+	return label
+}
@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func TestSetLabelProvider(t *testing.T) {
+	SetLabelProvider(func(pgn uint32, field string) (string, bool) {
+		if pgn != 127250 {
+			return "", false
+		}
+		switch field {
+		case "":
+			return "Cap du navire", true
+		case "Heading":
+			return "Cap", true
+		default:
+			return "", false
+		}
+	})
+	defer SetLabelProvider(nil)
+
+	const line = "2022-11-14T01:47:30.890Z,2,127250,17,255,8,01,10,27,ff,7f,ff,7f,fd\n"
+
+	msg := decodeFast(t, strings.TrimSpace(line), false)
+	test.That(t, msg.Description, test.ShouldEqual, "Cap du navire")
+	test.That(t, msg.Fields["Cap"], test.ShouldAlmostEqual, 57.2958, 0.001)
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "Heading")
+	test.That(t, msg.Fields, test.ShouldContainKey, "Reference")
+
+	var out bytes.Buffer
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(line)
+	conf.OutFile = &out
+	conf.ShowVersion = false
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ana.Run(), test.ShouldBeNil)
+	test.That(t, out.String(), test.ShouldContainSubstring, "127250 Cap du navire:  SID = 1; Cap = 57.3 deg")
+
+	msg = decodeFast(t, "2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff", false)
+	test.That(t, msg.Description, test.ShouldEqual, "Rate of Turn")
+}
@@ -73,6 +73,19 @@ func addLookup(typ string, val int, desc string) {
 	lookupPairForTyp[typ][val] = desc
 }
 
+// RegisterLookup installs the given values for the named LOOKUP enumeration. Values are
+// added to an existing enumeration of the same name, replacing duplicate keys; otherwise a
+// new enumeration is created. Lookups are shared by all analyzers, so register them before
+// any analyzer is in use.
+func RegisterLookup(name string, pairs map[int]string) {
+	if _, ok := lookupPairForTyp[name]; !ok {
+		addlookupType(name, 0)
+	}
+	for val, desc := range pairs {
+		addLookup(name, val, desc)
+	}
+}
+
 type tripletPair struct {
 	val1 int
 	val2 int
@@ -121,6 +134,19 @@ func addLookupFieldType(fType string, n int, str, ft string) {
 	}
 }
 
+// addLookupFieldTypeLongitude is addLookupFieldType for a longitude field.
+func addLookupFieldTypeLongitude(fType string, n int, str, ft string) {
+	addLookupFieldType(fType, n, str, ft)
+	fillField := lookupFieldTypeForTyp[fType][n]
+	lookupFieldTypeForTyp[fType][n] = func(ana *Analyzer) (string, error) {
+		str, err := fillField(ana)
+		if err == nil {
+			ana.ftf.longitude = true
+		}
+		return str, err
+	}
+}
+
 //nolint:unparam
 func addLookupFieldTypeLookup(
 	fType string,
@@ -813,6 +839,10 @@ func initLookupTypes() {
 	addLookup("DIRECTION_REFERENCE", 1, "Magnetic")
 	addLookup("DIRECTION_REFERENCE", 2, "Error")
 
+	addlookupType("BEARING_MODE", 2)
+	addLookup("BEARING_MODE", 0, "Great Circle")
+	addLookup("BEARING_MODE", 1, "Rhumbline")
+
 	addlookupType("DIRECTION_RUDDER", 3)
 	addLookup("DIRECTION_RUDDER", 0, "No Order")
 	addLookup("DIRECTION_RUDDER", 1, "Move to starboard")
@@ -1069,6 +1099,13 @@ func initLookupTypes() {
 	addLookup("SEATALK_PILOT_MODE", 70, "Wind")
 	addLookup("SEATALK_PILOT_MODE", 74, "Track")
 
+	addlookupType("SEATALK_PILOT_MODE_16", 8*2)
+	addLookup("SEATALK_PILOT_MODE_16", 0, "Standby")
+	addLookup("SEATALK_PILOT_MODE_16", 64, "Auto, compass commanded")
+	addLookup("SEATALK_PILOT_MODE_16", 256, "Vane, Wind Mode")
+	addLookup("SEATALK_PILOT_MODE_16", 384, "Track Mode")
+	addLookup("SEATALK_PILOT_MODE_16", 385, "No Drift, COG referenced (In track, course changes)")
+
 	// Entertainment PGNs new circa 2016
 	// https://www.nmea.org/Assets/20160715%20corrigenda%20entertainment%20pgns%20.pdf
 
@@ -1599,9 +1636,9 @@ func initLookupTypes() {
 	addLookup("RODE_TYPE", 0, "Chain presently detected")
 	addLookup("RODE_TYPE", 1, "Rope presently detected")
 
-	addlookupType("DOCKINana.STATUS", 2)
-	addLookup("DOCKINana.STATUS", 0, "Not docked")
-	addLookup("DOCKINana.STATUS", 1, "Fully docked")
+	addlookupType("DOCKING_STATUS", 2)
+	addLookup("DOCKING_STATUS", 0, "Not docked")
+	addLookup("DOCKING_STATUS", 1, "Fully docked")
 
 	addlookupTypeBitfield("WINDLASS_OPERATION", 6)
 	addLookupBitfield("WINDLASS_OPERATION", 0, "System error")
@@ -2185,7 +2222,7 @@ func initLookupTypes() {
 	addLookupFieldType("BANDana.KEY_VALUE", 0x106, "Sailing ETA", "TIME_UFIX32_MS")
 	addLookupFieldType("BANDana.KEY_VALUE", 0x109, "Trip Time", "TIME_UFIX32_MS")
 	addLookupFieldType("BANDana.KEY_VALUE", 0x10e, "Bow Latitude", "GEO_FIX32")
-	addLookupFieldType("BANDana.KEY_VALUE", 0x10f, "Bow Longitude", "GEO_FIX32")
+	addLookupFieldTypeLongitude("BANDana.KEY_VALUE", 0x10f, "Bow Longitude", "GEO_FIX32")
 	addLookupFieldType("BANDana.KEY_VALUE", 0x110, "Start Line Bearing", "ANGLE_FIX16")
 	addLookupFieldType("BANDana.KEY_VALUE", 0x111, "Start Line Bias", "ANGLE_FIX16")
 	addLookupFieldType("BANDana.KEY_VALUE", 0x112, "Distance to Start Line Port", "LENGTH_UFIX32_CM")
@@ -0,0 +1,30 @@
+package analyzer
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestRegisterLookup(t *testing.T) {
+	RegisterLookup("TEST_PILOT_MODE", map[int]string{0: "Standby", 1: "Auto", 2: "Wind"})
+
+	ana := newTestAnalyzer(t, "", RawFormatFast)
+	field := lookupField("Pilot Mode", 8, "TEST_PILOT_MODE")
+	field.ft, _ = ana.getFieldType(field.fieldType)
+	field.pgn = &pgnInfo{pgn: 65379}
+
+	var bits int
+	value, ok, err := ana.convertField(&field, field.name, []byte{0x02}, 0, &bits)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, value, test.ShouldEqual, "Wind")
+
+	// Extending an enumeration is visible to fields that already use it.
+	RegisterLookup("TEST_PILOT_MODE", map[int]string{3: "Track"})
+	value, ok, err = ana.convertField(&field, field.name, []byte{0x03}, 0, &bits)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, value, test.ShouldEqual, "Track")
+	test.That(t, lookupFunctionPairForTyp["TEST_PILOT_MODE"](1), test.ShouldEqual, "Auto")
+}
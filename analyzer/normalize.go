@@ -0,0 +1,51 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/erh/gonmea/common"
+)
+
+// printNormalized prints the raw message, if it passes the filters, in PLAIN format with
+// one line per frame, as normalizedLines returns them.
+func (ana *Analyzer) printNormalized(rawMsg *common.RawMessage) {
+	if !ana.matchesFilters(rawMsg) {
+		return
+	}
+	for _, line := range ana.normalizedLines(rawMsg) {
+		fmt.Fprintf(ana.OutFile, "%s\n", line)
+	}
+}
+
+// normalizedLines returns the raw message as PLAIN lines, one per CAN frame that carries
+// it, whatever format it was read in. The timestamp is kept as the input had it.
+func (ana *Analyzer) normalizedLines(rawMsg *common.RawMessage) []string {
+	var lines []string
+	for _, frame := range ana.canFrames(rawMsg) {
+		var line strings.Builder
+		fmt.Fprintf(&line, "%s,%d,%d,%d,%d,%d", rawMsg.Timestamp, rawMsg.Prio, rawMsg.PGN, rawMsg.Src, rawMsg.Dst, len(frame))
+		for _, b := range frame {
+			fmt.Fprintf(&line, ",%02x", b)
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func TestNormalize(t *testing.T) {
+	capture := strings.Join([]string{
+		"!PDGY,128275,6,35,255,1234.56,OErTvf8acNMCADwHAAA",
+		"!PDGY,127251,2,14,255,1234.57,AFG///////8",
+		"!PDGY,129038,4,43,255,1234.58,AUAHjQ6HMHUCh2gRH3lcPQICpaUFXD0AAMD4AA",
+	}, "\n") + "\n"
+
+	readAll := func(input string, format RawFormat, multipackets MultiPackets) []*common.Message {
+		t.Helper()
+		conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+		conf.InFile = strings.NewReader(input)
+		conf.SelectedFormat = format
+		conf.multipackets = multipackets
+		ana, err := NewAnalyzer(conf)
+		test.That(t, err, test.ShouldBeNil)
+		var msgs []*common.Message
+		for {
+			msg, err := ana.ReadMessage()
+			if err != nil {
+				test.That(t, err, test.ShouldEqual, io.EOF)
+				return msgs
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+
+	var out bytes.Buffer
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(capture)
+	conf.OutFile = &out
+	conf.ShowVersion = false
+	conf.Normalize = true
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ana.Run(), test.ShouldBeNil)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	test.That(t, lines, test.ShouldHaveLength, 3+1+5)
+	test.That(t, lines[0], test.ShouldEqual, "1234.56,6,128275,35,255,8,00,0e,38,4a,d3,bd,ff,1a")
+	test.That(t, lines[3], test.ShouldEqual, "1234.57,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff")
+
+	want := readAll(capture, RawFormatNavLink2, MultiPacketsCoalesced)
+	test.That(t, want, test.ShouldHaveLength, 3)
+	got := readAll(out.String(), RawFormatPlain, MultiPacketsSeparate)
+	test.That(t, got, test.ShouldHaveLength, len(want))
+	for i, msg := range got {
+		// Only the reassembled messages know how many frames they came in
+		msg.Frames = 0
+		test.That(t, msg, test.ShouldResemble, want[i])
+	}
+}
@@ -25,8 +25,9 @@ func NewParserWithFormat(format RawFormat) (*Parser, error) {
 	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
 	conf.InFile = &buf
 	conf.SelectedFormat = format
-	if conf.SelectedFormat != RawFormatPlain && conf.SelectedFormat != RawFormatPlainOrFast {
-		conf.multipackets = multipacketsCoalesced
+	if conf.SelectedFormat != RawFormatPlain && conf.SelectedFormat != RawFormatPlainOrFast &&
+		conf.SelectedFormat != RawFormatMiniPlex {
+		conf.multipackets = MultiPacketsCoalesced
 	}
 	ana, err := NewAnalyzer(conf)
 	if err != nil {
@@ -49,7 +50,13 @@ func (p *Parser) ParseMessage(msgData []byte) (*common.Message, error) {
 	if err := p.setNextData(msgData); err != nil {
 		return nil, err
 	}
-	return p.ana.ReadMessage()
+	// Unlike ReadMessage, a single fast-packet frame is reported as ErrFastPacketIncomplete.
+	// Partial fast packets are never decoded, so none are queued.
+	rawMsg, err := p.ana.ReadRawMessage()
+	if err != nil {
+		return nil, err
+	}
+	return p.ana.convertRawMessage(rawMsg)
 }
 
 // ParseRawMessage parses the given data into a raw message.
@@ -60,6 +67,11 @@ func (p *Parser) ParseRawMessage(msgData []byte) (*common.RawMessage, error) {
 	return p.ana.ReadRawMessage()
 }
 
+// DetectedFormat returns the format messages are parsed in and whether it is known yet.
+func (p *Parser) DetectedFormat() (RawFormat, bool) {
+	return p.ana.DetectedFormat()
+}
+
 // ParseMessage parses the given data into a message. It will attempt
 // to detect the format of the message.
 func ParseMessage(msgData []byte) (*common.Message, RawFormat, error) {
@@ -107,3 +119,46 @@ func ParseRawMessageWithFormat(msgData []byte, format RawFormat) (*common.RawMes
 	p.ana.SelectedFormat = format
 	return p.ParseRawMessage(msgData)
 }
+
+// peekLogger is the logger PeekHeader parses with; it reports nothing.
+var peekLogger = common.NewLogger(io.Discard)
+
+// broadcastDst is the destination PeekHeader gives messages of formats without one, as
+// the default DefaultDst does.
+const broadcastDst = 255
+
+// PeekHeader detects the format of a single line and returns just its CAN header
+// fields, without decoding any of the PGN fields. It parses the line by itself, without
+// an Analyzer, so it is cheap enough to call on every line of a large capture. Formats
+// that only show in a header line, such as Garmin CSV, are not recognized.
+func PeekHeader(line string) (prio uint8, pgn uint32, src, dst uint8, ok bool) {
+	msg, _, _ := common.SplitSequence([]byte(line))
+	if len(msg) == 0 {
+		return 0, 0, 0, 0, false
+	}
+
+	var m common.RawMessage
+	r := -1
+	switch detectLineFormat(string(msg)) {
+	case RawFormatPlain, RawFormatFast:
+		if r = common.ParseRawFormatPlain(msg, &m, false, peekLogger); r < 0 {
+			r = common.ParseRawFormatFast(msg, &m, false, peekLogger)
+		}
+	case RawFormatAirmar:
+		r = common.ParseRawFormatAirmar(msg, &m, false, peekLogger)
+	case RawFormatChetco:
+		r = common.ParseRawFormatChetco(msg, &m, false, peekLogger)
+	case RawFormatYDWG02:
+		r = common.ParseRawFormatYDWG02(msg, &m, peekLogger)
+	case RawFormatNavLink2:
+		r = common.ParseRawFormatNavLink2(msg, &m, peekLogger)
+	case RawFormatActisenseN2KASCII:
+		r = common.ParseRawFormatActisenseN2KAscii(msg, &m, false, peekLogger)
+	case RawFormatMiniPlex:
+		r = common.ParseRawFormatMiniPlex(msg, &m, false, broadcastDst, peekLogger)
+	}
+	if r != 0 {
+		return 0, 0, 0, 0, false
+	}
+	return m.Prio, m.PGN, m.Src, m.Dst, true
+}
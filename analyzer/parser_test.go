@@ -2,6 +2,7 @@ package analyzer
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"testing"
 	"time"
@@ -71,3 +72,150 @@ func TestParser(t *testing.T) {
 		test.That(t, errors.Is(err, io.EOF), test.ShouldBeTrue)
 	})
 }
+
+func TestParserMiniPlex(t *testing.T) {
+	msgData := []byte("$MXPGN,01F801,2801,0316A48E1F706BD5*6C")
+
+	t.Run("raw", func(t *testing.T) {
+		raw, format, err := ParseRawMessage(msgData)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, format, test.ShouldEqual, RawFormatMiniPlex)
+		test.That(t, raw.PGN, test.ShouldEqual, 129025)
+		test.That(t, raw.Prio, test.ShouldEqual, 2)
+		test.That(t, raw.Src, test.ShouldEqual, 1)
+		test.That(t, raw.Dst, test.ShouldEqual, 255)
+		test.That(t, raw.Data[:raw.Len], test.ShouldResemble, []byte{0xd5, 0x6b, 0x70, 0x1f, 0x8e, 0xa4, 0x16, 0x03})
+	})
+
+	t.Run("decoded", func(t *testing.T) {
+		msg, err := ParseMessageWithFormat(msgData, RawFormatMiniPlex)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, msg.Pgn, test.ShouldEqual, 129025)
+		test.That(t, msg.Fields["Latitude"], test.ShouldAlmostEqual, 52.7461333, 1e-9)
+		test.That(t, msg.Fields["Longitude"], test.ShouldAlmostEqual, 5.1815566, 1e-9)
+	})
+
+	t.Run("bad checksum", func(t *testing.T) {
+		_, err := ParseRawMessageWithFormat([]byte("$MXPGN,01F801,2801,0316A48E1F706BD5*6D"), RawFormatMiniPlex)
+		test.That(t, errors.Is(err, io.EOF), test.ShouldBeTrue)
+	})
+}
+
+func TestPeekHeader(t *testing.T) {
+	for _, line := range []string{
+		"!PDGY,130567,6,200,255,25631.18,RgPczwYAQnYeAB4AAAADAAAAAABQbiMA",
+		"$MXPGN,01F801,2801,0316A48E1F706BD5*6C",
+		"2022-11-14T01:47:30.890Z,2,127251,14,255,8,ff,51,bf,ff,ff,ff,ff,ff",
+	} {
+		prio, pgn, src, dst, ok := PeekHeader(line)
+		test.That(t, ok, test.ShouldBeTrue)
+
+		msg, _, err := ParseMessage([]byte(line))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, int(prio), test.ShouldEqual, msg.Priority)
+		test.That(t, int(pgn), test.ShouldEqual, msg.Pgn)
+		test.That(t, int(src), test.ShouldEqual, msg.Src)
+		test.That(t, int(dst), test.ShouldEqual, msg.Dst)
+	}
+
+	_, _, _, _, ok := PeekHeader("not a message")
+	test.That(t, ok, test.ShouldBeFalse)
+}
+
+func TestParserInsufficientData(t *testing.T) {
+	p, err := NewParserWithFormat(RawFormatPlain)
+	test.That(t, err, test.ShouldBeNil)
+
+	t.Run("partial fast packet", func(t *testing.T) {
+		_, err := p.ParseMessage([]byte("2022-09-28-11:36:59.668,3,129029,0,255,8,00,2f,e7,95,3d,00,73,d6"))
+		test.That(t, errors.Is(err, ErrFastPacketIncomplete), test.ShouldBeTrue)
+		test.That(t, errors.Is(err, ErrInsufficientData), test.ShouldBeFalse)
+	})
+
+	t.Run("short single frame", func(t *testing.T) {
+		// Position, Rapid Update starts with a 32 bit latitude
+		_, err := p.ParseMessage([]byte("2022-09-28-11:36:59.668,2,129025,1,255,2,01,02"))
+		test.That(t, errors.Is(err, ErrInsufficientData), test.ShouldBeTrue)
+		test.That(t, errors.Is(err, ErrFastPacketIncomplete), test.ShouldBeFalse)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "PGN 129025 field 'Latitude' at bit 0 needs 4 bytes, 2 present")
+	})
+
+	t.Run("short first fast packet frame", func(t *testing.T) {
+		_, err := p.ParseMessage([]byte("2022-09-28-11:36:59.668,3,129029,1,255,1,00"))
+		test.That(t, errors.Is(err, ErrInsufficientData), test.ShouldBeTrue)
+	})
+}
+
+func TestParserCorruptFastPacket(t *testing.T) {
+	p, err := NewParserWithFormat(RawFormatPlain)
+	test.That(t, err, test.ShouldBeNil)
+
+	t.Run("frame past the declared size", func(t *testing.T) {
+		// 14 bytes take frames 0 to 2
+		_, err := p.ParseMessage([]byte("2022-09-28-11:36:59.668,6,128275,35,255,8,00,0e,38,4a,d3,bd,ff,1a"))
+		test.That(t, errors.Is(err, ErrFastPacketIncomplete), test.ShouldBeTrue)
+		_, err = p.ParseMessage([]byte("2022-09-28-11:36:59.668,6,128275,35,255,8,05,70,d3,02,00,3c,07,00"))
+		test.That(t, errors.Is(err, ErrFastPacketCorrupt), test.ShouldBeTrue)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "frame 5 is past the 3 frames of size 14")
+	})
+
+	t.Run("size more than a fast packet holds", func(t *testing.T) {
+		_, err := p.ParseMessage([]byte("2022-09-28-11:36:59.668,6,128275,35,255,8,20,ff,38,4a,d3,bd,ff,1a"))
+		test.That(t, errors.Is(err, ErrFastPacketCorrupt), test.ShouldBeTrue)
+		test.That(t, err.Error(), test.ShouldContainSubstring, "size 255 is more than 223")
+	})
+
+	t.Run("next packet", func(t *testing.T) {
+		for _, frame := range []string{"40,0e,38,4a,d3,bd,ff,1a", "41,70,d3,02,00,3c,07,00"} {
+			_, err := p.ParseMessage([]byte("2022-09-28-11:36:59.668,6,128275,35,255,8," + frame))
+			test.That(t, errors.Is(err, ErrFastPacketIncomplete), test.ShouldBeTrue)
+		}
+		msg, err := p.ParseMessage([]byte("2022-09-28-11:36:59.668,6,128275,35,255,8,42,00,ff,ff,ff,ff,ff,ff"))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, msg.Fields["Trip Log"], test.ShouldEqual, 1852)
+	})
+}
+
+func TestParserZeroLengthData(t *testing.T) {
+	p, err := NewParserWithFormat(RawFormatPlain)
+	test.That(t, err, test.ShouldBeNil)
+
+	for _, pgn := range []int{59904, 129029} {
+		msg, err := p.ParseMessage([]byte(fmt.Sprintf("2022-09-28-11:36:59.668,6,%d,3,255,0", pgn)))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, msg.Pgn, test.ShouldEqual, pgn)
+		test.That(t, msg.Src, test.ShouldEqual, 3)
+		test.That(t, msg.Fields, test.ShouldNotBeNil)
+		test.That(t, msg.Fields, test.ShouldBeEmpty)
+	}
+}
+
+func TestDetectedFormat(t *testing.T) {
+	p, err := NewParser()
+	test.That(t, err, test.ShouldBeNil)
+	_, ok := p.DetectedFormat()
+	test.That(t, ok, test.ShouldBeFalse)
+
+	_, err = p.ParseMessage([]byte("!PDGY,130567,6,200,255,25631.18,RgPczwYAQnYeAB4AAAADAAAAAABQbiMA"))
+	test.That(t, err, test.ShouldBeNil)
+	format, ok := p.DetectedFormat()
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, format, test.ShouldEqual, RawFormatNavLink2)
+	test.That(t, string(format), test.ShouldEqual, "NAVLINK2")
+
+	p, err = NewParserWithFormat(RawFormatMiniPlex)
+	test.That(t, err, test.ShouldBeNil)
+	format, ok = p.DetectedFormat()
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, format, test.ShouldEqual, RawFormatMiniPlex)
+}
+
+func BenchmarkPeekHeader(b *testing.B) {
+	const line = "2022-11-14T01:47:30.890Z,2,127251,14,255,8,ff,51,bf,ff,ff,ff,ff,ff"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, _, _, ok := PeekHeader(line); !ok {
+			b.Fatal("no header")
+		}
+	}
+}
@@ -19,6 +19,7 @@ package analyzer
 
 import (
 	"bytes"
+	"io"
 	"math"
 	"strconv"
 	"unicode"
@@ -99,6 +100,7 @@ type pgnField struct {
 	precision   int     /* How many decimal digits after the decimal point to print; usually 0 = automatic */
 	unitOffset  float64 /* Only used for K.C conversion in non-SI print */
 	proprietary bool    /* Field is only present if earlier PGN field is in proprietary range */
+	longitude   bool    /* Field is a longitude, which goes to 180 degrees instead of 90 */
 	hasSign     bool    /* Is the value signed, e.g. has both positive and negative values? */
 
 	/* The following fields are filled by C, no need to set in initializers */
@@ -134,6 +136,14 @@ type pgnInfo struct {
 	repeatingField2  uint8        /* Which field explains how often the repeating fields set #2 repeats? 255 = there is no field */
 }
 
+// isRepeatingField returns whether the field is part of one of the repeating sets.
+func (pgn *pgnInfo) isRepeatingField(field *pgnField) bool {
+	inSet := func(start, count uint8) bool {
+		return count > 0 && field.order >= start && field.order < start+count
+	}
+	return inSet(pgn.repeatingStart1, pgn.repeatingCount1) || inSet(pgn.repeatingStart2, pgn.repeatingCount2)
+}
+
 func lookupField(nam string, dataLen uint32, typ string) pgnField {
 	return pgnField{
 		name:       nam,
@@ -292,12 +302,14 @@ func latitudeI64Field(nam string) pgnField {
 func longitudeI32Field(nam string) pgnField {
 	return pgnField{
 		name: nam, size: 8 * 4, resolution: 1e-7, hasSign: true, unit: "deg", fieldType: "GEO_FIX32",
+		longitude: true,
 	}
 }
 
 func longitudeI64Field(nam string) pgnField {
 	return pgnField{
 		name: nam, size: 8 * 8, resolution: 1e-16, hasSign: true, unit: "deg", fieldType: "GEO_FIX64",
+		longitude: true,
 	}
 }
 
@@ -1190,6 +1202,18 @@ func (ana *Analyzer) checkPGNs() error {
 			}
 		}
 
+		// With the same PGN, only the last definition may lack match fields, otherwise
+		// getMatchingPgn can never select the ones that follow it.
+		if i > 0 && prn == ana.pgns[i-1].pgn {
+			prev := &ana.pgns[i-1]
+			if !prev.fallback && !ana.pgns[i].fallback && !prev.hasMatchFields {
+				return ana.Logger.Error("Internal error: PGN %d '%s' duplicates '%s' without match fields to tell them apart\n",
+					prn,
+					ana.pgns[i].description,
+					prev.description)
+			}
+		}
+
 		if prn == prevPRN || ana.pgns[i].fallback {
 			continue
 		}
@@ -1208,26 +1232,32 @@ func (ana *Analyzer) checkPGNs() error {
  * There can be multiple (with differing 'match' fields).
  */
 func (ana *Analyzer) searchForPgn(pgn uint32) (*pgnInfo, int) {
+	return searchPgns(ana.pgns, pgn)
+}
+
+// searchPgns is searchForPgn on a sorted list of PGN definitions, for callers without
+// an Analyzer.
+func searchPgns(pgns []pgnInfo, pgn uint32) (*pgnInfo, int) {
 	start := 0
-	end := len(ana.pgns)
+	end := len(pgns)
 	var mid int
 
 	for start <= end {
 		mid = (start + end) / 2
-		if pgn == ana.pgns[mid].pgn {
+		if pgn == pgns[mid].pgn {
 			// Return the first one, unless it is the catch-all
-			for mid > 0 && pgn == ana.pgns[mid-1].pgn {
+			for mid > 0 && pgn == pgns[mid-1].pgn {
 				mid--
 			}
-			if ana.pgns[mid].fallback {
+			if pgns[mid].fallback {
 				mid++
-				if pgn != ana.pgns[mid].pgn {
+				if pgn != pgns[mid].pgn {
 					return nil, -1
 				}
 			}
-			return &ana.pgns[mid], mid
+			return &pgns[mid], mid
 		}
-		if pgn < ana.pgns[mid].pgn {
+		if pgn < pgns[mid].pgn {
 			if mid == 0 {
 				return nil, -1
 			}
@@ -1356,6 +1386,37 @@ func (ana *Analyzer) getMatchingPgn(pgnID uint32, data []byte) (*pgnInfo, error)
 	return ana.searchForUnknownPgn(pgnID)
 }
 
+// A MatchField is a field whose value is fixed for a PGN definition. It is used to tell
+// apart definitions that share a PGN, so it must be set to Value when building a message.
+type MatchField struct {
+	Name  string
+	Value int64
+}
+
+// PGNMatchFields returns the match fields of the first definition of the given PGN,
+// in field order. It returns nil when the PGN is unknown or has no match fields.
+func PGNMatchFields(pgn uint32) []MatchField {
+	for i := range immutPGNs {
+		if immutPGNs[i].pgn != pgn {
+			continue
+		}
+		var matchFields []MatchField
+		for _, field := range immutPGNs[i].fieldList {
+			if field.name == "" {
+				break
+			}
+			if field.unit == "" || field.unit[0] != '=' {
+				continue
+			}
+			//nolint:errcheck
+			value, _ := strconv.ParseInt(field.unit[1:], 10, 64)
+			matchFields = append(matchFields, MatchField{Name: field.name, Value: value})
+		}
+		return matchFields
+	}
+	return nil
+}
+
 func varLenFieldListToFixed(list []pgnField) [33]pgnField {
 	var out [33]pgnField
 	if len(list) > len(out) {
@@ -1408,3 +1469,52 @@ func camelize(str string, upperCamelCase bool, order int) string {
 	}
 	return p.String()
 }
+
+// A PGNDefinition describes one definition of a PGN in the built-in tables.
+type PGNDefinition struct {
+	PGN         uint32
+	Description string
+	Fast        bool // Whether the PGN is sent as a fast packet
+	Repeating   bool // Whether some of the fields repeat
+	Fields      []PGNFieldInfo
+}
+
+// PGNDefinitions returns all PGN definitions, in table order, with the field resolutions
+// and units as this analyzer decodes them.
+func (ana *Analyzer) PGNDefinitions() []PGNDefinition {
+	defs := make([]PGNDefinition, 0, len(ana.pgns))
+	for i := range ana.pgns {
+		pgn := &ana.pgns[i]
+		def := PGNDefinition{
+			PGN:         pgn.pgn,
+			Description: pgn.description,
+			Fast:        pgn.packetType == packetTypeFast,
+			Repeating:   pgn.repeatingCount1 > 0 || pgn.repeatingCount2 > 0,
+			Fields:      make([]PGNFieldInfo, 0, pgn.fieldCount),
+		}
+		for j := uint32(0); j < pgn.fieldCount; j++ {
+			field := &pgn.fieldList[j]
+			def.Fields = append(def.Fields, PGNFieldInfo{
+				Name:       field.name,
+				FieldType:  field.fieldType,
+				Bits:       int(field.size),
+				Resolution: field.resolution,
+				Signed:     field.hasSign,
+				Unit:       field.unit,
+				UnitOffset: field.unitOffset,
+				Lookup:     field.lookup.name,
+			})
+		}
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+// PGNDefinitions is like Analyzer.PGNDefinitions using an analyzer with the default configuration.
+func PGNDefinitions() ([]PGNDefinition, error) {
+	ana, err := NewAnalyzer(NewConfigForLibrary(common.NewLogger(io.Discard)))
+	if err != nil {
+		return nil, err
+	}
+	return ana.PGNDefinitions(), nil
+}
@@ -0,0 +1,40 @@
+package analyzer
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestCheckPGNsDuplicates(t *testing.T) {
+	ana := newTestAnalyzer(t, "", RawFormatFast)
+	test.That(t, ana.checkPGNs(), test.ShouldBeNil)
+
+	_, idx := ana.searchForPgn(127251)
+	test.That(t, idx, test.ShouldBeGreaterThanOrEqualTo, 0)
+	dup := ana.pgns[idx]
+	dup.description = "Rate of Turn Copy"
+	pgns := make([]pgnInfo, 0, len(ana.pgns)+1)
+	pgns = append(pgns, ana.pgns[:idx+1]...)
+	pgns = append(pgns, dup)
+	pgns = append(pgns, ana.pgns[idx+1:]...)
+	ana.pgns = pgns
+
+	err := ana.checkPGNs()
+	test.That(t, err, test.ShouldNotBeNil)
+	test.That(t, err.Error(), test.ShouldContainSubstring, "PGN 127251 'Rate of Turn Copy' duplicates 'Rate of Turn'")
+
+	// A match field on the first definition makes the pair unambiguous.
+	ana.pgns[idx].hasMatchFields = true
+	test.That(t, ana.checkPGNs(), test.ShouldBeNil)
+}
+
+func TestPGNMatchFields(t *testing.T) {
+	test.That(t, PGNMatchFields(130842), test.ShouldResemble, []MatchField{
+		{Name: "Manufacturer Code", Value: 1857},
+		{Name: "Industry Code", Value: 4},
+		{Name: "Message ID", Value: 0},
+	})
+	test.That(t, PGNMatchFields(127250), test.ShouldBeNil)
+	test.That(t, PGNMatchFields(1), test.ShouldBeNil)
+}
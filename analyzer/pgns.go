@@ -1016,7 +1016,7 @@ func createPGNList() []pgnInfo {
 			complete:    packetStatusIncomplete,
 			packetType:  packetTypeSingle,
 			fieldList: varLenFieldListToFixed(append(company("1851"),
-				binaryField("Pilot Mode", 8*2, ""),
+				lookupField("Pilot Mode", 8*2, "SEATALK_PILOT_MODE_16"),
 				binaryField("Sub Mode", 8*2, ""),
 				binaryField("Pilot Mode Data", 8*1, ""),
 				reservedField(8*1))),
@@ -3197,8 +3197,8 @@ func createPGNList() []pgnInfo {
 				lookupField("Message ID", 6, "AIS_MESSAGE_ID"),
 				lookupField("Repeat Indicator", 2, "REPEAT_INDICATOR"),
 				mmsiField("User ID"),
-				latitudeI32Field("Longitude"),
-				longitudeI32Field("Latitude"),
+				longitudeI32Field("Longitude"),
+				latitudeI32Field("Latitude"),
 				lookupField("Position Accuracy", 1, "POSITION_ACCURACY"),
 				lookupField("RAIM", 1, "RAIM_FLAG"),
 				lookupFieldDesc("Time Stamp", 6, "TIME_STAMP", "0-59 = UTC second when the report was generated"),
@@ -0,0 +1,1347 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+// decodeFast decodes a single line in FAST format (all frames on one line).
+func decodeFast(t *testing.T, line string, si bool) *common.Message {
+	t.Helper()
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(line + "\n")
+	conf.SelectedFormat = RawFormatFast
+	conf.multipackets = MultiPacketsCoalesced
+	conf.showSI = si
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	msg, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	return msg
+}
+
+// runText runs the analyzer over input in FAST format and returns what it prints. The
+// options change the config before the analyzer is made.
+func runText(t *testing.T, input string, opts ...func(conf *Config)) string {
+	t.Helper()
+	var out bytes.Buffer
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(input + "\n")
+	conf.OutFile = &out
+	conf.ShowVersion = false
+	conf.SelectedFormat = RawFormatFast
+	conf.multipackets = MultiPacketsCoalesced
+	for _, opt := range opts {
+		opt(conf)
+	}
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ana.Run(), test.ShouldBeNil)
+	return out.String()
+}
+
+func TestPGN59904ISORequest(t *testing.T) {
+	// Requests PGN 126996 (0x01f014), sent little-endian as 14,f0,01.
+	const line = "2022-11-14T01:47:30.890Z,6,59904,0,255,3,14,f0,01"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "ISO Request")
+	test.That(t, msg.Fields["PGN"], test.ShouldEqual, 126996)
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring, "ISO Request:  PGN = 126996\n")
+
+	// All ones is the unknown value.
+	msg = decodeFast(t, "2022-11-14T01:47:30.890Z,6,59904,0,255,3,ff,ff,ff", false)
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "PGN")
+}
+
+func TestPGN129025ValidateRanges(t *testing.T) {
+	// Latitude 0x7ffffff0 is below the reserved values but would be 214.7 degrees.
+	const line = "2022-11-14T01:47:30.890Z,2,129025,1,255,8,f0,ff,ff,7f,8e,a4,16,03"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Fields["Latitude"], test.ShouldAlmostEqual, 214.7483632, 1e-7)
+
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(line + "\n")
+	conf.SelectedFormat = RawFormatFast
+	conf.multipackets = MultiPacketsCoalesced
+	conf.ValidateRanges = true
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	msg, err = ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "Latitude")
+	test.That(t, msg.Fields["Longitude"], test.ShouldAlmostEqual, 5.1815566, 1e-9)
+
+	out := runText(t, line, func(conf *Config) { conf.ValidateRanges = true })
+	test.That(t, out, test.ShouldContainSubstring, "Latitude = ERROR;")
+	test.That(t, out, test.ShouldContainSubstring, "Longitude =  5.1815566")
+
+	// Longitudes go up to 180 degrees: 45N 170E is valid, 45N 200E is not.
+	conf.InFile = strings.NewReader("2022-11-14T01:47:30.890Z,2,129025,1,255,8,80,74,d2,1a,00,f1,53,65\n" +
+		"2022-11-14T01:47:30.990Z,2,129025,1,255,8,80,74,d2,1a,00,94,35,77\n")
+	ana, err = NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	msg, err = ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Fields["Latitude"], test.ShouldAlmostEqual, 45.0, 1e-9)
+	test.That(t, msg.Fields["Longitude"], test.ShouldAlmostEqual, 170.0, 1e-9)
+	msg, err = ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Fields["Latitude"], test.ShouldAlmostEqual, 45.0, 1e-9)
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "Longitude")
+}
+
+func TestPGN127251RateOfTurn(t *testing.T) {
+	// Rate is 0xffffbf51 = -16559 in units of 1e-6/32 rad/s.
+	const line = "2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff"
+	const radPerSec = -16559 * 1e-6 / 32
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Pgn, test.ShouldEqual, 127251)
+	test.That(t, msg.Description, test.ShouldEqual, "Rate of Turn")
+	test.That(t, msg.Fields["SID"], test.ShouldEqual, 0)
+	test.That(t, msg.Fields["Rate"], test.ShouldAlmostEqual, radPerSec*radianToDegree, 1e-9)
+	test.That(t, msg.Fields["Rate"], test.ShouldAlmostEqual, -0.029649, 1e-6)
+
+	msg = decodeFast(t, line, true)
+	test.That(t, msg.Fields["Rate"], test.ShouldAlmostEqual, radPerSec, 1e-12)
+}
+
+func TestPGN127257Attitude(t *testing.T) {
+	// Yaw, pitch and roll are 175, 1745 and 5236 in units of 1e-4 rad.
+	const line = "2022-11-14T01:47:30.890Z,3,127257,15,255,8,2a,af,00,d1,06,74,14,ff"
+
+	msg := decodeFast(t, line, true)
+	test.That(t, msg.Description, test.ShouldEqual, "Attitude")
+	test.That(t, msg.Fields["SID"], test.ShouldEqual, 42)
+	test.That(t, msg.Fields["Yaw"], test.ShouldAlmostEqual, 0.0175, 1e-9)
+	test.That(t, msg.Fields["Pitch"], test.ShouldAlmostEqual, 0.1745, 1e-9)
+	test.That(t, msg.Fields["Roll"], test.ShouldAlmostEqual, 0.5236, 1e-9)
+
+	// Without -si all three angles are in degrees.
+	msg = decodeFast(t, line, false)
+	test.That(t, msg.Fields["Yaw"], test.ShouldAlmostEqual, 0.0175*radianToDegree, 1e-9)
+	test.That(t, msg.Fields["Pitch"], test.ShouldAlmostEqual, 0.1745*radianToDegree, 1e-9)
+	test.That(t, msg.Fields["Roll"], test.ShouldAlmostEqual, 0.5236*radianToDegree, 1e-9)
+	test.That(t, msg.Fields["Roll"], test.ShouldAlmostEqual, 30.0, 1e-2)
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring, "Yaw = 1.0 deg; Pitch = 10.0 deg; Roll = 30.0 deg\n")
+}
+
+func TestPGN129284NavigationData(t *testing.T) {
+	// 1234.56 m to waypoint 2 at 52.3676N 4.9041E, bearings 1.5708 and 3.1416 rad,
+	// ETA 2022-11-14 12:34:56 and closing at 2.57 m/s.
+	const (
+		prefix = "2022-11-14T01:47:30.890Z,3,129284,1,255,34,11,40,e2,01,00,"
+		suffix = ",00,9f,ff,1a,6e,4b,5c,3d,b8,7a,01,00,00,00,02,00,00,00,60,a9,36,1f,68,4e,ec,02,01,01"
+	)
+	line := prefix + "10" + suffix
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Navigation Data")
+	test.That(t, msg.Fields["SID"], test.ShouldEqual, 17)
+	test.That(t, msg.Fields["Distance to Waypoint"], test.ShouldAlmostEqual, 1234.56, 1e-9)
+	test.That(t, msg.Fields["Course/Bearing reference"], test.ShouldEqual, "True")
+	test.That(t, msg.Fields["Perpendicular Crossed"], test.ShouldEqual, "No")
+	test.That(t, msg.Fields["Arrival Circle Entered"], test.ShouldEqual, "Yes")
+	test.That(t, msg.Fields["Calculation Type"], test.ShouldEqual, "Great Circle")
+	test.That(t, msg.Fields["ETA Time"], test.ShouldEqual, 12*time.Hour+34*time.Minute+56*time.Second)
+	test.That(t, msg.Fields["ETA Date"], test.ShouldEqual, time.Date(2022, time.November, 14, 0, 0, 0, 0, time.UTC))
+	test.That(t, msg.Fields["Bearing, Origin to Destination Waypoint"], test.ShouldAlmostEqual, 90.0, 1e-3)
+	test.That(t, msg.Fields["Bearing, Position to Destination Waypoint"], test.ShouldAlmostEqual, 180.0, 1e-3)
+	test.That(t, msg.Fields["Origin Waypoint Number"], test.ShouldEqual, 1)
+	test.That(t, msg.Fields["Destination Waypoint Number"], test.ShouldEqual, 2)
+	test.That(t, msg.Fields["Destination Latitude"], test.ShouldAlmostEqual, 52.3676, 1e-9)
+	test.That(t, msg.Fields["Destination Longitude"], test.ShouldAlmostEqual, 4.9041, 1e-9)
+	test.That(t, msg.Fields["Waypoint Closing Velocity"], test.ShouldAlmostEqual, 2.57, 1e-9)
+
+	msg = decodeFast(t, line, true)
+	test.That(t, msg.Fields["Bearing, Origin to Destination Waypoint"], test.ShouldAlmostEqual, 1.5708, 1e-9)
+	test.That(t, msg.Fields["Bearing, Position to Destination Waypoint"], test.ShouldAlmostEqual, 3.1416, 1e-9)
+
+	// Magnetic bearings calculated along the rhumb line.
+	msg = decodeFast(t, prefix+"51"+suffix, false)
+	test.That(t, msg.Fields["Course/Bearing reference"], test.ShouldEqual, "Magnetic")
+	test.That(t, msg.Fields["Calculation Type"], test.ShouldEqual, "Rhumbline")
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring,
+		"Calculation Type = Great Circle; ETA Time = 12:34:56; ETA Date = 2022.11.14; "+
+			"Bearing, Origin to Destination Waypoint = 90.0 deg; Bearing, Position to Destination Waypoint = 180.0 deg; "+
+			"Origin Waypoint Number = 1; Destination Waypoint Number = 2; "+
+			"Destination Latitude = 52.3676000; Destination Longitude =  4.9041000; Waypoint Closing Velocity = 2.57 m/s\n")
+}
+
+func TestPGN129794AISStaticData(t *testing.T) {
+	// Callsign "PD1234 ", name "EVER GIVEN" padded with '@' and the destination given
+	// separately, so that different paddings can be tried.
+	const (
+		prefix = "2022-11-14T01:47:30.890Z,6,129794,43,255,75,05,40,07,8d,0e,38,b4,95,00," +
+			"50,44,31,32,33,34,20," +
+			"45,56,45,52,20,47,49,56,45,4e,40,40,40,40,40,40,40,40,40,40," +
+			"46,9e,0f,4c,02,22,01,ac,0d,6e,4b,80,30,3d,12,aa,05,"
+		suffix      = ",84,e0"
+		rotterdam   = "52,4f,54,54,45,52,44,41,4d"
+		nulPadding  = ",00,00,00,00,00,00,00,00,00,00,00"
+		onesPadding = ",ff,ff,ff,ff,ff,ff,ff,ff,ff,ff,ff"
+	)
+	line := prefix + rotterdam + nulPadding + suffix
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "AIS Class A Static and Voyage Related Data")
+	test.That(t, msg.Fields["User ID"], test.ShouldEqual, 244123456)
+	test.That(t, msg.Fields["IMO number"], test.ShouldEqual, 9811000)
+	test.That(t, msg.Fields["Callsign"], test.ShouldEqual, "PD1234")
+	test.That(t, msg.Fields["Name"], test.ShouldEqual, "EVER GIVEN")
+	test.That(t, msg.Fields["Destination"], test.ShouldEqual, "ROTTERDAM")
+	test.That(t, msg.Fields["Type of ship"], test.ShouldEqual, "Cargo ship")
+	test.That(t, msg.Fields["Length"], test.ShouldAlmostEqual, 399.8, 1e-9)
+	test.That(t, msg.Fields["Beam"], test.ShouldAlmostEqual, 58.8, 1e-9)
+	test.That(t, msg.Fields["ETA Date"], test.ShouldEqual, time.Date(2022, time.November, 14, 0, 0, 0, 0, time.UTC))
+	test.That(t, msg.Fields["ETA Time"], test.ShouldEqual, 8*time.Hour+30*time.Minute)
+	test.That(t, msg.Fields["Draft"], test.ShouldAlmostEqual, 14.5, 1e-9)
+	test.That(t, msg.Fields["GNSS type"], test.ShouldEqual, "GPS")
+	test.That(t, msg.Fields["AIS Transceiver information"], test.ShouldEqual, "Channel A VDL reception")
+
+	msg = decodeFast(t, prefix+rotterdam+onesPadding+suffix, false)
+	test.That(t, msg.Fields["Destination"], test.ShouldEqual, "ROTTERDAM")
+
+	// A destination that is all padding is not there at all.
+	msg = decodeFast(t, prefix+"40,40,40,40,40,40,40,40,40"+onesPadding+suffix, false)
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "Destination")
+	test.That(t, msg.Fields["AIS Transceiver information"], test.ShouldEqual, "Channel A VDL reception")
+
+	out := runText(t, line, func(conf *Config) { conf.ShowJSON = true })
+	test.That(t, out, test.ShouldContainSubstring, `"Callsign":"PD1234","Name":"EVER GIVEN",`)
+	test.That(t, out, test.ShouldContainSubstring,
+		`"ETA Date":"2022.11.14","ETA Time":"08:30:00","Draft":14.50,"Destination":"ROTTERDAM",`)
+}
+
+func TestPGN130316TemperatureExtendedRange(t *testing.T) {
+	// Temperature is the 24 bit value 0x045f57 = 286551 mK, or 13.401 C.
+	const line = "2022-11-14T01:47:30.890Z,5,130316,35,255,8,01,02,0e,57,5f,04,fa,0b"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Temperature Extended Range")
+	test.That(t, msg.Fields["SID"], test.ShouldEqual, 1)
+	test.That(t, msg.Fields["Instance"], test.ShouldEqual, 2)
+	test.That(t, msg.Fields["Source"], test.ShouldEqual, "Exhaust Gas Temperature")
+	test.That(t, msg.Fields["Temperature"], test.ShouldAlmostEqual, 13.401, 1e-9)
+	test.That(t, msg.Fields["Set Temperature"], test.ShouldAlmostEqual, 33.45, 1e-9)
+
+	msg = decodeFast(t, line, true)
+	test.That(t, msg.Fields["Temperature"], test.ShouldAlmostEqual, 286.551, 1e-9)
+	test.That(t, msg.Fields["Set Temperature"], test.ShouldAlmostEqual, 306.6, 1e-9)
+
+	for _, showJSON := range []bool{false, true} {
+		out := runText(t, line, func(conf *Config) { conf.ShowJSON = showJSON })
+		if showJSON {
+			test.That(t, out, test.ShouldContainSubstring, `"Temperature":13.401,"Set Temperature":33.45}`)
+		} else {
+			test.That(t, out, test.ShouldContainSubstring, "Temperature = 13.401 C; Set Temperature = 33.45 C\n")
+		}
+	}
+}
+
+func TestPGN130842SimnetVariants(t *testing.T) {
+	t.Run("msg 24 part A", func(t *testing.T) {
+		msg := decodeFast(t, "2022-11-14T01:47:30.890Z,7,130842,35,255,29,41,9f,00,01,02,40,07,8d,0e,"+
+			"53,45,41,20,4c,49,4f,4e,20,20,20,20,20,20,20,20,20,20,20,20", false)
+		test.That(t, msg.Description, test.ShouldEqual, "Simnet: AIS Class B static data (msg 24 Part A)")
+		test.That(t, msg.Fields["Message ID"], test.ShouldEqual, 0)
+		test.That(t, msg.Fields["User ID"], test.ShouldEqual, 244123456)
+		test.That(t, msg.Fields["Name"], test.ShouldEqual, "SEA LION")
+	})
+
+	t.Run("msg 24 part B", func(t *testing.T) {
+		msg := decodeFast(t, "2022-11-14T01:47:30.890Z,7,130842,35,255,37,41,9f,01,01,02,40,07,8d,0e,25,"+
+			"53,49,4d,52,41,44,20,50,44,31,32,33,34,20,78,00,28,00,14,00,3c,00,00,00,00,00,ff", false)
+		test.That(t, msg.Description, test.ShouldEqual, "Simnet: AIS Class B static data (msg 24 Part B)")
+		test.That(t, msg.Fields["Message ID"], test.ShouldEqual, 1)
+		test.That(t, msg.Fields["Vendor ID"], test.ShouldEqual, "SIMRAD")
+		test.That(t, msg.Fields["Callsign"], test.ShouldEqual, "PD1234")
+		test.That(t, msg.Fields["Length"], test.ShouldAlmostEqual, 12.0)
+	})
+
+	t.Run("unknown message id", func(t *testing.T) {
+		msg := decodeFast(t, "2022-11-14T01:47:30.890Z,7,130842,35,255,13,41,9f,05,01,02,40,07,8d,0e,00,00,00,00", false)
+		test.That(t, msg.Pgn, test.ShouldEqual, 130842)
+		test.That(t, msg.Description, test.ShouldNotContainSubstring, "Simnet")
+		test.That(t, msg.Description, test.ShouldNotContainSubstring, "Furuno")
+	})
+}
+
+func TestPGN127513PeukertExponent(t *testing.T) {
+	// Raw Peukert exponent 0x7d = 125 decodes as (125 + 500) * 0.002
+	msg := decodeFast(t, "2022-11-14T01:47:30.890Z,6,127513,17,255,8,00,13,22,c8,00,05,7d,5a", false)
+	test.That(t, msg.Description, test.ShouldEqual, "Battery Configuration Status")
+	test.That(t, msg.Fields["Capacity"], test.ShouldEqual, 200)
+	test.That(t, msg.Fields["Peukert Exponent"], test.ShouldAlmostEqual, 1.25, 1e-9)
+	test.That(t, msg.Fields["Charge Efficiency Factor"], test.ShouldEqual, 90)
+
+	msg = decodeFast(t, "2022-11-14T01:47:30.890Z,6,127513,17,255,8,00,13,22,c8,00,05,00,5a", false)
+	test.That(t, msg.Fields["Peukert Exponent"], test.ShouldAlmostEqual, 1.0, 1e-9)
+
+	msg = decodeFast(t, "2022-11-14T01:47:30.890Z,6,127513,17,255,8,00,13,22,c8,00,05,fd,5a", false)
+	test.That(t, msg.Fields["Peukert Exponent"], test.ShouldAlmostEqual, 1.506, 1e-9)
+}
+
+func TestPGN127513BatteryConfiguration(t *testing.T) {
+	// A 400 Ah 12V lithium battery of type AGM without equalization, with reserved bits set.
+	const line = "2022-11-14T01:47:30.890Z,6,127513,17,255,8,01,c2,11,90,01,05,0a,5f"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Fields["Instance"], test.ShouldEqual, 1)
+	test.That(t, msg.Fields["Battery Type"], test.ShouldEqual, "AGM")
+	test.That(t, msg.Fields["Supports Equalization"], test.ShouldEqual, "No")
+	test.That(t, msg.Fields["Nominal Voltage"], test.ShouldEqual, "12V")
+	test.That(t, msg.Fields["Chemistry"], test.ShouldEqual, "Li")
+	test.That(t, msg.Fields["Capacity"], test.ShouldEqual, 400)
+	test.That(t, msg.Fields["Temperature Coefficient"], test.ShouldEqual, 5)
+	test.That(t, msg.Fields["Peukert Exponent"], test.ShouldAlmostEqual, 1.02, 1e-9)
+	test.That(t, msg.Fields["Charge Efficiency Factor"], test.ShouldEqual, 95)
+
+	// In SI units the capacity is in coulombs.
+	msg = decodeFast(t, line, true)
+	test.That(t, msg.Fields["Capacity"], test.ShouldAlmostEqual, 400*3600.0, 1e-6)
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring,
+		"Nominal Voltage = 12V; Chemistry = Li; Capacity = 400 Ah; Temperature Coefficient = 5 %; "+
+			"Peukert Exponent = 1.020; Charge Efficiency Factor = 95 %\n")
+}
+
+func TestPGN130321FloatField(t *testing.T) {
+	// Salinity is the IEEE-754 float 35.5 (0x420e0000), sent little-endian as 00,00,0e,42.
+	const line = "2022-11-14T01:47:30.890Z,6,130321,17,255,25,f0,38,4a,00,51,25,02,87,68,11,1f,f8,19,e8,02," +
+		"00,00,0e,42,8e,70,02,01,02,01"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Salinity Station Data")
+	test.That(t, msg.Fields["Salinity"], test.ShouldEqual, 35.5)
+	test.That(t, msg.Fields["Water Temperature"], test.ShouldAlmostEqual, 15.0, 0.01)
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring, "Salinity = 35.5 ppt")
+}
+
+func TestPGN129026BitOrder(t *testing.T) {
+	// The COG Reference lookup sits in the two least significant bits of byte 1 with the
+	// reserved bits (all ones) above it. If bit fields were MSB first it would read 3.
+	for _, tc := range []struct {
+		refByte   string
+		reference string
+	}{
+		{"fc", "True"},
+		{"fd", "Magnetic"},
+	} {
+		t.Run(tc.reference, func(t *testing.T) {
+			msg := decodeFast(t, "2022-11-14T01:47:30.890Z,2,129026,3,255,8,05,"+tc.refByte+",5c,3d,02,02,ff,ff", false)
+			test.That(t, msg.Description, test.ShouldEqual, "COG & SOG, Rapid Update")
+			test.That(t, msg.Fields["SID"], test.ShouldEqual, 5)
+			test.That(t, msg.Fields["COG Reference"], test.ShouldEqual, tc.reference)
+			test.That(t, msg.Fields["COG"], test.ShouldAlmostEqual, 1.5708*radianToDegree, 1e-9)
+			test.That(t, msg.Fields["SOG"], test.ShouldAlmostEqual, 5.14, 1e-9)
+		})
+	}
+}
+
+func TestPGN60928NameFields(t *testing.T) {
+	// NAME 0xc9f1829de83abcde, packed LSB first: unique number 0x1abcde, manufacturer 1857,
+	// ECU instance 5, function instance 19, function 130, spare 1, class 120,
+	// system instance 9, industry group 4 and arbitrary address capable 1.
+	msg := decodeFast(t, "2022-09-10T12:10:16.614Z,6,60928,5,255,8,de,bc,3a,e8,9d,82,f1,c9", false)
+	test.That(t, msg.Description, test.ShouldEqual, "ISO Address Claim")
+	test.That(t, msg.Fields["Unique Number"], test.ShouldEqual, 0x1abcde)
+	test.That(t, msg.Fields["Manufacturer Code"], test.ShouldEqual, "Simrad")
+	test.That(t, msg.Fields["Device Instance Lower"], test.ShouldEqual, 5)
+	test.That(t, msg.Fields["Device Instance Upper"], test.ShouldEqual, 19)
+	test.That(t, msg.Fields["Device Function"], test.ShouldEqual, "Display")
+	test.That(t, msg.Fields["Device Class"], test.ShouldEqual, "Display")
+	test.That(t, msg.Fields["System Instance"], test.ShouldEqual, 9)
+	test.That(t, msg.Fields["Industry Group"], test.ShouldEqual, "Marine")
+	test.That(t, msg.Fields["Arbitrary address capable"], test.ShouldEqual, 1)
+
+	descs, err := DescribeDecode(60928, []byte{0xde, 0xbc, 0x3a, 0xe8, 0x9d, 0x82, 0xf1, 0xc9})
+	test.That(t, err, test.ShouldBeNil)
+	bitCount := 0
+	for _, desc := range descs {
+		bitCount += desc.Bits
+	}
+	test.That(t, bitCount, test.ShouldEqual, 64)
+}
+
+func TestPGN129539DOPs(t *testing.T) {
+	// HDOP 0.90, VDOP 1.50 and TDOP not available (0x7fff).
+	msg := decodeFast(t, "2022-11-14T01:47:30.890Z,6,129539,3,255,8,07,d3,5a,00,96,00,ff,7f", false)
+	test.That(t, msg.Description, test.ShouldEqual, "GNSS DOPs")
+	test.That(t, msg.Fields["SID"], test.ShouldEqual, 7)
+	test.That(t, msg.Fields["Desired Mode"], test.ShouldEqual, "Auto")
+	test.That(t, msg.Fields["Actual Mode"], test.ShouldEqual, "3D")
+	test.That(t, msg.Fields["HDOP"], test.ShouldAlmostEqual, 0.9, 1e-9)
+	test.That(t, msg.Fields["VDOP"], test.ShouldAlmostEqual, 1.5, 1e-9)
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "TDOP")
+
+	// Like canboat, all three DOPs of this PGN are the signed FIX16 variant.
+	ana := newTestAnalyzer(t, "", RawFormatFast)
+	pgn, _ := ana.searchForPgn(129539)
+	for _, field := range pgn.fieldList[4:7] {
+		test.That(t, field.fieldType, test.ShouldEqual, "DILUTION_OF_PRECISION_FIX16")
+		test.That(t, field.hasSign, test.ShouldBeTrue)
+	}
+}
+
+func TestPGN127506DCDetailedStatus(t *testing.T) {
+	const line = "2022-11-14T01:47:30.890Z,6,127506,17,255,11,01,00,00,57,5f,7d,00,05,00,b4,00"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "DC Detailed Status")
+	test.That(t, msg.Fields["DC Type"], test.ShouldEqual, "Battery")
+	test.That(t, msg.Fields["State of Charge"], test.ShouldEqual, 87)
+	test.That(t, msg.Fields["State of Health"], test.ShouldEqual, 95)
+	test.That(t, msg.Fields["Time Remaining"], test.ShouldEqual, 125*time.Minute)
+	test.That(t, msg.Fields["Ripple Voltage"], test.ShouldAlmostEqual, 0.05, 1e-9)
+	test.That(t, msg.Fields["Remaining capacity"], test.ShouldEqual, 180)
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring, "State of Charge = 87; State of Health = 95; Time Remaining = 02:05:00; Ripple Voltage = 0.05 V; Remaining capacity = 180 Ah")
+}
+
+func TestPGN127489EngineDiscreteStatus(t *testing.T) {
+	// Discrete Status 1 = 0x0107 (bits 0, 1, 2 and 8), Discrete Status 2 = 0x0281 (bits 0, 7 and 9).
+	const line = "2022-11-14T01:47:30.890Z,2,127489,16,255,26,00,ff,ff,ff,ff,ff,ff,ff,7f,ff,7f," +
+		"ff,ff,ff,ff,ff,ff,ff,ff,ff,07,01,81,02,7f,7f"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Engine Parameters, Dynamic")
+	test.That(t, msg.Fields["Instance"], test.ShouldEqual, "Single Engine or Dual Engine Port")
+	test.That(t, msg.Fields["Discrete Status 1"], test.ShouldResemble, []interface{}{
+		"Check Engine", "Over Temperature", "Low Oil Pressure", "Water In Fuel",
+	})
+	// Bit 9 has no name, so its value is listed instead.
+	test.That(t, msg.Fields["Discrete Status 2"], test.ShouldResemble, []interface{}{
+		"Warning Level 1", "Engine Shutting Down", int64(512),
+	})
+
+	msg = decodeFast(t, strings.Replace(line, "07,01,81,02", "00,00,00,00", 1), false)
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "Discrete Status 1")
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "Discrete Status 2")
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring,
+		"Discrete Status 1 = Check Engine,Over Temperature,Low Oil Pressure,Water In Fuel; "+
+			"Discrete Status 2 = Warning Level 1,Engine Shutting Down,512;")
+}
+
+func TestPGN130306WindData(t *testing.T) {
+	// Wind speed 5.14 m/s and angle 0.7854 rad; the reference sits below 5 reserved bits.
+	for _, tc := range []struct {
+		refByte   string
+		reference string
+	}{
+		{"fa", "Apparent"},
+		{"fb", "True (boat referenced)"},
+		{"f8", "True (ground referenced to North)"},
+	} {
+		t.Run(tc.reference, func(t *testing.T) {
+			line := "2022-11-14T01:47:30.890Z,2,130306,1,255,8,00,02,02,ae,1e," + tc.refByte + ",ff,ff"
+
+			msg := decodeFast(t, line, false)
+			test.That(t, msg.Description, test.ShouldEqual, "Wind Data")
+			test.That(t, msg.Fields["SID"], test.ShouldEqual, 0)
+			test.That(t, msg.Fields["Reference"], test.ShouldEqual, tc.reference)
+			test.That(t, msg.Fields["Wind Speed"], test.ShouldAlmostEqual, 5.14, 1e-9)
+			test.That(t, msg.Fields["Wind Angle"], test.ShouldAlmostEqual, 45.0, 1e-3)
+
+			msg = decodeFast(t, line, true)
+			test.That(t, msg.Fields["Wind Angle"], test.ShouldAlmostEqual, 0.7854, 1e-9)
+
+			out := runText(t, line)
+			test.That(t, out, test.ShouldContainSubstring,
+				"Wind Speed = 5.14 m/s; Wind Angle = 45.0 deg; Reference = "+tc.reference+"\n")
+		})
+	}
+}
+
+func TestPGN128776WindlassControlStatus(t *testing.T) {
+	const line = "2023-01-01T00:00:00.000Z,2,128776,20,255,8,01,02,d6,32,41,14,f1,ff"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Windlass Control Status")
+	test.That(t, msg.Fields["Windlass Direction Control"], test.ShouldEqual, "Up")
+	test.That(t, msg.Fields["Anchor Docking Control"], test.ShouldEqual, "On")
+	test.That(t, msg.Fields["Speed Control Type"], test.ShouldEqual, "Dual speed")
+	test.That(t, msg.Fields["Power Enable"], test.ShouldEqual, "On")
+	test.That(t, msg.Fields["Mechanical Lock"], test.ShouldEqual, "Off")
+	test.That(t, msg.Fields["Anchor Light"], test.ShouldEqual, "On")
+	test.That(t, msg.Fields["Command Timeout"], test.ShouldEqual, 100*time.Millisecond)
+	test.That(t, msg.Fields["Windlass Control Events"], test.ShouldResemble,
+		[]interface{}{"Another device controlling windlass"})
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring,
+		"Command Timeout = 00:00:00.100; Windlass Control Events = Another device controlling windlass\n")
+}
+
+func TestPGN128777WindlassOperatingStatus(t *testing.T) {
+	const line = "2023-01-01T00:00:00.000Z,2,128777,20,255,8,01,02,c5,d2,04,32,00,48"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Anchor Windlass Operating Status")
+	test.That(t, msg.Fields["Windlass Direction Control"], test.ShouldEqual, "Down")
+	test.That(t, msg.Fields["Windlass Motion Status"], test.ShouldEqual, "Deployment occurring")
+	test.That(t, msg.Fields["Rode Type Status"], test.ShouldEqual, "Chain presently detected")
+	test.That(t, msg.Fields["Rode Counter Value"], test.ShouldAlmostEqual, 123.4, 1e-9)
+	test.That(t, msg.Fields["Windlass Line Speed"], test.ShouldAlmostEqual, 0.5, 1e-9)
+	test.That(t, msg.Fields["Anchor Docking Status"], test.ShouldEqual, "Not docked")
+	test.That(t, msg.Fields["Windlass Operating Events"], test.ShouldResemble,
+		[]interface{}{"Sensor error", "End of rode reached"})
+
+	// With no events set the bit lookup field is left out.
+	msg = decodeFast(t, "2023-01-01T00:00:00.000Z,2,128777,20,255,8,01,02,c5,d2,04,32,00,01", false)
+	test.That(t, msg.Fields["Anchor Docking Status"], test.ShouldEqual, "Fully docked")
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "Windlass Operating Events")
+}
+
+func TestPGN130577DirectionData(t *testing.T) {
+	// COG 1.5708 rad, SOG 5 m/s, heading 3.15 rad, STW 4.5 m/s, set 1 rad, drift 0.5 m/s.
+	const line = "2023-01-01T00:00:00.000Z,3,130577,20,255,14,c0,07,5c,3d,f4,01,0c,7b,c2,01,10,27,32,00"
+
+	msg := decodeFast(t, line, true)
+	test.That(t, msg.Description, test.ShouldEqual, "Direction Data")
+	test.That(t, msg.Fields["Data Mode"], test.ShouldEqual, "Autonomous")
+	test.That(t, msg.Fields["COG Reference"], test.ShouldEqual, "True")
+	test.That(t, msg.Fields["SID"], test.ShouldEqual, 7)
+	test.That(t, msg.Fields["COG"], test.ShouldAlmostEqual, 1.5708, 1e-9)
+	test.That(t, msg.Fields["SOG"], test.ShouldAlmostEqual, 5, 1e-9)
+	test.That(t, msg.Fields["Heading"], test.ShouldAlmostEqual, 3.15, 1e-9)
+	test.That(t, msg.Fields["Speed through Water"], test.ShouldAlmostEqual, 4.5, 1e-9)
+	test.That(t, msg.Fields["Set"], test.ShouldAlmostEqual, 1, 1e-9)
+	test.That(t, msg.Fields["Drift"], test.ShouldAlmostEqual, 0.5, 1e-9)
+
+	msg = decodeFast(t, line, false)
+	test.That(t, msg.Fields["COG"], test.ShouldAlmostEqual, 90, 1e-3)
+	test.That(t, msg.Fields["Set"], test.ShouldAlmostEqual, 57.2958, 1e-4)
+
+	coalesced := MultiPacketsCoalesced
+	out := runText(t, line, func(conf *Config) {
+		conf.multipackets = MultiPacketsSeparate
+		conf.ForceMultiPackets = &coalesced
+	})
+	test.That(t, out, test.ShouldContainSubstring,
+		"COG = 90.0 deg; SOG = 5.00 m/s; Heading = 180.5 deg; Speed through Water = 4.50 m/s; Set = 57.3 deg; Drift = 0.50 m/s\n")
+}
+
+func TestAirmarProprietaryPGNs(t *testing.T) {
+	// Proprietary PGNs in the Airmar transport, each with the CAN id (priority 6, source 0x23).
+	const input = `2023-01-01T00:00:00.000Z - 65410 18FF8223 87,98,01,77,74,e2,04,ff
+2023-01-01T00:00:00.100Z - 65285 18FF0523 87,98,fa,ff,ff,ff,ff,ff
+2023-01-01T00:00:00.200Z - 65285 18FF0523 8c,98,00,77,74,ff,ff,ff
+`
+	ana := newTestAnalyzer(t, input, RawFormatUnknown)
+
+	msg, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ana.SelectedFormat, test.ShouldEqual, RawFormatAirmar)
+	test.That(t, msg.Timestamp, test.ShouldEqual, "2023-01-01T00:00:00.000Z")
+	test.That(t, msg.Priority, test.ShouldEqual, 6)
+	test.That(t, msg.Src, test.ShouldEqual, 0x23)
+	test.That(t, msg.Pgn, test.ShouldEqual, 65410)
+	test.That(t, msg.Description, test.ShouldEqual, "Airmar: Device Information")
+	test.That(t, msg.Fields["Manufacturer Code"], test.ShouldEqual, "Airmar")
+	test.That(t, msg.Fields["Internal Device Temperature"], test.ShouldAlmostEqual, 25, 1e-9)
+	test.That(t, msg.Fields["Supply Voltage"], test.ShouldAlmostEqual, 12.5, 1e-9)
+
+	// 65285 is defined by Airmar and by Lowrance; the manufacturer code selects between them.
+	msg, err = ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Description, test.ShouldEqual, "Airmar: Boot State Acknowledgment")
+	test.That(t, msg.Fields["Boot State"], test.ShouldEqual, "running Application")
+
+	msg, err = ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Description, test.ShouldEqual, "Lowrance: Temperature")
+	test.That(t, msg.Fields["Manufacturer Code"], test.ShouldEqual, "Lowrance")
+	test.That(t, msg.Fields["Actual Temperature"], test.ShouldAlmostEqual, 25, 1e-9)
+}
+
+func TestPGN127245Rudder(t *testing.T) {
+	// Rudder 0 ordered to starboard (1, the reserved bits above it set) at 1745 and at
+	// -873 in units of 1e-4 rad.
+	const line = "2022-11-14T01:47:30.890Z,2,127245,204,255,8,00,f9,d1,06,97,fc,ff,ff"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Rudder")
+	test.That(t, msg.Fields["Instance"], test.ShouldEqual, 0)
+	test.That(t, msg.Fields["Direction Order"], test.ShouldEqual, "Move to starboard")
+	test.That(t, msg.Fields["Angle Order"], test.ShouldAlmostEqual, 0.1745*radianToDegree, 1e-9)
+	test.That(t, msg.Fields["Position"], test.ShouldAlmostEqual, -0.0873*radianToDegree, 1e-9)
+
+	msg = decodeFast(t, line, true)
+	test.That(t, msg.Fields["Angle Order"], test.ShouldAlmostEqual, 0.1745, 1e-9)
+	test.That(t, msg.Fields["Position"], test.ShouldAlmostEqual, -0.0873, 1e-9)
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring,
+		"Instance = 0; Direction Order = Move to starboard; Angle Order = 10.0 deg; Position = -5.0 deg\n")
+}
+
+func TestPGN126983Alert(t *testing.T) {
+	// A technical alarm (0x12) from system 5, ID 1234, raised by the device with NAME
+	// 0xc0a0000000000001 and not acknowledged by anyone (all ones); priority 7, active.
+	const line = "2022-11-14T01:47:30.890Z,2,126983,35,255,28,12,05,00,d2,04,01,00,00,00,00,00,a0,c0,00,01,03,da," +
+		"ff,ff,ff,ff,ff,ff,ff,ff,11,07,02"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Alert")
+	test.That(t, msg.Fields["Alert Type"], test.ShouldEqual, "Alarm")
+	test.That(t, msg.Fields["Alert Category"], test.ShouldEqual, "Technical")
+	test.That(t, msg.Fields["Alert System"], test.ShouldEqual, 5)
+	test.That(t, msg.Fields["Alert ID"], test.ShouldEqual, 1234)
+	test.That(t, msg.Fields["Data Source Network ID NAME"], test.ShouldEqual, uint64(0xc0a0000000000001))
+	test.That(t, msg.Fields["Alert Occurrence Number"], test.ShouldEqual, 3)
+	test.That(t, msg.Fields["Temporary Silence Status"], test.ShouldEqual, "No")
+	test.That(t, msg.Fields["Acknowledge Status"], test.ShouldEqual, "Yes")
+	test.That(t, msg.Fields["Acknowledge Support"], test.ShouldEqual, "Yes")
+	test.That(t, msg.Fields["Escalation Support"], test.ShouldEqual, "No")
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "Acknowledge Source Network ID NAME")
+	test.That(t, msg.Fields["Trigger Condition"], test.ShouldEqual, "Auto")
+	test.That(t, msg.Fields["Threshold Status"], test.ShouldEqual, "Threshold Exceeded")
+	test.That(t, msg.Fields["Alert Priority"], test.ShouldEqual, 7)
+	test.That(t, msg.Fields["Alert State"], test.ShouldEqual, "Active")
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring,
+		"Data Source Network ID NAME = 13880094051555868673; ")
+	test.That(t, out, test.ShouldContainSubstring, "Acknowledge Source Network ID NAME = Unknown; ")
+}
+
+func TestPGN126985AlertText(t *testing.T) {
+	const line = "2022-11-14T01:47:30.890Z,6,126985,35,255,48,12,05,00,d2,04,01,00,00,00,00,00,a0,c0,00,01,03,00," +
+		"12,01,48,69,67,68,20,62,69,6c,67,65,20,77,61,74,65,72,0d,01,45,6e,67,69,6e,65,20,72,6f,6f,6d"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Alert Text")
+	test.That(t, msg.Fields["Alert Type"], test.ShouldEqual, "Alarm")
+	test.That(t, msg.Fields["Alert Category"], test.ShouldEqual, "Technical")
+	test.That(t, msg.Fields["Alert ID"], test.ShouldEqual, 1234)
+	test.That(t, msg.Fields["Language ID"], test.ShouldEqual, "English (US)")
+	test.That(t, msg.Fields["Alert Text Description"], test.ShouldEqual, "High bilge water")
+	test.That(t, msg.Fields["Alert Location Text Description"], test.ShouldEqual, "Engine room")
+}
+
+func TestPGN129540GNSSSatsInView(t *testing.T) {
+	// Three satellites, each PRN, elevation and azimuth (1e-4 rad), SNR (0.01 dB), range
+	// residuals and status: 5 at 30/90 deg used, 12 at 10/225 deg tracked, and 29 at 75/300
+	// deg without SNR and range residuals, not tracked.
+	const line = "2022-11-14T01:47:30.890Z,6,129540,3,255,39,07,fc,03," +
+		"05,74,14,5c,3d,94,11,00,00,00,00,f2," +
+		"0c,d1,06,66,99,0a,0f,88,ff,ff,ff,f1," +
+		"1d,22,33,88,cc,ff,ff,ff,ff,ff,7f,f0"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "GNSS Sats in View")
+	test.That(t, msg.Fields["SID"], test.ShouldEqual, 7)
+	test.That(t, msg.Fields["Sats in View"], test.ShouldEqual, 3)
+	list, ok := msg.Fields["list"].([]interface{})
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, list, test.ShouldHaveLength, 3)
+
+	for i, expected := range []struct {
+		prn                int
+		elevation, azimuth float64
+		snr                interface{}
+		status             string
+	}{
+		{5, 30, 90, 45.0, "Used"},
+		{12, 10, 225, 38.5, "Tracked"},
+		{29, 75, 300, nil, "Not tracked"},
+	} {
+		sat, ok := list[i].(map[string]interface{})
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, sat["PRN"], test.ShouldEqual, expected.prn)
+		test.That(t, sat["Elevation"], test.ShouldAlmostEqual, expected.elevation, 1e-2)
+		test.That(t, sat["Azimuth"], test.ShouldAlmostEqual, expected.azimuth, 1e-2)
+		test.That(t, sat["SNR"], test.ShouldEqual, expected.snr)
+		test.That(t, sat["Status"], test.ShouldEqual, expected.status)
+	}
+	test.That(t, list[1].(map[string]interface{})["Range residuals"], test.ShouldEqual, -120)
+	test.That(t, list[2], test.ShouldNotContainKey, "Range residuals")
+}
+
+func TestPGN130311EnvironmentalParameters(t *testing.T) {
+	// Outside temperature and humidity (0x41: 1 in the low 6 bits, 1 in the high 2),
+	// 293.15 K, 55.5 % in units of 0.004 % and 1013 hPa.
+	const line = "2022-11-14T01:47:30.890Z,5,130311,35,255,8,01,41,83,72,33,36,f5,03"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Environmental Parameters")
+	test.That(t, msg.Fields["Temperature Source"], test.ShouldEqual, "Outside Temperature")
+	test.That(t, msg.Fields["Humidity Source"], test.ShouldEqual, "Outside")
+	test.That(t, msg.Fields["Temperature"], test.ShouldAlmostEqual, 20.0, 1e-9)
+	test.That(t, msg.Fields["Humidity"], test.ShouldAlmostEqual, 55.5, 1e-9)
+	test.That(t, msg.Fields["Atmospheric Pressure"], test.ShouldAlmostEqual, 1.013, 1e-9)
+
+	// With -si the temperature is in K and the pressure in Pa.
+	msg = decodeFast(t, line, true)
+	test.That(t, msg.Fields["Temperature"], test.ShouldAlmostEqual, 293.15, 1e-9)
+	test.That(t, msg.Fields["Atmospheric Pressure"], test.ShouldAlmostEqual, 101300, 1e-9)
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring,
+		"Temperature = 20.00 C; Humidity = 55.500 %; Atmospheric Pressure = 1.013 bar\n")
+}
+
+func TestPGN127508BatteryStatus(t *testing.T) {
+	// Battery 1 at 12.65 V, discharging at 12.3 A (-123 = 0xff85 in units of 0.1 A) and
+	// 298.15 K.
+	const line = "2022-11-14T01:47:30.890Z,6,127508,17,255,8,01,f1,04,85,ff,77,74,2a"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Battery Status")
+	test.That(t, msg.Fields["Instance"], test.ShouldEqual, 1)
+	test.That(t, msg.Fields["Voltage"], test.ShouldAlmostEqual, 12.65, 1e-9)
+	test.That(t, msg.Fields["Current"], test.ShouldAlmostEqual, -12.3, 1e-9)
+	test.That(t, msg.Fields["Temperature"], test.ShouldAlmostEqual, 25.0, 1e-9)
+	test.That(t, msg.Fields["SID"], test.ShouldEqual, 42)
+
+	// The sign bit is extended: 0x8000 is the most negative current, while 0x7fff and
+	// 0x7ffe are the Unknown and ERROR values of a signed field.
+	ana := newTestAnalyzer(t, "", RawFormatFast)
+	pgn, _ := ana.searchForPgn(127508)
+	field := &pgn.fieldList[2]
+	test.That(t, field.name, test.ShouldEqual, "Current")
+	var value, maxValue int64
+	for _, tc := range []struct {
+		data  []byte
+		value int64
+	}{
+		{[]byte{0x85, 0xff}, -123},
+		{[]byte{0x00, 0x80}, -32768},
+		{[]byte{0xfd, 0x7f}, 32765},
+		{[]byte{0x7b, 0x00}, 123},
+	} {
+		test.That(t, extractNumber(field, tc.data, 0, 16, &value, &maxValue, ana.Logger), test.ShouldBeTrue)
+		test.That(t, value, test.ShouldEqual, tc.value)
+		test.That(t, maxValue, test.ShouldEqual, 0x7fff)
+	}
+
+	msg = decodeFast(t, "2022-11-14T01:47:30.890Z,6,127508,17,255,8,01,f1,04,ff,7f,77,74,2a", false)
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "Current")
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring,
+		"Instance = 1; Voltage = 12.65 V; Current = -12.3 A; Temperature = 25.00 C; SID = 42\n")
+}
+
+func TestPGN128006ThrusterControlStatus(t *testing.T) {
+	// Thruster 1 to port (2), power on (1) and extending (1) packed as 0x52, at 75 %, with
+	// the first two control events set, a 0.5 s timeout (100 * 5 ms) and azimuth 90 deg.
+	const line = "2022-11-14T01:47:30.890Z,2,128006,12,255,8,05,01,52,4b,03,64,5c,3d"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Thruster Control Status")
+	test.That(t, msg.Fields["Identifier"], test.ShouldEqual, 1)
+	test.That(t, msg.Fields["Direction Control"], test.ShouldEqual, "To Port")
+	test.That(t, msg.Fields["Power Enabled"], test.ShouldEqual, "On")
+	test.That(t, msg.Fields["Retract Control"], test.ShouldEqual, "Extend")
+	test.That(t, msg.Fields["Speed Control"], test.ShouldEqual, 75)
+	test.That(t, msg.Fields["Control Events"], test.ShouldResemble, []interface{}{
+		"Another device controlling thruster",
+		"Boat speed too fast to safely use thruster",
+	})
+	test.That(t, msg.Fields["Command Timeout"], test.ShouldEqual, 500*time.Millisecond)
+	test.That(t, msg.Fields["Azimuth Control"], test.ShouldAlmostEqual, 90, 1e-3)
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring, "Direction Control = To Port; Power Enabled = On; "+
+		"Retract Control = Extend; Speed Control = 75 %; Control Events = Another device controlling thruster,"+
+		"Boat speed too fast to safely use thruster; Command Timeout = 00:00:00.500; Azimuth Control = 90.0 deg\n")
+}
+
+func TestPGN126720ProprietaryDispatch(t *testing.T) {
+	// Fusion (419) in the marine industry (4) is 0x99a3, and proprietary ID 24 picks Set Zone Volume.
+	msg := decodeFast(t, "2022-11-14T01:47:30.890Z,7,126720,10,255,6,a3,99,18,80,01,0c", false)
+	test.That(t, msg.Description, test.ShouldEqual, "Fusion: Set Zone Volume")
+	test.That(t, msg.Fields["Manufacturer Code"], test.ShouldEqual, "Fusion Electronics")
+	test.That(t, msg.Fields["Proprietary ID"], test.ShouldEqual, "Set Zone Volume")
+	test.That(t, msg.Fields["Zone"], test.ShouldEqual, 1)
+	test.That(t, msg.Fields["Volume"], test.ShouldEqual, 12)
+
+	// A proprietary ID that Fusion has no definition for falls back to the generic PGN
+	msg = decodeFast(t, "2022-11-14T01:47:30.890Z,7,126720,10,255,6,a3,99,63,80,01,0c", false)
+	test.That(t, msg.Description, test.ShouldEqual, "0x1EF00-0x1EFFF: Manufacturer Proprietary fast-packet addressed")
+	test.That(t, msg.Fields["Manufacturer Code"], test.ShouldEqual, "Fusion Electronics")
+	test.That(t, msg.Fields["Data"], test.ShouldResemble, []byte{0x63, 0x80, 0x01, 0x0c})
+
+	// As does the same proprietary ID from another manufacturer (420)
+	msg = decodeFast(t, "2022-11-14T01:47:30.890Z,7,126720,10,255,6,a4,99,18,80,01,0c", false)
+	test.That(t, msg.Description, test.ShouldEqual, "0x1EF00-0x1EFFF: Manufacturer Proprietary fast-packet addressed")
+	test.That(t, msg.Fields["Manufacturer Code"], test.ShouldEqual, 420)
+	test.That(t, msg.Fields["Data"], test.ShouldResemble, []byte{0x18, 0x80, 0x01, 0x0c})
+}
+
+func TestPGN127488EngineParametersRapidUpdate(t *testing.T) {
+	// 6001 * 0.25 rpm, 50 hPa of boost and the drive trimmed 5 down
+	const line = "2022-11-14T01:47:30.890Z,2,127488,0,255,8,00,71,17,32,00,fb,ff,ff"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Engine Parameters, Rapid Update")
+	test.That(t, msg.Fields["Instance"], test.ShouldEqual, "Single Engine or Dual Engine Port")
+	test.That(t, msg.Fields["Speed"], test.ShouldEqual, 1500.25)
+	test.That(t, msg.Fields["Boost Pressure"], test.ShouldAlmostEqual, 0.05)
+	test.That(t, msg.Fields["Tilt/Trim"], test.ShouldEqual, -5)
+
+	msg = decodeFast(t, line, true)
+	test.That(t, msg.Fields["Boost Pressure"], test.ShouldEqual, 5000)
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring,
+		"Speed = 1500.2 rpm; Boost Pressure = 0.050 bar; Tilt/Trim = -5\n")
+}
+
+func TestPGN128275DistanceLog(t *testing.T) {
+	// Day 19000, 452967891 * 0.0001 s after midnight, a log of 100 nm and a trip of 1 nm
+	const line = "2022-11-14T01:47:30.890Z,6,128275,35,255,14,38,4a,d3,bd,ff,1a,70,d3,02,00,3c,07,00,00"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Distance Log")
+	test.That(t, msg.Fields["Date"], test.ShouldEqual, time.Date(2022, time.January, 8, 0, 0, 0, 0, time.UTC))
+	test.That(t, msg.Fields["Time"], test.ShouldEqual, 12*time.Hour+34*time.Minute+56*time.Second+789100*time.Microsecond)
+	test.That(t, msg.Fields["Log"], test.ShouldEqual, 185200)
+	test.That(t, msg.Fields["Trip Log"], test.ShouldEqual, 1852)
+
+	for _, showJSON := range []bool{false, true} {
+		out := runText(t, line, func(conf *Config) { conf.ShowJSON = showJSON })
+		if showJSON {
+			test.That(t, out, test.ShouldContainSubstring,
+				`"fields":{"Date":"2022.01.08","Time":"12:34:56.7891","Log":185200,"Trip Log":1852}`)
+		} else {
+			test.That(t, out, test.ShouldContainSubstring,
+				"Date = 2022.01.08; Time = 12:34:56.7891; Log = 185200 m; Trip Log = 1852 m\n")
+		}
+	}
+}
+
+func TestPGN130314ActualPressure(t *testing.T) {
+	for _, tc := range []struct {
+		line        string
+		description string
+		source      string
+		pa          float64
+		text        string
+	}{
+		{
+			// 1013250 dPa of atmospheric pressure
+			"2022-11-14T01:47:30.890Z,5,130314,35,255,8,01,00,00,02,76,0f,00,ff",
+			"Actual Pressure", "Atmospheric", 101325, "Source = Atmospheric; Pressure = 1.013 bar\n",
+		},
+		{
+			// -50000 dPa, Actual Pressure is signed
+			"2022-11-14T01:47:30.890Z,5,130314,35,255,8,01,02,05,b0,3c,ff,ff,ff",
+			"Actual Pressure", "Filter", -5000, "Source = Filter; Pressure = -0.050 bar\n",
+		},
+		{
+			"2022-11-14T01:47:30.890Z,5,130315,35,255,8,01,00,00,02,76,0f,00,ff",
+			"Set Pressure", "Atmospheric", 101325, "Source = Atmospheric; Pressure = 1.013 bar\n",
+		},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			msg := decodeFast(t, tc.line, true)
+			test.That(t, msg.Description, test.ShouldEqual, tc.description)
+			test.That(t, msg.Fields["Source"], test.ShouldEqual, tc.source)
+			test.That(t, msg.Fields["Pressure"], test.ShouldEqual, tc.pa)
+
+			msg = decodeFast(t, tc.line, false)
+			test.That(t, msg.Fields["Pressure"], test.ShouldAlmostEqual, tc.pa/100000)
+
+			out := runText(t, tc.line)
+			test.That(t, out, test.ShouldContainSubstring, tc.text)
+		})
+	}
+}
+
+func TestPGN128000LeewayAngle(t *testing.T) {
+	// -436 * 0.0001 rad of leeway
+	const line = "2022-11-14T01:47:30.890Z,3,128000,35,255,8,07,4c,fe,ff,ff,ff,ff,ff"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Leeway Angle")
+	test.That(t, msg.Fields["SID"], test.ShouldEqual, 7)
+	test.That(t, msg.Fields["Leeway Angle"], test.ShouldAlmostEqual, -0.0436*180/math.Pi, 1e-9)
+
+	msg = decodeFast(t, line, true)
+	test.That(t, msg.Fields["Leeway Angle"], test.ShouldAlmostEqual, -0.0436)
+
+	// A single frame PGN in the NMEA range that allows both single frame and fast packet
+	ana := newTestAnalyzer(t, "", RawFormatFast)
+	test.That(t, ana.checkPGNs(), test.ShouldBeNil)
+	pgn, _ := ana.searchForPgn(128000)
+	test.That(t, pgn, test.ShouldNotBeNil)
+	test.That(t, pgn.packetType, test.ShouldEqual, packetTypeSingle)
+	var inRange *pgnRange
+	for i := range pgnRanges {
+		if pgnRanges[i].pgnStart <= 128000 && 128000 <= pgnRanges[i].pgnEnd {
+			inRange = &pgnRanges[i]
+		}
+	}
+	test.That(t, inRange, test.ShouldNotBeNil)
+	test.That(t, inRange.packetType, test.ShouldEqual, packetTypeMixed)
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring, "SID = 7; Leeway Angle = -2.5 deg\n")
+}
+
+func TestPGN129038AISPositionReports(t *testing.T) {
+	// Class A and B reports carry the 19 bit SOTDMA/ITDMA communication state before the
+	// transceiver information; the fields after it only line up if it has that width.
+	for _, tc := range []struct {
+		line      string
+		commState []byte
+		fields    map[string]interface{}
+	}{
+		{
+			"2022-11-14T01:47:30.890Z,4,129038,43,255,28,01,40,07,8d,0e,87,30,75,02,87,68,11,1f,79,5c,3d," +
+				"02,02,a5,a5,05,5c,3d,00,00,c0,f8,00",
+			[]byte{0xa5, 0xa5, 0x05},
+			map[string]interface{}{
+				"Message ID":                  "Scheduled Class A position report",
+				"User ID":                     244123456,
+				"Latitude":                    52.1234567,
+				"Longitude":                   4.1234567,
+				"Time Stamp":                  30,
+				"AIS Transceiver information": "Channel A VDL reception",
+				"Nav Status":                  "Under way using engine",
+				"Sequence ID":                 0,
+			},
+		},
+		{
+			"2022-11-14T01:47:30.890Z,4,129039,43,255,27,12,c0,5a,27,14,79,70,35,b7,31,8c,82,16,b6,b8,7a," +
+				"01,01,06,00,0e,b8,7a,00,74,ff,ff",
+			[]byte{0x06, 0x00, 0x06},
+			map[string]interface{}{
+				"Message ID":                  "Standard Class B position report",
+				"User ID":                     338123456,
+				"Latitude":                    37.7654321,
+				"Longitude":                   -122.1234567,
+				"Time Stamp":                  45,
+				"AIS Transceiver information": "Channel B VDL reception",
+				"Unit type":                   "CS",
+				"AIS communication state":     "ITDMA",
+			},
+		},
+		{
+			// Message 19 has no communication state
+			"2022-11-14T01:47:30.890Z,4,129040,43,255,54,13,c0,5a,27,14,79,70,35,b7,31,8c,82,16,b6,b8,7a," +
+				"01,01,00,f0,24,b8,7a,1f,78,00,28,00,14,00,32,00,54,45,53,54,20,56,45,53,53,45,4c," +
+				"40,40,40,40,40,40,40,40,40,40,f8",
+			nil,
+			map[string]interface{}{
+				"Message ID":                  "Extended Class B position report",
+				"User ID":                     338123456,
+				"Latitude":                    37.7654321,
+				"Longitude":                   -122.1234567,
+				"Type of ship":                "Sailing",
+				"GNSS type":                   "GPS",
+				"Length":                      12,
+				"Name":                        "TEST VESSEL",
+				"AIS Transceiver information": "Channel B VDL reception",
+			},
+		},
+	} {
+		msg := decodeFast(t, tc.line, false)
+		for name, value := range tc.fields {
+			if f, ok := value.(float64); ok {
+				test.That(t, msg.Fields[name], test.ShouldAlmostEqual, f)
+			} else {
+				test.That(t, msg.Fields[name], test.ShouldEqual, value)
+			}
+		}
+		if tc.commState == nil {
+			test.That(t, msg.Fields, test.ShouldNotContainKey, "Communication State")
+			continue
+		}
+		test.That(t, msg.Fields["Communication State"], test.ShouldResemble, tc.commState)
+
+		ana := newTestAnalyzer(t, "", RawFormatFast)
+		pgn, _ := ana.searchForPgn(uint32(msg.Pgn))
+		for i := range pgn.fieldList[:pgn.fieldCount] {
+			if pgn.fieldList[i].name == "Communication State" {
+				test.That(t, pgn.fieldList[i].size, test.ShouldEqual, 19)
+			}
+		}
+	}
+}
+
+func TestPGN65305ProprietaryDispatch(t *testing.T) {
+	const fallback = "0xFF00-0xFFFF: Manufacturer Proprietary single-frame non-addressed"
+
+	// Simrad (1857) in the marine industry (4) is 0x9f41, and report 2 picks Device Status.
+	msg := decodeFast(t, "2022-11-14T01:47:30.890Z,3,65305,2,255,8,41,9f,64,02,10,ff,ff,ff", false)
+	test.That(t, msg.Description, test.ShouldEqual, "Simnet: Device Status")
+	test.That(t, msg.Fields["Manufacturer Code"], test.ShouldEqual, "Simrad")
+	test.That(t, msg.Fields["Model"], test.ShouldEqual, "NAC")
+	test.That(t, msg.Fields["Report"], test.ShouldEqual, "Status")
+	test.That(t, msg.Fields["Status"], test.ShouldEqual, "Automatic")
+
+	for _, tc := range []struct {
+		line         string
+		manufacturer string
+		industry     string
+		data         []byte
+	}{
+		// A report Simrad has no definition for
+		{"2022-11-14T01:47:30.890Z,3,65305,2,255,8,41,9f,64,07,10,ff,ff,ff", "Simrad", "Marine", []byte{0x64, 0x07, 0x10, 0xff, 0xff, 0xff}},
+		// Another manufacturer (1858)
+		{"2022-11-14T01:47:30.890Z,3,65305,2,255,8,42,9f,64,02,10,ff,ff,ff", "Litton", "Marine", []byte{0x64, 0x02, 0x10, 0xff, 0xff, 0xff}},
+		// Simrad outside the marine industry (0)
+		{"2022-11-14T01:47:30.890Z,3,65305,2,255,8,41,1f,64,02,10,ff,ff,ff", "Simrad", "Global", []byte{0x64, 0x02, 0x10, 0xff, 0xff, 0xff}},
+	} {
+		msg := decodeFast(t, tc.line, false)
+		test.That(t, msg.Description, test.ShouldEqual, fallback)
+		test.That(t, msg.Fields["Manufacturer Code"], test.ShouldEqual, tc.manufacturer)
+		test.That(t, msg.Fields["Industry Code"], test.ShouldEqual, tc.industry)
+		test.That(t, msg.Fields["Data"], test.ShouldResemble, tc.data)
+	}
+}
+
+func TestPGN127493TransmissionParameters(t *testing.T) {
+	// In forward at 3000 hPa and 3532 * 0.1 K of oil, with discrete status bits 0 and 2 set
+	const line = "2022-11-14T01:47:30.890Z,2,127493,0,255,8,00,fc,b8,0b,cc,0d,05,ff"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Transmission Parameters, Dynamic")
+	test.That(t, msg.Fields["Transmission Gear"], test.ShouldEqual, "Forward")
+	test.That(t, msg.Fields["Oil pressure"], test.ShouldAlmostEqual, 3)
+	test.That(t, msg.Fields["Oil temperature"], test.ShouldAlmostEqual, 80.05)
+	test.That(t, msg.Fields["Discrete Status 1"], test.ShouldEqual, 5)
+
+	msg = decodeFast(t, line, true)
+	test.That(t, msg.Fields["Oil pressure"], test.ShouldEqual, 300000)
+	test.That(t, msg.Fields["Oil temperature"], test.ShouldAlmostEqual, 353.2)
+
+	// In reverse without an oil temperature
+	msg = decodeFast(t, "2022-11-14T01:47:30.890Z,2,127493,0,255,8,01,fe,b8,0b,ff,ff,00,ff", false)
+	test.That(t, msg.Fields["Instance"], test.ShouldEqual, "Dual Engine Starboard")
+	test.That(t, msg.Fields["Transmission Gear"], test.ShouldEqual, "Reverse")
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "Oil temperature")
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring,
+		"Transmission Gear = Forward; Oil pressure = 3.000 bar; Oil temperature = 80.05 C; Discrete Status 1 = 5\n")
+}
+
+func TestPGN126992SystemTime(t *testing.T) {
+	// GPS time: day 19160 (2022-06-17) and 235937408 * 0.0001 s after midnight
+	const line = "2022-06-17T06:33:14.000Z,3,126992,2,255,8,00,f0,d8,4a,80,1e,10,0e"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "System Time")
+	test.That(t, msg.Fields["Source"], test.ShouldEqual, "GPS")
+	test.That(t, msg.Fields["Date"], test.ShouldEqual, time.Date(2022, time.June, 17, 0, 0, 0, 0, time.UTC))
+	test.That(t, msg.Fields["Time"], test.ShouldEqual, 6*time.Hour+33*time.Minute+13*time.Second+740800*time.Microsecond)
+
+	dateTime, ok := msg.DateTime("Date", "Time")
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, dateTime, test.ShouldEqual, time.Date(2022, time.June, 17, 6, 33, 13, 740800000, time.UTC))
+	_, ok = msg.DateTime("Date", "Missing")
+	test.That(t, ok, test.ShouldBeFalse)
+
+	for _, showJSON := range []bool{false, true} {
+		out := runText(t, line, func(conf *Config) { conf.ShowJSON = showJSON })
+		if showJSON {
+			test.That(t, out, test.ShouldContainSubstring,
+				`"fields":{"SID":0,"Source":"GPS","Date":"2022.06.17","Time":"06:33:13.7408"}`)
+		} else {
+			test.That(t, out, test.ShouldContainSubstring,
+				"SID = 0; Source = GPS; Date = 2022.06.17; Time = 06:33:13.7408\n")
+		}
+	}
+}
+
+func TestPGN127505FluidLevel(t *testing.T) {
+	for _, tc := range []struct {
+		line     string
+		instance int
+		fluid    string
+		level    float64
+		text     string
+	}{
+		// Instance 1, water, 15625 * 0.004 % full of 2000 dl
+		{"2022-11-14T01:47:30.890Z,6,127505,17,255,8,11,09,3d,d0,07,00,00,ff", 1, "Water", 62.5,
+			"Instance = 1; Type = Water; Level = 62.500 %; Capacity = 200.0 L\n"},
+		// Instance 5, gray water, a sensor reading below empty: -1000 * 0.004 %
+		{"2022-11-14T01:47:30.890Z,6,127505,17,255,8,25,18,fc,d0,07,00,00,ff", 5, "Gray water", -4,
+			"Instance = 5; Type = Gray water; Level = -4.000 %; Capacity = 200.0 L\n"},
+	} {
+		msg := decodeFast(t, tc.line, false)
+		test.That(t, msg.Description, test.ShouldEqual, "Fluid Level")
+		test.That(t, msg.Fields["Instance"], test.ShouldEqual, tc.instance)
+		test.That(t, msg.Fields["Type"], test.ShouldEqual, tc.fluid)
+		test.That(t, msg.Fields["Level"], test.ShouldEqual, tc.level)
+		test.That(t, msg.Fields["Capacity"], test.ShouldEqual, 200)
+
+		out := runText(t, tc.line)
+		test.That(t, out, test.ShouldContainSubstring, tc.text)
+	}
+}
+
+func TestPGN128259Speed(t *testing.T) {
+	// 514 cm/s through the water from a paddle wheel and 540 cm/s over ground
+	const line = "2022-11-14T01:47:30.890Z,2,128259,35,255,8,00,02,02,1c,02,00,f0,ff"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Speed")
+	test.That(t, msg.Fields["Speed Water Referenced"], test.ShouldEqual, 5.14)
+	test.That(t, msg.Fields["Speed Ground Referenced"], test.ShouldEqual, 5.4)
+	test.That(t, msg.Fields["Speed Water Referenced Type"], test.ShouldEqual, "Paddle wheel")
+
+	knots := func(quantity, unit string, value float64) (string, float64, bool) {
+		if quantity == "SPEED" && unit == "m/s" {
+			return "kn", value * 3600 / 1852, true
+		}
+		return unit, value, false
+	}
+	for _, showJSON := range []bool{false, true} {
+		out := runText(t, line, func(conf *Config) {
+			conf.ShowJSON = showJSON
+			conf.UnitConverter = knots
+		})
+		if showJSON {
+			test.That(t, out, test.ShouldContainSubstring,
+				`"Speed Water Referenced":9.99,"Speed Ground Referenced":10.50,"Speed Water Referenced Type":"Paddle wheel"`)
+		} else {
+			test.That(t, out, test.ShouldContainSubstring,
+				"Speed Water Referenced = 9.99 kn; Speed Ground Referenced = 10.50 kn; Speed Water Referenced Type = Paddle wheel")
+		}
+	}
+}
+
+func TestPGN130312Temperature(t *testing.T) {
+	// Inside temperature 29335 * 0.01 K, set to 30000 * 0.01 K
+	const line = "2022-11-14T01:47:30.990Z,6,130312,35,255,8,01,00,02,97,72,30,75,ff"
+
+	msg := decodeFast(t, line, true)
+	test.That(t, msg.Description, test.ShouldEqual, "Temperature")
+	test.That(t, msg.Fields["Instance"], test.ShouldEqual, 0)
+	test.That(t, msg.Fields["Source"], test.ShouldEqual, "Inside Temperature")
+	test.That(t, msg.Fields["Actual Temperature"], test.ShouldEqual, 293.35)
+	test.That(t, msg.Fields["Set Temperature"], test.ShouldEqual, 300)
+
+	msg = decodeFast(t, line, false)
+	test.That(t, msg.Fields["Actual Temperature"], test.ShouldAlmostEqual, 20.2, 1e-9)
+	test.That(t, msg.Fields["Set Temperature"], test.ShouldAlmostEqual, 26.85, 1e-9)
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring,
+		"SID = 1; Instance = 0; Source = Inside Temperature; Actual Temperature = 20.20 C; Set Temperature = 26.85 C\n")
+}
+
+func TestPGN130313Humidity(t *testing.T) {
+	// Inside humidity 13750 * 0.004 %, set to 12500 * 0.004 %
+	const line = "2022-11-14T01:47:30.990Z,6,130313,35,255,8,01,00,00,b6,35,d4,30,ff"
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Humidity")
+	test.That(t, msg.Fields["Instance"], test.ShouldEqual, 0)
+	test.That(t, msg.Fields["Source"], test.ShouldEqual, "Inside")
+	test.That(t, msg.Fields["Actual Humidity"], test.ShouldEqual, 55)
+	test.That(t, msg.Fields["Set Humidity"], test.ShouldEqual, 50)
+
+	out := runText(t, line, func(conf *Config) { conf.ShowJSON = true })
+	test.That(t, out, test.ShouldContainSubstring,
+		`"fields":{"SID":1,"Instance":0,"Source":"Inside","Actual Humidity":55.000,"Set Humidity":50.000}`)
+}
+
+func TestPGN127258MagneticVariation(t *testing.T) {
+	for _, tc := range []struct {
+		line    string
+		degrees float64
+		text    string
+	}{
+		// 2618 * 0.0001 rad west
+		{"2022-11-14T01:47:30.990Z,7,127258,35,255,8,00,f8,38,4a,c6,f5,ff,ff", -15,
+			"SID = 0; Source = WMM 2020; Age of service = 2022.01.08; Variation = -15.0 deg\n"},
+		// 873 * 0.0001 rad east
+		{"2022-11-14T01:47:30.990Z,7,127258,35,255,8,00,f8,38,4a,69,03,ff,ff", 5,
+			"SID = 0; Source = WMM 2020; Age of service = 2022.01.08; Variation = 5.0 deg\n"},
+	} {
+		msg := decodeFast(t, tc.line, false)
+		test.That(t, msg.Description, test.ShouldEqual, "Magnetic Variation")
+		test.That(t, msg.Fields["Source"], test.ShouldEqual, "WMM 2020")
+		test.That(t, msg.Fields["Age of service"], test.ShouldEqual, time.Date(2022, time.January, 8, 0, 0, 0, 0, time.UTC))
+		test.That(t, msg.Fields["Variation"], test.ShouldAlmostEqual, tc.degrees, 0.01)
+
+		out := runText(t, tc.line)
+		test.That(t, out, test.ShouldContainSubstring, tc.text)
+	}
+}
+
+func TestPGN127501BinarySwitchBank(t *testing.T) {
+	// Bank 2 with 1-4 On, Off, Error and Unknown, 5-8 Off, On, On, Off and 25-28 On, On,
+	// Off and Unknown; the rest Unknown
+	const payload = "8,02,e1,14,ff,ff,ff,ff,c5"
+	want := map[string]interface{}{
+		"1": "On", "2": "Off", "5": "Off", "6": "On", "7": "On", "8": "Off", "25": "On", "26": "On", "27": "Off",
+	}
+
+	for _, tc := range []struct {
+		pgn         int
+		description string
+		prefix      string
+	}{
+		{127501, "Binary Switch Bank Status", "Indicator"},
+		{127502, "Switch Bank Control", "Switch"},
+	} {
+		line := fmt.Sprintf("2022-11-14T01:47:30.990Z,3,%d,35,255,%s", tc.pgn, payload)
+		msg := decodeFast(t, line, false)
+		test.That(t, msg.Description, test.ShouldEqual, tc.description)
+		expected := map[string]interface{}{"Instance": 2}
+		for n, state := range want {
+			expected[tc.prefix+n] = state
+		}
+		test.That(t, msg.Fields, test.ShouldResemble, expected)
+
+		out := runText(t, line)
+		test.That(t, out, test.ShouldContainSubstring, fmt.Sprintf(
+			"Instance = 2; %[1]s1 = On; %[1]s2 = Off; %[1]s3 = ERROR; %[1]s4 = Unknown; %[1]s5 = Off; %[1]s6 = On; "+
+				"%[1]s7 = On; %[1]s8 = Off; %[1]s9 = Unknown;", tc.prefix))
+		test.That(t, out, test.ShouldEndWith, fmt.Sprintf(
+			"%[1]s25 = On; %[1]s26 = On; %[1]s27 = Off; %[1]s28 = Unknown\n", tc.prefix))
+	}
+}
+
+func TestPGN129283CrossTrackError(t *testing.T) {
+	for _, tc := range []struct {
+		line       string
+		terminated string
+		xte        float64
+		text       string
+	}{
+		// 1234 cm to port
+		{"2022-11-14T01:47:30.990Z,3,129283,35,255,8,05,31,2e,fb,ff,ff,ff,ff", "No", -12.34,
+			"SID = 5; XTE mode = Differential enhanced; Navigation Terminated = No; XTE = -12.34 m\n"},
+		// 25000 cm to starboard, with navigation terminated
+		{"2022-11-14T01:47:30.990Z,3,129283,35,255,8,05,71,a8,61,00,00,ff,ff", "Yes", 250,
+			"SID = 5; XTE mode = Differential enhanced; Navigation Terminated = Yes; XTE = 250.00 m\n"},
+	} {
+		msg := decodeFast(t, tc.line, false)
+		test.That(t, msg.Description, test.ShouldEqual, "Cross Track Error")
+		test.That(t, msg.Fields["XTE mode"], test.ShouldEqual, "Differential enhanced")
+		test.That(t, msg.Fields["Navigation Terminated"], test.ShouldEqual, tc.terminated)
+		test.That(t, msg.Fields["XTE"], test.ShouldEqual, tc.xte)
+
+		out := runText(t, tc.line)
+		test.That(t, out, test.ShouldContainSubstring, tc.text)
+	}
+}
+
+// The name and position of waypoints 1 "Home" at 52.3702, 4.8952 and 2 "Buoy 7" at
+// -33.8568, 151.2153, which follow the WP ID in the lists of PGNs 130074 and 129285.
+const (
+	wpHome        = "06,01,48,6f,6d,65,f0,0e,37,1f,c0,f2,ea,02"
+	wpBuoy7       = "08,01,42,75,6f,79,20,37,c0,dc,d1,eb,a8,9f,21,5a"
+	waypointsText = "WP ID 1 = 1; WP Name 1 = Home; WP Latitude 1 = 52.3702000; WP Longitude 1 =  4.8952000; " +
+		"WP ID 2 = 2; WP Name 2 = Buoy 7; WP Latitude 2 = -33.8568000; WP Longitude 2 = 151.2153000\n"
+)
+
+// testWaypoints checks that the list of the message holds waypoints 1 "Home" and 2 "Buoy 7".
+func testWaypoints(t *testing.T, msg *common.Message) {
+	t.Helper()
+	list, ok := msg.Fields["list"].([]interface{})
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, list, test.ShouldHaveLength, 2)
+	for i, want := range []struct {
+		id       int
+		name     string
+		lat, lon float64
+	}{
+		{1, "Home", 52.3702, 4.8952},
+		{2, "Buoy 7", -33.8568, 151.2153},
+	} {
+		wp := list[i].(map[string]interface{})
+		test.That(t, wp["WP ID"], test.ShouldEqual, want.id)
+		test.That(t, wp["WP Name"], test.ShouldEqual, want.name)
+		test.That(t, wp["WP Latitude"], test.ShouldAlmostEqual, want.lat, 1e-9)
+		test.That(t, wp["WP Longitude"], test.ShouldAlmostEqual, want.lon, 1e-9)
+	}
+}
+
+func TestPGN130074WaypointList(t *testing.T) {
+	const line = "2022-11-14T01:47:30.990Z,7,130074,35,255,38,00,02,02,00,01,ff," +
+		"01," + wpHome + ",02," + wpBuoy7
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Route and WP Service - WP List - WP Name & Position")
+	test.That(t, msg.Fields["nItems"], test.ShouldEqual, 2)
+	test.That(t, msg.Fields["Database ID"], test.ShouldEqual, 1)
+	testWaypoints(t, msg)
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring, waypointsText)
+}
+
+func TestPGN65379SeatalkPilotMode(t *testing.T) {
+	// Raymarine (1851) in the Marine industry is 3b,9f; Airmar (135) is 87,98
+	for _, tc := range []struct {
+		mode string
+		want string
+	}{
+		{"00,00", "Standby"},
+		{"40,00", "Auto, compass commanded"},
+		{"00,01", "Vane, Wind Mode"},
+		{"80,01", "Track Mode"},
+		{"81,01", "No Drift, COG referenced (In track, course changes)"},
+	} {
+		msg := decodeFast(t, "2022-11-14T01:47:30.890Z,2,65379,204,255,8,3b,9f,"+tc.mode+",00,00,ff,ff", false)
+		test.That(t, msg.Description, test.ShouldEqual, "Seatalk: Pilot Mode")
+		test.That(t, msg.Fields["Manufacturer Code"], test.ShouldEqual, "Raymarine")
+		test.That(t, msg.Fields["Pilot Mode"], test.ShouldEqual, tc.want)
+	}
+
+	msg := decodeFast(t, "2022-11-14T01:47:30.890Z,2,65360,204,255,8,3b,9f,00,10,27,20,4e,ff", false)
+	test.That(t, msg.Description, test.ShouldEqual, "Seatalk: Pilot Locked Heading")
+	test.That(t, msg.Fields["Target Heading True"], test.ShouldAlmostEqual, 57.2958, 1e-4)
+	test.That(t, msg.Fields["Target Heading Magnetic"], test.ShouldAlmostEqual, 114.5916, 1e-4)
+
+	// The same PGN from another manufacturer is not a pilot mode
+	msg = decodeFast(t, "2022-11-14T01:47:30.890Z,2,65379,204,255,8,87,98,40,00,00,00,ff,ff", false)
+	test.That(t, msg.Description, test.ShouldEqual, "0xFF00-0xFFFF: Manufacturer Proprietary single-frame non-addressed")
+	test.That(t, msg.Fields["Manufacturer Code"], test.ShouldEqual, "Airmar")
+	test.That(t, msg.Fields, test.ShouldNotContainKey, "Pilot Mode")
+}
+
+func TestPGN129285RouteWPInformation(t *testing.T) {
+	// Route 3 "Harbour", sailed in reverse, through waypoints 1 and 2
+	const line = "2022-11-14T01:47:30.990Z,7,129285,35,255,53,00,00,02,00,01,00,03,00,e1," +
+		"09,01,48,61,72,62,6f,75,72,ff," +
+		"01,00," + wpHome + ",02,00," + wpBuoy7
+
+	msg := decodeFast(t, line, false)
+	test.That(t, msg.Description, test.ShouldEqual, "Navigation - Route/WP Information")
+	test.That(t, msg.Fields["nItems"], test.ShouldEqual, 2)
+	test.That(t, msg.Fields["Database ID"], test.ShouldEqual, 1)
+	test.That(t, msg.Fields["Route ID"], test.ShouldEqual, 3)
+	test.That(t, msg.Fields["Navigation direction in route"], test.ShouldEqual, "Reverse")
+	test.That(t, msg.Fields["Supplementary Route/WP data available"], test.ShouldEqual, "Off")
+	test.That(t, msg.Fields["Route Name"], test.ShouldEqual, "Harbour")
+	testWaypoints(t, msg)
+
+	out := runText(t, line)
+	test.That(t, out, test.ShouldContainSubstring,
+		"Navigation direction in route = Reverse; Supplementary Route/WP data available = Off; Route Name = Harbour; "+
+			waypointsText)
+}
@@ -66,6 +66,11 @@ func (pb *printBuffer) Chr(location int) rune {
 	return rune(pb.buf[location])
 }
 
+// Since returns what has been printed from location on.
+func (pb *printBuffer) Since(location int) string {
+	return string(pb.buf[location:pb.p])
+}
+
 func (pb *printBuffer) Insert(location int, str string) {
 	strLen := len(str)
 	if pb.p+strLen <= bufMaxSize {
@@ -213,6 +218,7 @@ func extractNumber(
 
 	logger.Debug("extractNumber <%s> startBit=%d bits=%d\n", name, startBit, bits)
 
+	fieldStart, dataLen := startBit, len(data)
 	data, adjusted := adjustDataLenStart(data, &startBit)
 	if !adjusted {
 		return false
@@ -244,7 +250,8 @@ func extractNumber(
 		}
 	}
 	if bitsRemaining > 0 {
-		logger.Debug("Insufficient length in PGN to fill field '%s'\n", name)
+		logger.Debug("Insufficient length in PGN to fill field '%s' at bit %d: needs %d bytes, %d present\n",
+			name, fieldStart, (fieldStart+bits+7)/8, dataLen)
 		return false
 	}
 
@@ -292,8 +299,12 @@ func (ana *Analyzer) extractNumberNotEmpty(
 		return false
 	}
 
+	// The maximum of a 64 bit unsigned field does not fit an int64 and wraps to -1, so
+	// such fields are compared as uint64.
+	unsigned64 := bits >= 64 && !field.hasSign
+
 	//nolint:gocritic
-	if *maxValue >= 7 {
+	if *maxValue >= 7 || unsigned64 {
 		reserved = 2 /* dataFieldError and dataFieldUnknown */
 	} else if *maxValue > 1 {
 		reserved = 1 /* dataFieldUnknown */
@@ -313,8 +324,14 @@ func (ana *Analyzer) extractNumberNotEmpty(
 
 	ana.previousFieldValue = *value
 
-	if *value > *maxValue-reserved {
-		ana.printEmpty(*value - *maxValue)
+	empty := *value > *maxValue-reserved
+	if unsigned64 {
+		empty = uint64(*value) > uint64(*maxValue-reserved)
+	}
+	if empty {
+		ana.emptyValue = *value - *maxValue
+		ana.haveEmptyValue = true
+		ana.printEmpty(ana.emptyValue)
 		return false
 	}
 
@@ -354,6 +371,31 @@ func (ana *Analyzer) printEmpty(exceptionValue int64) {
 	}
 }
 
+// printFloat prints args with format, which shows value. JSON has no NaN or infinities, so
+// in JSON those print as null instead.
+func (ana *Analyzer) printFloat(value float64, format string, args ...interface{}) {
+	if ana.ShowJSON && isNaNOrInf(value) {
+		ana.pb.Printf("null")
+		return
+	}
+	ana.pb.Printf(format, args...)
+}
+
+// fieldPrecision returns the configured number of decimals for the field, preferring
+// a "PGN:name" entry over a plain "name" entry.
+func (ana *Analyzer) fieldPrecision(field *pgnField) (int, bool) {
+	if len(ana.FieldPrecision) == 0 {
+		return 0, false
+	}
+	if field.pgn != nil {
+		if precision, ok := ana.FieldPrecision[fmt.Sprintf("%d:%s", field.pgn.pgn, field.name)]; ok {
+			return precision, true
+		}
+	}
+	precision, ok := ana.FieldPrecision[field.name]
+	return precision, ok
+}
+
 func fieldPrintNumber(
 	ana *Analyzer,
 	field *pgnField,
@@ -388,19 +430,31 @@ func fieldPrintNumber(
 		resolution,
 		field.unitOffset,
 		logUnit)
-	if resolution == 1.0 && field.unitOffset == 0.0 {
+	newUnit, converted, isConverted := ana.convertUnit(field, float64(value)*resolution+field.unitOffset)
+	if resolution == 1.0 && field.unitOffset == 0.0 && !isConverted {
 		ana.Logger.Debug("fieldPrintNumber <%s> print as integer %d\n", fieldName, value)
-		ana.pb.Printf("%d", value)
+		if value < 0 && *bits >= 64 && !field.hasSign {
+			// A 64 bit unsigned field, such as a NAME, can go past math.MaxInt64
+			ana.pb.Printf("%d", uint64(value))
+		} else {
+			ana.pb.Printf("%d", value)
+		}
 		if !ana.ShowJSON && unit != "" {
 			ana.pb.Printf(" %s", unit)
 		}
 	} else {
 		var precision int
 
-		a = float64(value)*field.resolution + field.unitOffset
+		a = float64(scaleNumber(value, field.resolution, *bits)) + field.unitOffset
+		if isConverted {
+			a = converted
+			unit = newUnit
+		}
 
 		precision = field.precision
-		if precision == 0 {
+		if override, ok := ana.fieldPrecision(field); ok {
+			precision = override
+		} else if precision == 0 {
 			for r := field.resolution; (r > 0.0) && (r < 1.0); r *= 10.0 {
 				precision++
 			}
@@ -408,11 +462,11 @@ func fieldPrintNumber(
 
 		//nolint:gocritic
 		if ana.ShowJSON {
-			ana.pb.Printf("%.*f", precision, a)
+			ana.printFloat(a, "%.*f", precision, a)
 		} else if unit != "" && unit == "m" && a >= 1000.0 {
-			ana.pb.Printf("%.*f km", precision+3, a/1000)
+			ana.printFloat(a, "%.*f km", precision+3, a/1000)
 		} else {
-			ana.pb.Printf("%.*f", precision, a)
+			ana.printFloat(a, "%.*f", precision, a)
 			if unit != "" {
 				ana.pb.Printf(" %s", unit)
 			}
@@ -436,7 +490,7 @@ func fieldPrintFloat(
 		return false, nil
 	}
 
-	if *bits != 4 || startBit != 0 {
+	if *bits != 32 || startBit != 0 {
 		//nolint:errcheck
 		ana.Logger.Error("field '%s' FLOAT value unhandled bits=%d startBit=%d\n", fieldName, *bits, startBit)
 		return false, nil
@@ -445,7 +499,14 @@ func fieldPrintFloat(
 		return false, nil
 	}
 
-	ana.pb.Printf("%g", math.Float32frombits(binary.BigEndian.Uint32(data)))
+	// Like all NMEA 2000 numbers the float is little-endian on the wire; canboat copies
+	// the bytes straight into a float on (little-endian) hosts.
+	value := math.Float32frombits(binary.LittleEndian.Uint32(data))
+	if ana.FloatNaNAsEmpty && isNaNOrInf(float64(value)) {
+		ana.printEmpty(dataFieldUnknown)
+		return true, nil
+	}
+	ana.printFloat(float64(value), "%g", value)
 	if !ana.ShowJSON && field.unit != "" {
 		ana.pb.Printf(" %s", field.unit)
 	}
@@ -503,6 +564,23 @@ func fieldPrintDecimal(
 	return true, nil
 }
 
+// unknownLookupName returns value, which a lookup has no name for, formatted with
+// UnknownLookupFormat. It returns "" when that is not set or value is one of the Unknown,
+// ERROR or RESERVED values of a lookup field of the given bits.
+func (ana *Analyzer) unknownLookupName(value, maxValue int64, bits int) string {
+	if ana.UnknownLookupFormat == "" || value < 0 {
+		return ""
+	}
+	maxValueBitCheck := int64(1)
+	if bits > 2 {
+		maxValueBitCheck = 2
+	}
+	if bits > 1 && (value >= maxValue-maxValueBitCheck) {
+		return ""
+	}
+	return fmt.Sprintf(ana.UnknownLookupFormat, value)
+}
+
 func fieldPrintLookup(
 	ana *Analyzer,
 	field *pgnField,
@@ -548,6 +626,9 @@ func fieldPrintLookup(
 		}
 		// BIT is handled in fieldPrintBitLookup
 	}
+	if s == "" && field.lookup.lookupType != lookupTypeNone {
+		s = ana.unknownLookupName(value, maxValue, *bits)
+	}
 
 	if s != "" {
 		//nolint:gocritic
@@ -621,6 +702,9 @@ func fieldPrintBitLookup(
 		ana.Logger.Debug("RES_BITFIELD is bit %d value %d set? = %t\n", bit, bitValue, isSet)
 		if isSet {
 			s := field.lookup.functionPair(bit)
+			if s == "" && ana.UnknownLookupFormat != "" {
+				s = fmt.Sprintf(ana.UnknownLookupFormat, bitValue)
+			}
 
 			if s != "" {
 				//nolint:gocritic
@@ -839,6 +923,19 @@ func fieldPrintKeyValue(
 	return r, nil
 }
 
+// latLonInRange tells whether dd degrees is a possible latitude or longitude for the
+// field. Anything is possible unless ValidateRanges is set.
+func (ana *Analyzer) latLonInRange(field *pgnField, dd float64) bool {
+	if !ana.ValidateRanges {
+		return true
+	}
+	limit := 90.0
+	if field.longitude {
+		limit = 180.0
+	}
+	return dd >= -limit && dd <= limit
+}
+
 func fieldPrintLatLon(
 	ana *Analyzer,
 	field *pgnField,
@@ -868,10 +965,14 @@ func fieldPrintLatLon(
 	} else {
 		absVal = uint64(value)
 	}
-	dd = float64(value) * field.resolution
+	dd = scaleNumber(value, field.resolution, *bits)
+	if !ana.latLonInRange(field, dd) {
+		ana.printEmpty(dataFieldError)
+		return true, nil
+	}
 
 	if ana.ShowGeo == geoFormatDD {
-		ana.pb.Printf("%10.7f", dd)
+		ana.printFloat(dd, "%10.7f", dd)
 	} else {
 		if ana.ShowJSONValue {
 			ana.pb.Printf("%d,\"name\":", value)
@@ -1143,7 +1244,14 @@ func fieldPrintTime(
 	hours = minutes / 60
 	minutes %= 60
 
-	digits = int(math.Log10(float64(unitspersecond)))
+	// Show the fraction in decimal digits, also when a unit is not a power of ten
+	// seconds (e.g. 5 ms).
+	scale := uint64(1)
+	for scale < unitspersecond {
+		scale *= 10
+		digits++
+	}
+	units = uint32(uint64(units) * scale / unitspersecond)
 
 	if ana.ShowJSON {
 		if ana.ShowJSONValue {
@@ -1180,7 +1288,7 @@ func unhandledBitLength(fieldName string, length int, logger *common.Logger) boo
 }
 
 func (ana *Analyzer) printASCIIJSONEscaped(data []byte) {
-	for _, c := range string(data) {
+	for _, c := range ana.decodeString(data) {
 		switch c {
 		case '\b':
 			ana.pb.Printf("%s", "\\b")
@@ -1206,11 +1314,6 @@ func (ana *Analyzer) printASCIIJSONEscaped(data []byte) {
 		case '/':
 			ana.pb.Printf("%s", "\\/")
 
-		case '\377':
-			// 0xff has been seen on recent Simrad VHF systems, and it seems to indicate
-			// end-of-field, with noise following. Assume this does not break other systems.
-			return
-
 		default:
 			if c > 0x00 {
 				ana.pb.Printf("%c", c)
@@ -1220,19 +1323,7 @@ func (ana *Analyzer) printASCIIJSONEscaped(data []byte) {
 }
 
 func (ana *Analyzer) printString(data []byte) (bool, error) {
-	var lastbyte *byte
-
-	dataLen := len(data)
-	if dataLen > 0 {
-		// rtrim funny stuff from end, we see all sorts
-		lastbyte = &data[len(data)-1]
-		lastbyteCount := 0
-		for dataLen > 0 && (*lastbyte == 0xff || unicode.IsSpace(rune(*lastbyte)) || *lastbyte == 0 || *lastbyte == '@') {
-			dataLen--
-			lastbyteCount++
-			lastbyte = &data[len(data)-1-lastbyteCount]
-		}
-	}
+	dataLen := trimmedStringLen(data, ana.StringFFIsTerminator)
 
 	if dataLen == 0 {
 		ana.printEmpty(dataFieldUnknown)
@@ -0,0 +1,321 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func TestExtractNumberOddWidths(t *testing.T) {
+	logger := common.NewLogger(io.Discard)
+
+	for _, tc := range []struct {
+		name     string
+		data     []byte
+		startBit int
+		bits     int
+		value    int64
+		maxValue int64
+	}{
+		// ISO Address Claim: 21 bit unique number, then 11 bit manufacturer code (Navico = 275).
+		{"manufacturer code", []byte{0xfb, 0x9b, 0x70, 0x22}, 21, 11, 275, 0x7ff},
+		// Proprietary PGN header: 11 bit manufacturer, 2 reserved bits, 3 bit industry (Marine = 4).
+		{"proprietary manufacturer", []byte{0xe5, 0x98}, 0, 11, 229, 0x7ff},
+		{"proprietary industry", []byte{0xe5, 0x98}, 13, 3, 4, 0x7},
+		{"3 bits within a byte", []byte{0xc0}, 4, 3, 4, 0x7},
+		{"3 bits across bytes", []byte{0x80, 0x03}, 7, 3, 7, 0x7},
+		{"11 bits across three bytes", []byte{0xc0, 0xff, 0x01}, 6, 11, 0x7ff, 0x7ff},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var value, maxValue int64
+			test.That(t, extractNumber(nil, tc.data, tc.startBit, tc.bits, &value, &maxValue, logger), test.ShouldBeTrue)
+			test.That(t, value, test.ShouldEqual, tc.value)
+			test.That(t, maxValue, test.ShouldEqual, tc.maxValue)
+		})
+	}
+}
+
+func TestDecodeManufacturerAndIndustry(t *testing.T) {
+	msg := decodeFast(t, "2022-09-10T12:10:16.614Z,6,60928,5,255,8,fb,9b,70,22,00,9b,50,c0", false)
+	test.That(t, msg.Fields["Unique Number"], test.ShouldEqual, 1088507)
+	test.That(t, msg.Fields["Manufacturer Code"], test.ShouldEqual, "Navico")
+	test.That(t, msg.Fields["Industry Group"], test.ShouldEqual, "Marine")
+	test.That(t, msg.Fields["Arbitrary address capable"], test.ShouldEqual, 1)
+}
+
+func TestConvertSizedIntegerFields(t *testing.T) {
+	ana := newTestAnalyzer(t, "", RawFormatFast)
+	data := []byte{0xe5, 0x98}
+
+	for _, tc := range []struct {
+		field    pgnField
+		startBit int
+		value    int
+	}{
+		{simpleField("Manufacturer", 11), 0, 229},
+		{simpleField("Industry", 3), 13, 4},
+	} {
+		field := tc.field
+		field.ft, _ = ana.getFieldType(field.fieldType)
+		field.pgn = &pgnInfo{pgn: 126720}
+		var bits int
+		value, ok, err := ana.convertField(&field, field.name, data, tc.startBit, &bits)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, bits, test.ShouldEqual, int(field.size))
+		test.That(t, value, test.ShouldEqual, tc.value)
+	}
+}
+
+func TestConvertGeoFix64(t *testing.T) {
+	ana := newTestAnalyzer(t, "", RawFormatFast)
+
+	// 590171898067849930 * 1e-16 deg, more digits than a float64 holds
+	data := []byte{0xca, 0x92, 0xc0, 0xe4, 0x36, 0xb6, 0x30, 0x08}
+	field := latitudeI64Field("Latitude")
+	field.ft, _ = ana.getFieldType(field.fieldType)
+	field.pgn = &pgnInfo{pgn: 129029}
+	var bits int
+	value, ok, err := ana.convertField(&field, field.name, data, 0, &bits)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ok, test.ShouldBeTrue)
+	// The float64 nearest to the exact value; float64(value) * 1e-16 gives 59.017189806785
+	test.That(t, value, test.ShouldEqual, 59.01718980678499)
+	// A millimeter is about 9e-9 degrees of latitude
+	test.That(t, value, test.ShouldAlmostEqual, 59.0171898067849930, 1e-12)
+
+	test.That(t, scaleNumber(-590171898067849930, 1e-16, 64), test.ShouldEqual, -59.01718980678499)
+	test.That(t, scaleNumber(12345678, 1e-6, 64), test.ShouldEqual, 12.345678)
+	test.That(t, scaleNumber(1234, 0.01, 16), test.ShouldEqual, 1234*0.01)
+}
+
+func TestStringFFIsTerminator(t *testing.T) {
+	// Printing and converting end the string at the same place
+	for _, tc := range []struct {
+		data           string
+		ffIsTerminator bool
+		printed        string
+		converted      string
+	}{
+		{"AB\xffCD\xff\xff", true, "AB", "AB"},
+		{"AB \xffCD", true, "AB", "AB"},
+		{"AB\xffCD\xff\xff", false, "AB�CD��", "AB\xffCD\xff\xff"},
+	} {
+		ana := newTestAnalyzer(t, "", RawFormatFast)
+		ana.StringFFIsTerminator = tc.ffIsTerminator
+
+		ana.pb.Reset()
+		ok, err := ana.printString([]byte(tc.data))
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, string(ana.pb.buf[:ana.pb.p]), test.ShouldEqual, tc.printed)
+
+		converted, ok := ana.convertString([]byte(tc.data))
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, converted, test.ShouldEqual, tc.converted)
+	}
+
+	ana := newTestAnalyzer(t, "", RawFormatFast)
+	_, ok := ana.convertString([]byte{0xff, ' ', 0, '@'})
+	test.That(t, ok, test.ShouldBeFalse)
+}
+
+func TestFieldPrecision(t *testing.T) {
+	const line = "2022-11-14T01:47:30.890Z,3,128267,35,255,8,01,d2,04,00,00,f4,01,ff\n"
+
+	for _, tc := range []struct {
+		name      string
+		precision map[string]int
+		expected  string
+	}{
+		{"default", nil, "Depth = 12.34 m; Offset = 0.500 m"},
+		{"by name", map[string]int{"Depth": 1}, "Depth = 12.3 m; Offset = 0.500 m"},
+		{"by pgn and name", map[string]int{"Depth": 1, "128267:Depth": 0, "128259:Offset": 1}, "Depth = 12 m; Offset = 0.500 m"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+			conf.InFile = strings.NewReader(line)
+			conf.OutFile = &out
+			conf.ShowVersion = false
+			conf.FieldPrecision = tc.precision
+			ana, err := NewAnalyzer(conf)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, ana.Run(), test.ShouldBeNil)
+			test.That(t, out.String(), test.ShouldContainSubstring, tc.expected)
+		})
+	}
+}
+
+func TestStringEncoding(t *testing.T) {
+	// "Café Señor" in Latin-1.
+	data := []byte("Caf\xe9 Se\xf1or")
+
+	for _, tc := range []struct {
+		encoding StringEncoding
+		expected string
+	}{
+		{StringEncodingUTF8, "Caf� Se�or"},
+		{StringEncodingASCII, "Caf? Se?or"},
+		{StringEncodingLatin1, "Café Señor"},
+	} {
+		ana := newTestAnalyzer(t, "", RawFormatFast)
+		ana.StringEncoding = tc.encoding
+
+		ana.pb.Reset()
+		ok, err := ana.printString(data)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, string(ana.pb.buf[:ana.pb.p]), test.ShouldEqual, tc.expected)
+
+		if tc.encoding != StringEncodingUTF8 {
+			converted, ok := ana.convertString(data)
+			test.That(t, ok, test.ShouldBeTrue)
+			test.That(t, converted, test.ShouldEqual, tc.expected)
+		}
+	}
+
+	conf, cont, err := ParseArgs([]string{"analyzer", "-string-encoding", "latin1"})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, cont, test.ShouldBeTrue)
+	test.That(t, conf.StringEncoding, test.ShouldEqual, StringEncodingLatin1)
+}
+
+func TestFloatNaNAsEmpty(t *testing.T) {
+	// Salinity Station Data with NaN bits (00,00,c0,7f) for the Salinity FLOAT
+	const line = "2022-11-14T01:47:30.890Z,6,130321,35,255,25," +
+		"01,ff,ff,ff,ff,ff,ff,ff,ff,ff,7f,ff,ff,ff,7f,00,00,c0,7f,ff,ff,02,01,02,01\n"
+
+	for _, tc := range []struct {
+		name      string
+		nanEmpty  bool
+		showEmpty bool
+		expected  string
+	}{
+		{"null in JSON", false, false, `"Salinity":null`},
+		{"skipped", true, false, ""},
+		{"null", true, true, `"Salinity":null`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+			conf.InFile = strings.NewReader(line)
+			conf.OutFile = &out
+			conf.ShowVersion = false
+			conf.ShowJSON = true
+			conf.ShowJSONEmpty = tc.showEmpty
+			conf.FloatNaNAsEmpty = tc.nanEmpty
+			ana, err := NewAnalyzer(conf)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, ana.Run(), test.ShouldBeNil)
+			test.That(t, out.String(), test.ShouldContainSubstring, `"pgn":130321`)
+			test.That(t, out.String(), test.ShouldContainSubstring, tc.expected)
+			test.That(t, json.Valid(out.Bytes()), test.ShouldBeTrue)
+			test.That(t, out.String(), test.ShouldNotContainSubstring, "NaN")
+		})
+	}
+
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(line)
+	conf.FloatNaNAsEmpty = true
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	msg, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	_, ok := msg.Fields["Salinity"]
+	test.That(t, ok, test.ShouldBeFalse)
+}
+
+func TestNonFiniteJSON(t *testing.T) {
+	// Salinity Station Data with +Inf bits (00,00,80,7f) for the Salinity FLOAT
+	const line = "2022-11-14T01:47:30.890Z,6,130321,35,255,25," +
+		"01,ff,ff,ff,ff,ff,ff,ff,ff,ff,7f,ff,ff,ff,7f,00,00,80,7f,ff,ff,02,01,02,01\n"
+
+	var out bytes.Buffer
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(line)
+	conf.OutFile = &out
+	conf.ShowVersion = false
+	conf.ShowJSON = true
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ana.Run(), test.ShouldBeNil)
+
+	var doc map[string]interface{}
+	test.That(t, json.Unmarshal(out.Bytes(), &doc), test.ShouldBeNil)
+	fields, ok := doc["fields"].(map[string]interface{})
+	test.That(t, ok, test.ShouldBeTrue)
+	salinity, ok := fields["Salinity"]
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, salinity, test.ShouldBeNil)
+
+	conf = NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(line)
+	ana, err = NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	msg, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, math.IsInf(msg.Fields["Salinity"].(float64), 1), test.ShouldBeTrue)
+	data, err := MarshalMessageJSON(msg)
+	test.That(t, err, test.ShouldBeNil)
+	doc = nil
+	test.That(t, json.Unmarshal(data, &doc), test.ShouldBeNil)
+	test.That(t, doc["fields"].(map[string]interface{})["Salinity"], test.ShouldBeNil)
+}
+
+func TestUnknownLookupFormat(t *testing.T) {
+	// Fluid Level of tank type 9, which has no name, and of type 15, which is Unknown
+	const line = "2022-11-14T01:47:30.890Z,6,127505,17,255,8,91,09,3d,d0,07,00,00,ff\n"
+	const unknown = "2022-11-14T01:47:30.890Z,6,127505,17,255,8,f1,09,3d,d0,07,00,00,ff\n"
+
+	for _, tc := range []struct {
+		name      string
+		format    string
+		json      bool
+		jsonValue bool
+		expected  string
+	}{
+		{"default", "", false, false, "Instance = 1; Type = 9; Level"},
+		{"text", "UNKNOWN(%d)", false, false, "Instance = 1; Type = UNKNOWN(9); Level"},
+		{"json", "UNKNOWN(%d)", true, false, `"Type":"UNKNOWN(9)"`},
+		{"json value", "UNKNOWN(%d)", true, true, `"Type":{"value":9,"name":"UNKNOWN(9)"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+			conf.InFile = strings.NewReader(line + unknown)
+			conf.OutFile = &out
+			conf.ShowVersion = false
+			conf.ShowJSON = tc.json
+			conf.ShowJSONValue = tc.jsonValue
+			conf.SelectedFormat = RawFormatFast
+			conf.UnknownLookupFormat = tc.format
+			ana, err := NewAnalyzer(conf)
+			test.That(t, err, test.ShouldBeNil)
+			test.That(t, ana.Run(), test.ShouldBeNil)
+			test.That(t, out.String(), test.ShouldContainSubstring, tc.expected)
+			test.That(t, out.String(), test.ShouldNotContainSubstring, "(15)")
+		})
+	}
+
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.InFile = strings.NewReader(line + unknown)
+	conf.SelectedFormat = RawFormatFast
+	conf.UnknownLookupFormat = "UNKNOWN(%d)"
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+	msg, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg.Fields["Type"], test.ShouldEqual, "UNKNOWN(9)")
+	msg, err = ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	_, ok := msg.Fields["Type"]
+	test.That(t, ok, test.ShouldBeFalse)
+}
@@ -0,0 +1,117 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// timestampLayouts are the layouts of the timestamps that the parsers put in raw
+// messages. Fractional seconds, after a dot or a comma, are accepted by each of them.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02-15:04:05",
+	"2006-01-02T15:04:05",
+}
+
+// parseTimestamp parses the timestamp of a raw message.
+func parseTimestamp(timestamp string) (time.Time, bool) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, timestamp); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// A replayReader passes the lines of a capture on no faster than their timestamps allow.
+type replayReader struct {
+	in     *bufio.Reader
+	speed  float64
+	parser *Parser
+	err    error // From creating the parser, returned by the first Read
+	now    func() time.Time
+	sleep  func(time.Duration)
+
+	pending  []byte    // The rest of the line being read
+	lastTime time.Time // Timestamp of the last line that had one
+	lastSent time.Time // When that line was passed on
+}
+
+// ReplayReader returns a reader of the lines of r, in any format the analyzer detects,
+// that holds each line back until the time between its timestamp and that of the previous
+// one, divided by speed, has passed since that line was read. This makes a capture look
+// like a live bus. Lines without a timestamp, and timestamps that go backwards, are
+// passed on right away. A speed of 0 or less replays at the original speed.
+func ReplayReader(r io.Reader, speed float64) io.Reader {
+	if speed <= 0 {
+		speed = 1
+	}
+	parser, err := NewParser()
+	return &replayReader{
+		in:     bufio.NewReader(r),
+		speed:  speed,
+		parser: parser,
+		err:    err,
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+}
+
+func (rr *replayReader) Read(p []byte) (int, error) {
+	if rr.err != nil {
+		return 0, rr.err
+	}
+	if len(rr.pending) == 0 {
+		line, err := rr.in.ReadBytes('\n')
+		if len(line) == 0 {
+			return 0, err
+		}
+		rr.wait(line)
+		rr.pending = line
+	}
+	n := copy(p, rr.pending)
+	rr.pending = rr.pending[n:]
+	return n, nil
+}
+
+// wait sleeps until the given line is due.
+func (rr *replayReader) wait(line []byte) {
+	if strings.TrimSpace(string(line)) == "" || line[0] == '#' {
+		return
+	}
+	raw, err := rr.parser.ParseRawMessage(line)
+	if err != nil || raw == nil {
+		return
+	}
+	t, ok := parseTimestamp(raw.Timestamp)
+	if !ok {
+		return
+	}
+	if !rr.lastTime.IsZero() && t.After(rr.lastTime) {
+		due := rr.lastSent.Add(time.Duration(float64(t.Sub(rr.lastTime)) / rr.speed))
+		if delay := due.Sub(rr.now()); delay > 0 {
+			rr.sleep(delay)
+		}
+	}
+	rr.lastTime = t
+	rr.lastSent = rr.now()
+}
@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go.viam.com/test"
+)
+
+func TestReplayReader(t *testing.T) {
+	const capture = `2022-09-28-11:36:59.600,2,127250,1,255,8,00,fc,69,97,00,ff,7f,fd
+# a comment
+2022-09-28-11:36:59.800,2,127250,1,255,8,00,fc,69,97,00,ff,7f,fd
+2022-09-28-11:36:59.700,2,127250,1,255,8,00,fc,69,97,00,ff,7f,fd
+2022-09-28-11:37:00.000,2,127250,1,255,8,00,fc,69,97,00,ff,7f,fd
+`
+	// Only the lines after a later timestamp wait, for the time since the last one
+	expected := []time.Duration{200 * time.Millisecond, 300 * time.Millisecond}
+
+	for _, speed := range []float64{1, 4} {
+		rr, ok := ReplayReader(strings.NewReader(capture), speed).(*replayReader)
+		test.That(t, ok, test.ShouldBeTrue)
+		clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		var delays []time.Duration
+		rr.now = func() time.Time { return clock }
+		rr.sleep = func(d time.Duration) {
+			delays = append(delays, d)
+			clock = clock.Add(d)
+		}
+
+		data, err := io.ReadAll(rr)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, string(data), test.ShouldEqual, capture)
+		test.That(t, delays, test.ShouldHaveLength, len(expected))
+		for i, delay := range delays {
+			test.That(t, delay, test.ShouldEqual, time.Duration(float64(expected[i])/speed))
+		}
+	}
+
+	// Time spent by the reader counts towards the delay
+	rr, ok := ReplayReader(strings.NewReader(capture), 1).(*replayReader)
+	test.That(t, ok, test.ShouldBeTrue)
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var delays []time.Duration
+	rr.now = func() time.Time {
+		clock = clock.Add(50 * time.Millisecond)
+		return clock
+	}
+	rr.sleep = func(d time.Duration) { delays = append(delays, d) }
+	_, err := io.ReadAll(rr)
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, delays, test.ShouldResemble, []time.Duration{150 * time.Millisecond, 250 * time.Millisecond})
+}
+
+func TestParseTimestamp(t *testing.T) {
+	for _, timestamp := range []string{
+		"2022-09-28-11:36:59.669",
+		"2022-09-28T11:36:59.669Z",
+		"2022-09-28T11:36:59,669",
+	} {
+		parsed, ok := parseTimestamp(timestamp)
+		test.That(t, ok, test.ShouldBeTrue)
+		test.That(t, parsed, test.ShouldEqual, time.Date(2022, 9, 28, 11, 36, 59, 669e6, time.UTC))
+	}
+	_, ok := parseTimestamp("11:36:59")
+	test.That(t, ok, test.ShouldBeFalse)
+}
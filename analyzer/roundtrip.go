@@ -0,0 +1,215 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/erh/gonmea/common"
+)
+
+// A CodecMismatch is a decoded message that did not come back the same from
+// MarshalMessageBinary and UnmarshalMessageBinary.
+type CodecMismatch struct {
+	File   string
+	PGN    int
+	Reason string
+}
+
+// A CodecCheckReport summarizes what CheckBinaryCodec found.
+type CodecCheckReport struct {
+	Files      int // Files read
+	Messages   int // Messages decoded
+	Mismatches []CodecMismatch
+}
+
+// CheckBinaryCodec decodes every message in each file in dir, using the analyzer's config
+// for every file, and checks that it comes back equal from MarshalMessageBinary and
+// UnmarshalMessageBinary. It is a self-check of the binary codec over real captures: the
+// messages are never encoded back to NMEA 2000 bytes, for which there is no encoder. NaN
+// fields count as equal. Lines and messages that do not decode are skipped; the validate
+// mode reports those. Subdirectories are not read.
+func (ana *Analyzer) CheckBinaryCodec(ctx context.Context, dir string) (*CodecCheckReport, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	report := &CodecCheckReport{}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		capture, err := ana.checkCodecFile(ctx, filepath.Join(dir, entry.Name()), report)
+		if err != nil {
+			return nil, err
+		}
+		if capture {
+			report.Files++
+		}
+	}
+	return report, nil
+}
+
+// checkCodecFile adds what it finds in the file to the report. It returns false for a file
+// that is not in any format the analyzer knows.
+func (ana *Analyzer) checkCodecFile(ctx context.Context, path string, report *CodecCheckReport) (bool, error) {
+	//nolint:gosec
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	//nolint:errcheck
+	defer f.Close()
+
+	conf := ana.Config
+	conf.InFile = f
+	conf.InFiles = nil
+	conf.closeInFiles = false
+	conf.CodecCheckDir = ""
+	fileAna, err := NewAnalyzer(&conf)
+	if err != nil {
+		return false, err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		rawMsg, err := fileAna.ReadRawMessage()
+		if err != nil {
+			_, detected := fileAna.DetectedFormat()
+			if errors.Is(err, io.EOF) || !detected {
+				report.check(path, fileAna.FlushPartialFastPackets())
+				return detected, nil
+			}
+			return false, fmt.Errorf("%s: %w", path, err)
+		}
+		msg, err := fileAna.convertRawMessage(rawMsg)
+		msgs := fileAna.takePartialMsgs()
+		if err == nil {
+			msgs = append(msgs, msg)
+		}
+		report.check(path, msgs)
+	}
+}
+
+// check runs the messages read from the file at path through the binary codec.
+func (r *CodecCheckReport) check(path string, msgs []*common.Message) {
+	for _, msg := range msgs {
+		r.Messages++
+		if reason := checkMessageCodec(msg); reason != "" {
+			r.Mismatches = append(r.Mismatches, CodecMismatch{File: path, PGN: msg.Pgn, Reason: reason})
+		}
+	}
+}
+
+// checkMessageCodec returns why the message does not come back equal from
+// MarshalMessageBinary and UnmarshalMessageBinary, or "" when it does.
+func checkMessageCodec(msg *common.Message) string {
+	data, err := MarshalMessageBinary(msg)
+	if err != nil {
+		return err.Error()
+	}
+	back, err := UnmarshalMessageBinary(data)
+	if err != nil {
+		return err.Error()
+	}
+	names := make([]string, 0, len(msg.Fields))
+	for name := range msg.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !codecValuesEqual(msg.Fields[name], back.Fields[name]) {
+			return fmt.Sprintf("field '%s' %#v came back as %#v", name, msg.Fields[name], back.Fields[name])
+		}
+	}
+	if len(back.Fields) != len(msg.Fields) {
+		return fmt.Sprintf("%d fields came back as %d", len(msg.Fields), len(back.Fields))
+	}
+	header, backHeader := *msg, *back
+	header.Fields, backHeader.Fields = nil, nil
+	if !reflect.DeepEqual(header, backHeader) {
+		return "header came back different"
+	}
+	return ""
+}
+
+// codecValuesEqual is reflect.DeepEqual, except that a NaN equals a NaN, also inside
+// lists and maps.
+func codecValuesEqual(a, b interface{}) bool {
+	switch a := a.(type) {
+	case float64:
+		b, ok := b.(float64)
+		return ok && (a == b || (math.IsNaN(a) && math.IsNaN(b)))
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) || (a == nil) != (b == nil) {
+			return false
+		}
+		for i := range a {
+			if !codecValuesEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) || (a == nil) != (b == nil) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !codecValuesEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// runCodecCheck checks the binary codec over the files in CodecCheckDir, prints the
+// mismatches and fails if there were any.
+func (ana *Analyzer) runCodecCheck(ctx context.Context) error {
+	report, err := ana.CheckBinaryCodec(ctx, ana.CodecCheckDir)
+	if err != nil {
+		return err
+	}
+
+	for _, mismatch := range report.Mismatches {
+		fmt.Fprintf(ana.OutFile, "%s: PGN %d: %s\n", mismatch.File, mismatch.PGN, mismatch.Reason)
+	}
+	fmt.Fprintf(ana.OutFile, "Files: %d\n", report.Files)
+	fmt.Fprintf(ana.OutFile, "Messages: %d\n", report.Messages)
+	fmt.Fprintf(ana.OutFile, "Mismatches: %d\n", len(report.Mismatches))
+
+	if len(report.Mismatches) > 0 {
+		return &common.ExitError{Code: 1, Cause: fmt.Errorf("%d messages did not come back equal from the binary codec", len(report.Mismatches))}
+	}
+	return nil
+}
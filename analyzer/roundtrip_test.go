@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func TestCheckBinaryCodec(t *testing.T) {
+	var out bytes.Buffer
+	conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+	conf.OutFile = &out
+	conf.CodecCheckDir = "tests"
+	ana, err := NewAnalyzer(conf)
+	test.That(t, err, test.ShouldBeNil)
+
+	report, err := ana.CheckBinaryCodec(context.Background(), "tests")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, report.Files, test.ShouldBeGreaterThan, 0)
+	test.That(t, report.Messages, test.ShouldBeGreaterThan, 0)
+	test.That(t, report.Mismatches, test.ShouldBeEmpty)
+
+	test.That(t, ana.Run(), test.ShouldBeNil)
+	test.That(t, out.String(), test.ShouldContainSubstring, "Mismatches: 0\n")
+
+	_, err = ana.CheckBinaryCodec(context.Background(), "no-such-dir")
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestCheckMessageCodec(t *testing.T) {
+	msg := &common.Message{Pgn: 127250, Fields: map[string]interface{}{"Heading": 1.5, "Data": []byte{1, 2}}}
+	test.That(t, checkMessageCodec(msg), test.ShouldEqual, "")
+
+	msg.Fields["Heading"] = math.NaN()
+	msg.Fields["List"] = []interface{}{map[string]interface{}{"Depth": math.NaN()}}
+	test.That(t, checkMessageCodec(msg), test.ShouldEqual, "")
+
+	msg.Fields["Heading"] = float32(1.5)
+	test.That(t, checkMessageCodec(msg), test.ShouldContainSubstring, "cannot encode field value of type float32")
+}
+
+func TestCheckMessageCodecDecodedPGNs(t *testing.T) {
+	// Numbers, lookups, times, repeating fields and strings, with and without SI units.
+	for _, line := range []string{
+		"2022-06-17T06:33:14.000Z,3,126992,2,255,8,00,f0,d8,4a,80,1e,10,0e",
+		"2022-11-14T01:47:30.890Z,6,127508,17,255,8,01,f1,04,85,ff,77,74,2a",
+		"2022-11-14T01:47:30.890Z,6,128275,35,255,14,38,4a,d3,bd,ff,1a,70,d3,02,00,3c,07,00,00",
+		"2022-11-14T01:47:30.990Z,7,130074,35,255,38,00,02,02,00,01,ff,01," + wpHome + ",02," + wpBuoy7,
+		"2022-11-14T01:47:30.990Z,7,129285,35,255,53,00,00,02,00,01,00,03,00,e1,09,01,48,61,72,62,6f,75,72,ff,01,00," +
+			wpHome + ",02,00," + wpBuoy7,
+	} {
+		for _, si := range []bool{false, true} {
+			msg := decodeFast(t, line, si)
+			test.That(t, checkMessageCodec(msg), test.ShouldEqual, "")
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "time"
+
+// PGNTiming is how often a PGN was decoded and how long that took in total.
+type PGNTiming struct {
+	Count int
+	Total time.Duration
+}
+
+// Timings returns the decode time per PGN collected so far. It is only filled in when
+// CollectTimings is set.
+func (ana *Analyzer) Timings() map[uint32]PGNTiming {
+	timings := make(map[uint32]PGNTiming, len(ana.timings))
+	for pgn, timing := range ana.timings {
+		timings[pgn] = timing
+	}
+	return timings
+}
+
+func (ana *Analyzer) recordTiming(pgn uint32, start time.Time) {
+	if ana.timings == nil {
+		ana.timings = map[uint32]PGNTiming{}
+	}
+	timing := ana.timings[pgn]
+	timing.Count++
+	timing.Total += time.Since(start)
+	ana.timings[pgn] = timing
+}
@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func TestTimings(t *testing.T) {
+	const input = `2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff
+2022-11-14T01:47:30.890Z,2,127250,17,255,8,01,10,27,ff,7f,ff,7f,fd
+2022-11-14T01:47:30.990Z,2,127251,14,255,8,01,52,bf,ff,ff,ff,ff,ff
+`
+	for _, collect := range []bool{false, true} {
+		conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+		conf.InFile = strings.NewReader(input)
+		conf.OutFile = io.Discard
+		conf.ShowVersion = false
+		conf.CollectTimings = collect
+		ana, err := NewAnalyzer(conf)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, ana.Run(), test.ShouldBeNil)
+
+		timings := ana.Timings()
+		if !collect {
+			test.That(t, timings, test.ShouldBeEmpty)
+			continue
+		}
+		test.That(t, timings, test.ShouldHaveLength, 2)
+		test.That(t, timings[127251].Count, test.ShouldEqual, 2)
+		test.That(t, timings[127250].Count, test.ShouldEqual, 1)
+		test.That(t, timings[127251].Total, test.ShouldBeGreaterThan, 0)
+	}
+
+	ana := newTestAnalyzer(t, input, RawFormatPlain)
+	ana.CollectTimings = true
+	_, err := ana.ReadMessage()
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, ana.Timings()[127251].Count, test.ShouldEqual, 1)
+}
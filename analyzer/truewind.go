@@ -0,0 +1,87 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"math"
+
+	"github.com/erh/gonmea/common"
+)
+
+// A WindReference is the frame the true wind is computed in.
+type WindReference byte
+
+// The frames the true wind can be computed in, named after their WIND_REFERENCE.
+const (
+	WindReferenceBoat  WindReference = iota // Angle off the bow, as "True (boat referenced)"
+	WindReferenceNorth                      // Direction from true north, as "True (ground referenced to North)"
+)
+
+// NewTrueWindPostProcessor returns a post-processor that remembers the latest SOG, from
+// PGN 129026, and true heading, from PGN 127250, and adds the true wind computed from
+// them to each apparent wind message of PGN 130306, as the fields "True Wind Speed",
+// "True Wind Angle" and "True Wind Reference". The true wind is only added once an SOG,
+// and for WindReferenceNorth also a true heading, has been seen. It expects speeds in m/s
+// and angles in degrees, as they are converted without a UnitConverter. The returned
+// function keeps state, so give it to one analyzer only.
+func NewTrueWindPostProcessor(reference WindReference) func(*common.Message) error {
+	var sog, heading float64
+	var haveSOG, haveHeading bool
+	return func(msg *common.Message) error {
+		switch msg.Pgn {
+		case 129026:
+			sog, haveSOG = msg.Fields["SOG"].(float64)
+		case 127250:
+			if msg.Fields["Reference"] == "True" {
+				heading, haveHeading = msg.Fields["Heading"].(float64)
+			}
+		case 130306:
+			if msg.Fields["Reference"] != "Apparent" || !haveSOG || (reference == WindReferenceNorth && !haveHeading) {
+				return nil
+			}
+			speed, ok1 := msg.Fields["Wind Speed"].(float64)
+			angle, ok2 := msg.Fields["Wind Angle"].(float64)
+			if !ok1 || !ok2 {
+				return nil
+			}
+			trueSpeed, trueAngle := trueWind(speed, angle, sog)
+			name := "True (boat referenced)"
+			if reference == WindReferenceNorth {
+				trueAngle = math.Mod(trueAngle+heading, 360)
+				name = "True (ground referenced to North)"
+			}
+			msg.Fields["True Wind Speed"] = trueSpeed
+			msg.Fields["True Wind Angle"] = trueAngle
+			msg.Fields["True Wind Reference"] = name
+		}
+		return nil
+	}
+}
+
+// trueWind returns the speed of the true wind and its angle off the bow, in [0, 360), from
+// the apparent wind and the speed of the boat moving ahead. Leeway and current are ignored.
+func trueWind(apparentSpeed, apparentAngle, boatSpeed float64) (float64, float64) {
+	rad := apparentAngle * math.Pi / 180
+	ahead := apparentSpeed*math.Cos(rad) - boatSpeed
+	abeam := apparentSpeed * math.Sin(rad)
+	angle := math.Atan2(abeam, ahead) * 180 / math.Pi
+	if angle < 0 {
+		angle += 360
+	}
+	return math.Hypot(ahead, abeam), angle
+}
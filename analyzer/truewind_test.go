@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func TestTrueWindPostProcessor(t *testing.T) {
+	capture := strings.Join([]string{
+		// 10 m/s of apparent wind at 60 degrees before any SOG is known
+		"2022-11-14T01:47:30.890Z,2,130306,35,255,8,00,e8,03,e8,28,fa,ff,ff",
+		// 5 m/s SOG, then a true heading of 1 radian
+		"2022-11-14T01:47:30.990Z,2,129026,35,255,8,00,fc,10,27,f4,01,ff,ff",
+		"2022-11-14T01:47:31.090Z,2,127250,35,255,8,00,10,27,ff,7f,ff,7f,fc",
+		"2022-11-14T01:47:31.190Z,2,130306,35,255,8,00,e8,03,e8,28,fa,ff,ff",
+	}, "\n") + "\n"
+
+	for _, tc := range []struct {
+		reference WindReference
+		name      string
+		angle     float64
+	}{
+		{WindReferenceBoat, "True (boat referenced)", 90},
+		{WindReferenceNorth, "True (ground referenced to North)", 90 + 57.29577951308232},
+	} {
+		conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+		conf.InFile = strings.NewReader(capture)
+		conf.SelectedFormat = RawFormatFast
+		conf.multipackets = MultiPacketsCoalesced
+		conf.PostProcessors = []func(*common.Message) error{NewTrueWindPostProcessor(tc.reference)}
+		ana, err := NewAnalyzer(conf)
+		test.That(t, err, test.ShouldBeNil)
+
+		msg, err := ana.ReadMessage()
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, msg.Fields, test.ShouldNotContainKey, "True Wind Speed")
+		for i := 0; i < 3; i++ {
+			msg, err = ana.ReadMessage()
+			test.That(t, err, test.ShouldBeNil)
+		}
+		test.That(t, msg.Pgn, test.ShouldEqual, 130306)
+		// The 5 m/s of apparent wind from ahead are all the boat's own, which leaves the
+		// 8.66 m/s from abeam
+		test.That(t, msg.Fields["True Wind Speed"], test.ShouldAlmostEqual, 8.660, 1e-3)
+		test.That(t, msg.Fields["True Wind Angle"], test.ShouldAlmostEqual, tc.angle, 1e-3)
+		test.That(t, msg.Fields["True Wind Reference"], test.ShouldEqual, tc.name)
+		test.That(t, msg.Fields["Wind Speed"], test.ShouldEqual, 10)
+	}
+}
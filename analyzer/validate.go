@@ -0,0 +1,136 @@
+package analyzer
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/erh/gonmea/common"
+)
+
+// A ValidationReport summarizes how well a capture decoded.
+type ValidationReport struct {
+	Messages              int            // Messages decoded
+	PGNCounts             map[uint32]int // Messages decoded per PGN
+	BadLines              int            // Input lines that could not be parsed
+	IncompleteFastPackets int            // Fast packets that lost frames or never completed
+	DecodeErrors          int            // Complete messages that could not be decoded
+	UnknownPGNs           map[uint32]int // Messages per PGN without a definition
+}
+
+// Errors returns the number of problems found. Unknown PGNs are not counted as errors.
+func (r *ValidationReport) Errors() int {
+	return r.BadLines + r.IncompleteFastPackets + r.DecodeErrors
+}
+
+// ValidateInput reads and decodes the whole input without printing the messages, and reports
+// what it found.
+func (ana *Analyzer) ValidateInput(ctx context.Context) (*ValidationReport, error) {
+	report := &ValidationReport{
+		PGNCounts:   map[uint32]int{},
+		UnknownPGNs: map[uint32]int{},
+	}
+	ana.badLines = 0
+	ana.incompleteFastPackets = 0
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rawMsg, err := ana.ReadRawMessage()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if pgn, _ := ana.searchForPgn(rawMsg.PGN); pgn == nil {
+			report.UnknownPGNs[rawMsg.PGN]++
+		}
+		msg, err := ana.convertRawMessage(rawMsg)
+		for _, partial := range ana.takePartialMsgs() {
+			report.Messages++
+			report.PGNCounts[uint32(partial.Pgn)]++
+		}
+		if err != nil {
+			if !errors.Is(err, ErrFastPacketIncomplete) {
+				ana.Logger.Debug("PGN %d from source %d does not decode: %s\n", rawMsg.PGN, rawMsg.Src, err)
+				report.DecodeErrors++
+			}
+			continue
+		}
+		report.Messages++
+		report.PGNCounts[uint32(msg.Pgn)]++
+	}
+
+	report.BadLines = ana.badLines
+	report.IncompleteFastPackets = ana.incompleteFastPackets
+	for i := range ana.reassemblyBuffer {
+		if ana.reassemblyBuffer[i].used && ana.reassemblyBuffer[i].frames != 0 {
+			report.IncompleteFastPackets++
+		}
+	}
+	for _, partial := range ana.FlushPartialFastPackets() {
+		report.Messages++
+		report.PGNCounts[uint32(partial.Pgn)]++
+	}
+	return report, nil
+}
+
+// runValidate validates the input, prints the report and fails if any errors were found.
+func (ana *Analyzer) runValidate(ctx context.Context) error {
+	report, err := ana.ValidateInput(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ana.OutFile, "Messages: %d\n", report.Messages)
+	fmt.Fprintf(ana.OutFile, "Lines not understood: %d\n", report.BadLines)
+	fmt.Fprintf(ana.OutFile, "Incomplete fast packets: %d\n", report.IncompleteFastPackets)
+	fmt.Fprintf(ana.OutFile, "Decode errors: %d\n", report.DecodeErrors)
+	fmt.Fprintf(ana.OutFile, "Unknown PGNs: %d\n", len(report.UnknownPGNs))
+	for _, pgn := range sortedPGNs(report.UnknownPGNs) {
+		fmt.Fprintf(ana.OutFile, "  %6d %d\n", pgn, report.UnknownPGNs[pgn])
+	}
+	fmt.Fprintf(ana.OutFile, "Messages per PGN:\n")
+	for _, pgn := range sortedPGNs(report.PGNCounts) {
+		description := ""
+		if info, _ := ana.searchForPgn(pgn); info != nil {
+			description = pgnLabel(info)
+		}
+		fmt.Fprintf(ana.OutFile, "  %6d %d %s\n", pgn, report.PGNCounts[pgn], description)
+	}
+
+	if errCount := report.Errors(); errCount > 0 {
+		return &common.ExitError{Code: 1, Cause: fmt.Errorf("capture has %d errors", errCount)}
+	}
+	return nil
+}
+
+func sortedPGNs(counts map[uint32]int) []uint32 {
+	pgns := make([]uint32, 0, len(counts))
+	for pgn := range counts {
+		pgns = append(pgns, pgn)
+	}
+	sort.Slice(pgns, func(i, j int) bool { return pgns[i] < pgns[j] })
+	return pgns
+}
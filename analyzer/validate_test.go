@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/common"
+)
+
+func TestValidate(t *testing.T) {
+	const gnss = `2022-09-28-11:36:59.668,3,129029,0,255,8,00,2f,e7,95,3d,00,73,d6
+2022-09-28-11:36:59.668,3,129029,0,255,8,01,29,00,da,04,73,db,c9
+2022-09-28-11:36:59.668,3,129029,0,255,8,02,e5,05,80,7d,02,28,5f
+2022-09-28-11:36:59.668,3,129029,0,255,8,03,d6,10,f6,9b,50,6c,05
+2022-09-28-11:36:59.668,3,129029,0,255,8,04,00,00,00,00,13,fc,08
+2022-09-28-11:36:59.668,3,129029,0,255,8,05,6f,00,be,00,dd,f2,ff
+2022-09-28-11:36:59.668,3,129029,0,255,8,06,ff,00,ff,ff,ff,ff,ff
+`
+	const rateOfTurn = "2022-09-28-11:36:59.668,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff\n"
+
+	validate := func(t *testing.T, input string) (string, error) {
+		t.Helper()
+		var out bytes.Buffer
+		conf := NewConfigForLibrary(common.NewLogger(io.Discard))
+		conf.InFile = strings.NewReader(input)
+		conf.OutFile = &out
+		conf.SelectedFormat = RawFormatPlain
+		conf.Validate = true
+		ana, err := NewAnalyzer(conf)
+		test.That(t, err, test.ShouldBeNil)
+		err = ana.Run()
+		return out.String(), err
+	}
+
+	t.Run("clean", func(t *testing.T) {
+		out, err := validate(t, gnss+rateOfTurn+rateOfTurn)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, out, test.ShouldContainSubstring, "Messages: 3\n")
+		test.That(t, out, test.ShouldContainSubstring, "Decode errors: 0\n")
+		test.That(t, out, test.ShouldContainSubstring, "  127251 2 Rate of Turn\n")
+		test.That(t, out, test.ShouldContainSubstring, "  129029 1 GNSS Position Data\n")
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		// Frame 3 of the first GNSS packet is missing and a line is cut short.
+		lines := strings.SplitAfter(gnss, "\n")
+		input := strings.Join(lines[:3], "") + strings.Join(lines[4:], "") + gnss +
+			"2022-09-28-11:36:59.668,2,127251,14\n" + rateOfTurn
+		out, err := validate(t, input)
+		var exitErr *common.ExitError
+		test.That(t, errors.As(err, &exitErr), test.ShouldBeTrue)
+		test.That(t, exitErr.Code, test.ShouldEqual, 1)
+		test.That(t, out, test.ShouldContainSubstring, "Messages: 2\n")
+		test.That(t, out, test.ShouldContainSubstring, "Lines not understood: 1\n")
+		test.That(t, out, test.ShouldContainSubstring, "Incomplete fast packets: 1\n")
+	})
+}
@@ -2,9 +2,12 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 
 	"github.com/erh/gonmea/analyzer"
 	"github.com/erh/gonmea/common"
@@ -16,9 +19,39 @@ func main() {
 	if !cont {
 		return
 	}
+	// Stop cleanly on interrupt so buffered output is flushed.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// Let a second interrupt kill the process.
+		stop()
+	}()
+	if conf.InFile == os.Stdin {
+		conf.InFile = cancelableReader(ctx, os.Stdin)
+	}
+
 	ana, err := analyzer.NewAnalyzer(conf)
 	handleErr(err)
-	handleErr(ana.Run())
+	err = ana.RunContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		handleErr(err)
+	}
+}
+
+// cancelableReader returns a reader of in whose reads return once ctx is done, even when
+// blocked on in: reading stdin cannot be interrupted otherwise.
+func cancelableReader(ctx context.Context, in io.Reader) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := io.Copy(pw, in)
+		pw.CloseWithError(err)
+	}()
+	go func() {
+		<-ctx.Done()
+		pw.CloseWithError(ctx.Err())
+	}()
+	return pr
 }
 
 func handleErr(err error) {
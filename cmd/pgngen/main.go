@@ -0,0 +1,239 @@
+// Package main generates typed Go structs for a selection of PGNs from the analyzer's
+// PGN definitions. The output is the pgns package; see its go:generate line.
+package main
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/erh/gonmea/analyzer"
+)
+
+// curatedPGNs are the PGNs structs are generated for. They have a single definition
+// without repeating fields, and only number and lookup fields.
+var curatedPGNs = []uint32{
+	127245, // Rudder
+	127250, // Vessel Heading
+	127251, // Rate of Turn
+	127257, // Attitude
+	127488, // Engine Parameters, Rapid Update
+	127508, // Battery Status
+	128259, // Speed
+	128267, // Water Depth
+	129025, // Position, Rapid Update
+	129026, // COG & SOG, Rapid Update
+	130306, // Wind Data
+	130312, // Temperature
+}
+
+func main() {
+	out := flag.String("o", "", "file to write the generated code to instead of stdout")
+	pkg := flag.String("package", "pgns", "package name of the generated code")
+	flag.Parse()
+
+	if err := realMain(*out, *pkg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func realMain(out, pkg string) error {
+	defs, err := analyzer.PGNDefinitions()
+	if err != nil {
+		return err
+	}
+	code, err := generate(pkg, defs, curatedPGNs)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(out, code, 0o644)
+}
+
+// licenseHeader starts the generated file like every other file here.
+const licenseHeader = `// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.`
+
+// generate returns the formatted source of the structs for the given PGNs.
+func generate(pkg string, defs []analyzer.PGNDefinition, pgns []uint32) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by pgngen; DO NOT EDIT.\n\npackage %s\n\n%s\n", pkg, licenseHeader)
+
+	for _, pgn := range pgns {
+		def, err := findDefinition(defs, pgn)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeStruct(&buf, def); err != nil {
+			return nil, err
+		}
+	}
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return code, nil
+}
+
+func findDefinition(defs []analyzer.PGNDefinition, pgn uint32) (*analyzer.PGNDefinition, error) {
+	var found *analyzer.PGNDefinition
+	for i := range defs {
+		if defs[i].PGN != pgn {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("PGN %d has more than one definition", pgn)
+		}
+		found = &defs[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("PGN %d is not defined", pgn)
+	}
+	if found.Repeating {
+		return nil, fmt.Errorf("PGN %d has repeating fields", pgn)
+	}
+	return found, nil
+}
+
+type structField struct {
+	goName string
+	name   string
+	kind   string // "int", "float64" or "string"
+	unit   string
+}
+
+func writeStruct(buf *bytes.Buffer, def *analyzer.PGNDefinition) error {
+	typeName := goName(def.Description)
+
+	var fields []structField
+	seen := map[string]bool{}
+	for _, field := range def.Fields {
+		if field.FieldType == "RESERVED" || field.FieldType == "SPARE" {
+			continue
+		}
+		kind, err := fieldKind(field)
+		if err != nil {
+			return fmt.Errorf("PGN %d: %w", def.PGN, err)
+		}
+		name := goName(field.Name)
+		if seen[name] {
+			return fmt.Errorf("PGN %d: field name %s is not unique", def.PGN, name)
+		}
+		seen[name] = true
+		fields = append(fields, structField{goName: name, name: field.Name, kind: kind, unit: field.Unit})
+	}
+
+	fmt.Fprintf(buf, "\n// %s is PGN %d, %s. Fields that are not available are nil.\n", typeName, def.PGN, def.Description)
+	fmt.Fprintf(buf, "type %s struct {\n", typeName)
+	for _, field := range fields {
+		fmt.Fprintf(buf, "\t%s *%s", field.goName, field.kind)
+		if field.unit != "" {
+			fmt.Fprintf(buf, " // %s", field.unit)
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n")
+
+	fmt.Fprintf(buf, "\n// PGN returns %d.\n", def.PGN)
+	fmt.Fprintf(buf, "func (*%s) PGN() uint32 {\n\treturn %d\n}\n", typeName, def.PGN)
+
+	fmt.Fprintf(buf, "\n// Unmarshal decodes the payload of a PGN %d message into m.\n", def.PGN)
+	fmt.Fprintf(buf, "func (m *%s) Unmarshal(data []byte) error {\n", typeName)
+	fmt.Fprintf(buf, "\tfields, err := decode(%d, data)\n\tif err != nil {\n\t\treturn err\n\t}\n", def.PGN)
+	fmt.Fprintf(buf, "\t*m = %s{\n", typeName)
+	for _, field := range fields {
+		fmt.Fprintf(buf, "\t\t%s: %sField(fields, %q),\n", field.goName, strings.TrimSuffix(field.kind, "64"), field.name)
+	}
+	buf.WriteString("\t}\n\treturn nil\n}\n")
+	return nil
+}
+
+// fieldKind returns the Go type the analyzer decodes the field as.
+func fieldKind(field analyzer.PGNFieldInfo) (string, error) {
+	switch {
+	case field.Lookup != "":
+		return "string", nil
+	case field.Unit != "" && field.Unit[0] == '=':
+		return "", fmt.Errorf("field '%s' is a match field", field.Name)
+	case isNumber(field.FieldType) && field.Resolution == 1 && field.UnitOffset == 0:
+		return "int", nil
+	case isNumber(field.FieldType):
+		return "float64", nil
+	default:
+		return "", fmt.Errorf("field '%s' has unsupported field type %s", field.Name, field.FieldType)
+	}
+}
+
+func isNumber(fieldType string) bool {
+	for _, prefix := range []string{
+		"UINT", "INT", "INTEGER", "UNSIGNED_INTEGER", "ANGLE_", "ROTATION_", "SPEED_", "LENGTH_", "DISTANCE_",
+		"GEO_FIX", "TEMPERATURE", "PRESSURE_", "VOLTAGE_", "CURRENT_", "DILUTION_OF_PRECISION_",
+	} {
+		if strings.HasPrefix(fieldType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// goName turns a PGN or field name like "COG & SOG, Rapid Update" into an exported Go
+// identifier like "COGSOGRapidUpdate".
+func goName(name string) string {
+	var b strings.Builder
+	upperNext := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upperNext = true
+			continue
+		}
+		if b.Len() == 0 && unicode.IsDigit(r) {
+			b.WriteByte('N')
+		}
+		if upperNext {
+			r = unicode.ToUpper(r)
+			upperNext = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
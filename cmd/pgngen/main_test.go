@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/analyzer"
+)
+
+func TestGeneratedCodeIsCurrent(t *testing.T) {
+	defs, err := analyzer.PGNDefinitions()
+	test.That(t, err, test.ShouldBeNil)
+	code, err := generate("pgns", defs, curatedPGNs)
+	test.That(t, err, test.ShouldBeNil)
+
+	current, err := os.ReadFile("../../pgns/pgns_gen.go")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(current), test.ShouldEqual, string(code))
+}
+
+func TestGenerateRejectsUnsupportedPGNs(t *testing.T) {
+	defs, err := analyzer.PGNDefinitions()
+	test.That(t, err, test.ShouldBeNil)
+
+	_, err = generate("pgns", defs, []uint32{1})
+	test.That(t, err, test.ShouldBeError, "PGN 1 is not defined")
+
+	// Product Information has string fields.
+	_, err = generate("pgns", defs, []uint32{126996})
+	test.That(t, err, test.ShouldNotBeNil)
+}
+
+func TestGoName(t *testing.T) {
+	test.That(t, goName("COG & SOG, Rapid Update"), test.ShouldEqual, "COGSOGRapidUpdate")
+	test.That(t, goName("Rate of Turn"), test.ShouldEqual, "RateOfTurn")
+	test.That(t, goName("3-axis"), test.ShouldEqual, "N3Axis")
+}
+
+func TestFieldKind(t *testing.T) {
+	kind, err := fieldKind(analyzer.PGNFieldInfo{Name: "Count", FieldType: "UINT8", Resolution: 1})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, kind, test.ShouldEqual, "int")
+
+	// An offset makes whole units decode as a float, like temperatures converted to C.
+	kind, err = fieldKind(analyzer.PGNFieldInfo{Name: "Temperature", FieldType: "UINT8", Resolution: 1, UnitOffset: -273.15})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, kind, test.ShouldEqual, "float64")
+}
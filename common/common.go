@@ -55,6 +55,7 @@ type Logger struct {
 	level          LogLevel
 	progName       string
 	fixedTimestamp string
+	location       *time.Location
 	writer         io.Writer
 	isCLI          bool
 }
@@ -212,6 +213,21 @@ func (l *Logger) SetFixedTimestamp(fixedStr string) {
 	l.Info("Timestamp fixed\n")
 }
 
+// SetLocation sets the time zone that parsers put absolute timestamps in, for formats
+// that give times as seconds since the epoch or only a time of day. Nil means local time.
+func (l *Logger) SetLocation(loc *time.Location) {
+	l.location = loc
+}
+
+// Location returns the time zone that parsers put absolute timestamps in.
+func (l *Logger) Location() *time.Location {
+	if l.location == nil {
+		//nolint:gosmopolitan
+		return time.Local
+	}
+	return l.location
+}
+
 // AllowPGNFastPacket returns if this PGN Fast is allowed.
 func AllowPGNFastPacket(n uint32) bool {
 	return (((n) >= 0x10000 && (n) < 0x1FFFF) || (n) >= CANBoatPGNStart)
@@ -287,12 +303,12 @@ func getISO11783BitsFromCanID(
 	src *uint,
 	dst *uint,
 ) {
-	PF := id >> 16
-	PS := id >> 8
+	PF := (id >> 16) & 0xff
+	PS := (id >> 8) & 0xff
 	RDP := id >> 24 & 3 // Use R + DP bits
 
 	if src != nil {
-		*src = id >> 0
+		*src = id & 0xff
 	}
 	if prio != nil {
 		*prio = (id >> 26) & 0x7
@@ -317,6 +333,31 @@ func getISO11783BitsFromCanID(
 	}
 }
 
+// DecodeCanID returns the priority, PGN, source and destination of a 29 bit CAN id.
+func DecodeCanID(id uint32) (prio uint8, pgn uint32, src, dst uint8) {
+	var p, n, s, d uint
+	getISO11783BitsFromCanID(uint(id), &p, &n, &s, &d)
+	return uint8(p), uint32(n), uint8(s), uint8(d)
+}
+
+// EncodeCanID returns the 29 bit CAN id for the priority, PGN, source and destination;
+// it is the inverse of DecodeCanID.
+func EncodeCanID(prio uint8, pgn uint32, src, dst uint8) uint32 {
+	return uint32(getCanIDFromISO11783Bits(uint(prio), uint(pgn), uint(src), uint(dst)))
+}
+
+// getCanIDFromISO11783Bits is the inverse of getISO11783BitsFromCanID. The destination
+// is only part of the ID for PDU1 PGNs; PDU2 PGNs are always sent to all.
+func getCanIDFromISO11783Bits(prio, pgn, src, dst uint) uint {
+	id := (src & 0xff) | ((pgn & 0x3ff00) << 8) | ((prio & 0x7) << 26)
+	if (pgn>>8)&0xff < 240 {
+		id |= (dst & 0xff) << 8
+	} else {
+		id |= (pgn & 0xff) << 8
+	}
+	return id
+}
+
 // ExitError is an error for exit codes.
 type ExitError struct {
 	Code  int
@@ -0,0 +1,74 @@
+package common
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "fmt"
+
+// ParseHexBytes parses data bytes written as pairs of hex digits. Bytes may be separated
+// by any mix of spaces, commas and newlines or not at all, and each run of digits may
+// start with 0x, so "01,02", "01 02", "0102", "0x01 0x02" and "0x0102" are all the same.
+func ParseHexBytes(s string) ([]byte, error) {
+	return appendHexBytes(nil, []byte(s))
+}
+
+// appendHexBytes is ParseHexBytes appending to dst, so that parsers can fill the data of
+// a RawMessage without allocating.
+func appendHexBytes(dst, p []byte) ([]byte, error) {
+	runStart := true
+	for i := 0; i < len(p); {
+		c := p[i]
+		if c == ',' || c == '\n' || isScanSpace(c) {
+			runStart = true
+			i++
+			continue
+		}
+		if runStart && c == '0' && i+1 < len(p) && (p[i+1] == 'x' || p[i+1] == 'X') {
+			if i+2 == len(p) || scanNibble(p[i+2]) > 15 {
+				return dst, fmt.Errorf("0x without hex digits at offset %d", i)
+			}
+			runStart = false
+			i += 2
+			continue
+		}
+		runStart = false
+
+		if i+1 >= len(p) {
+			return dst, fmt.Errorf("odd number of hex digits at offset %d", i)
+		}
+		hi, lo := scanNibble(p[i]), scanNibble(p[i+1])
+		if hi > 15 || lo > 15 {
+			return dst, fmt.Errorf("invalid hex byte %q at offset %d", p[i:i+2], i)
+		}
+		dst = append(dst, hi<<4|lo)
+		i += 2
+	}
+	return dst, nil
+}
+
+// parseHexData parses data bytes like ParseHexBytes into m.Data and returns how many
+// there were.
+func parseHexData(m *RawMessage, p []byte) (int, error) {
+	data, err := appendHexBytes(m.Data[:0], p)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > len(m.Data) {
+		return 0, fmt.Errorf("more than %d data bytes", len(m.Data))
+	}
+	return len(data), nil
+}
@@ -0,0 +1,84 @@
+package common
+
+import (
+	"io"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestParseHexBytes(t *testing.T) {
+	expected := []byte{0x01, 0xab, 0xff}
+	for _, s := range []string{
+		"01abff",
+		"01 ab ff",
+		"01,ab,ff",
+		"01, ab,\tff\r\n",
+		"0x01abff",
+		"0X01ABFF",
+		"0x01 0xab 0xff",
+		"0x01,0xAB,0xFF",
+		" 01ab ff ",
+	} {
+		data, err := ParseHexBytes(s)
+		test.That(t, err, test.ShouldBeNil)
+		test.That(t, data, test.ShouldResemble, expected)
+	}
+
+	data, err := ParseHexBytes("")
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, data, test.ShouldBeEmpty)
+
+	for _, s := range []string{"01a", "01 a ff", "01,0g", "01;02", "010x02", "0x", "0x 01"} {
+		_, err := ParseHexBytes(s)
+		test.That(t, err, test.ShouldNotBeNil)
+	}
+}
+
+func TestParseRawFormatsHexData(t *testing.T) {
+	logger := NewLogger(io.Discard)
+	expected := []byte{0xff, 0xdf, 0x40, 0xa6, 0xe9, 0xbb, 0x22, 0xc0}
+
+	for _, tc := range []struct {
+		name  string
+		parse func(msg []byte, m *RawMessage) int
+		line  string
+	}{
+		{
+			"fast", func(msg []byte, m *RawMessage) int { return ParseRawFormatFast(msg, m, true, logger) },
+			"2022-11-14T01:47:30.890Z,2,127251,14,255,8,ff,df,40,a6,e9,bb,22,c0\n",
+		},
+		{
+			"actisense", func(msg []byte, m *RawMessage) int { return ParseRawFormatActisenseN2KAscii(msg, m, true, logger) },
+			"A000123.5 0EFF2 1F113 FFDF40A6E9BB22C0\n",
+		},
+		{
+			"airmar commas", func(msg []byte, m *RawMessage) int { return ParseRawFormatAirmar(msg, m, true, logger) },
+			"2022-11-14T01:47:30.890Z - 127251 FF,DF,40,A6,E9,BB,22,C0",
+		},
+		{
+			"airmar spaces", func(msg []byte, m *RawMessage) int { return ParseRawFormatAirmar(msg, m, true, logger) },
+			"2022-11-14T01:47:30.890Z - 127251 FF DF 40 A6 E9 BB 22 C0",
+		},
+		{
+			"chetco", func(msg []byte, m *RawMessage) int { return ParseRawFormatChetco(msg, m, true, logger) },
+			"$PCDIN,01F113,00000000,0F,FFDF40A6E9BB22C0*59\n",
+		},
+		{
+			"garmin", func(msg []byte, m *RawMessage) int { return ParseRawFormatGarminCSV(msg, m, true, false, logger) },
+			"0,486942,127251,Rate of Turn,Garmin,6,255,2,1,8,0xFFDF40A6E9BB22C0\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var m RawMessage
+			test.That(t, tc.parse([]byte(tc.line), &m), test.ShouldEqual, 0)
+			test.That(t, m.Data[:m.Len], test.ShouldResemble, expected)
+		})
+	}
+
+	t.Run("fast with too few bytes", func(t *testing.T) {
+		var m RawMessage
+		line := "2022-11-14T01:47:30.890Z,2,127251,14,255,8,ff,df,40\n"
+		test.That(t, ParseRawFormatFast([]byte(line), &m, true, logger), test.ShouldEqual, 2)
+	})
+}
@@ -20,6 +20,7 @@ package common
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -36,6 +37,13 @@ type RawMessage struct {
 	Src       uint8
 	Len       uint8
 	Data      [FastPacketMaxSize]byte
+	Sequence  uint8 // Fast-packet sequence id read from a trailing "seq=" column
+}
+
+// CanID returns the 29 bit CAN id the message was sent with. The destination is only part
+// of it for PDU1 PGNs.
+func (m *RawMessage) CanID() uint32 {
+	return EncodeCanID(m.Prio, m.PGN, m.Src, m.Dst)
 }
 
 // Message is a NMEA 2000 PGN message.
@@ -47,6 +55,99 @@ type Message struct {
 	Pgn         int                    `json:"pgn"`
 	Description string                 `json:"description"`
 	Fields      map[string]interface{} `json:"fields"`
+
+	// CamelDescription is the description in camelCase, which the JSON output uses as the
+	// key of the message. It is only set when the analyzer was configured with CamelCase.
+	CamelDescription string `json:"camelDescription,omitempty"`
+
+	// Frames is the number of frames a fast-packet message was reassembled from and
+	// Sequence the 3 bit sequence id the sender used for those frames. Both are only
+	// set when the analyzer reassembled the message itself.
+	Frames   int `json:"frames,omitempty"`
+	Sequence int `json:"sequence,omitempty"`
+
+	// Partial is set when the message was decoded from the first frames of a fast
+	// packet whose other frames never arrived.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// DateTime combines the DATE field dateField and the TIME field timeField of the message,
+// such as the "Date" and "Time" of System Time (126992), into one UTC time. It returns
+// false when either field is missing or empty.
+func (m *Message) DateTime(dateField, timeField string) (time.Time, bool) {
+	date, ok := m.Fields[dateField].(time.Time)
+	if !ok {
+		return time.Time{}, false
+	}
+	timeOfDay, ok := m.Fields[timeField].(time.Duration)
+	if !ok {
+		return time.Time{}, false
+	}
+	return date.Add(timeOfDay), true
+}
+
+// A FieldSentinel is decoded in place of a value when a field holds one of the values
+// NMEA 2000 reserves at the top of its range to say the value is not a real one.
+type FieldSentinel int
+
+// The field sentinels, from the highest raw value down.
+const (
+	FieldUnknown FieldSentinel = iota
+	FieldError
+	FieldReserved1
+	FieldReserved2
+	FieldReserved3
+)
+
+// FieldNotPresent is held by fields that the data of a message ended before, when the
+// analyzer is asked to include empty fields.
+const FieldNotPresent FieldSentinel = -1
+
+func (fs FieldSentinel) String() string {
+	switch fs {
+	case FieldUnknown:
+		return "Unknown"
+	case FieldError:
+		return "ERROR"
+	case FieldReserved1:
+		return "RESERVED1"
+	case FieldReserved2:
+		return "RESERVED2"
+	case FieldReserved3:
+		return "RESERVED3"
+	case FieldNotPresent:
+		return "Not present"
+	default:
+		return fmt.Sprintf("FieldSentinel(%d)", int(fs))
+	}
+}
+
+// MarshalText marshals the sentinel as its name.
+func (fs FieldSentinel) MarshalText() ([]byte, error) {
+	return []byte(fs.String()), nil
+}
+
+// MarshalJSON marshals FieldNotPresent as {"notPresent":true}, which no decoded value can
+// be mistaken for, and the other sentinels as a string holding their name.
+func (fs FieldSentinel) MarshalJSON() ([]byte, error) {
+	if fs == FieldNotPresent {
+		return []byte(`{"notPresent":true}`), nil
+	}
+	return json.Marshal(fs.String())
+}
+
+// SplitSequence splits off the trailing ",seq=<id>" column that the analyzer adds to raw
+// output with -seq. It returns the line unchanged if there is no such column.
+func SplitSequence(msg []byte) ([]byte, uint8, bool) {
+	idx := bytes.LastIndexByte(msg, ',')
+	if idx == -1 || !bytes.HasPrefix(msg[idx+1:], []byte("seq=")) {
+		return msg, 0, false
+	}
+	seq, err := strconv.ParseUint(strings.TrimSpace(string(msg[idx+len(",seq="):])), 10, 3)
+	if err != nil {
+		return msg, 0, false
+	}
+	return msg[:idx], uint8(seq), true
 }
 
 func findOccurrence(msg []byte, c rune, count int) int {
@@ -71,7 +172,7 @@ func findOccurrence(msg []byte, c rune, count int) int {
 
 // ParseRawFormatPlain parses PLAIN messages.
 func ParseRawFormatPlain(msg []byte, m *RawMessage, showJSON bool, logger *Logger) int {
-	var prio, pgn, dst, src, dataLen, junk, r int
+	var prio, pgn, dst, src, dataLen int
 	var data [8]int
 
 	pIdx := findOccurrence(msg, ',', 1)
@@ -82,23 +183,23 @@ func ParseRawFormatPlain(msg []byte, m *RawMessage, showJSON bool, logger *Logge
 
 	m.Timestamp = string(msg[:pIdx])
 
-	r, _ = fmt.Sscanf(string(msg[pIdx:]),
-		",%d,%d,%d,%d,%d"+
-			",%x,%x,%x,%x,%x,%x,%x,%x,%x",
-		&prio,
-		&pgn,
-		&src,
-		&dst,
-		&dataLen,
-		&data[0],
-		&data[1],
-		&data[2],
-		&data[3],
-		&data[4],
-		&data[5],
-		&data[6],
-		&data[7],
-		&junk)
+	s := NewScanner(msg[pIdx:])
+	r := ScanInts(&s, &prio, &pgn, &src, &dst, &dataLen)
+	if r == 5 {
+		// One more than fits in data, to tell PLAIN from FAST.
+		for ; r < 5+len(data)+1; r++ {
+			if !s.Literal(",") {
+				break
+			}
+			value, ok := s.Hex()
+			if !ok {
+				break
+			}
+			if r < 5+len(data) {
+				data[r-5] = value
+			}
+		}
+	}
 	if r < 5 {
 		//nolint:errcheck
 		logger.Error("Error reading message, scanned %d from %s", r, string(msg))
@@ -124,6 +225,78 @@ func ParseRawFormatPlain(msg []byte, m *RawMessage, showJSON bool, logger *Logge
 	return setParsedValues(m, prio, pgn, dst, src, dataLen)
 }
 
+// ParseRawFormatPlainLenient parses PLAIN messages, but also accepts abbreviated lines
+// that leave out the destination and data length, and lines without a timestamp:
+//
+// timestamp,prio,pgn,src,data...
+// prio,pgn,src,dst,len,data...
+//
+// The destination of an abbreviated line is defaultDst and the data length is the number
+// of data bytes present. A line without a timestamp is stamped with the current time.
+func ParseRawFormatPlainLenient(msg []byte, m *RawMessage, showJSON bool, defaultDst uint8, logger *Logger) int {
+	fields := strings.Split(strings.TrimSpace(string(msg)), ",")
+	if isTimestampless(fields) {
+		stamped := logger.Now().UTC().Format("2006-01-02T15:04:05.000Z") + "," + strings.TrimSpace(string(msg))
+		return ParseRawFormatPlain([]byte(stamped), m, showJSON, logger)
+	}
+	if len(fields) >= 6 {
+		if dataLen, err := strconv.Atoi(fields[5]); err == nil && dataLen == len(fields)-6 {
+			return ParseRawFormatPlain(msg, m, showJSON, logger)
+		}
+	}
+	if len(fields) < 4 {
+		return ParseRawFormatPlain(msg, m, showJSON, logger)
+	}
+
+	prio, err1 := strconv.Atoi(fields[1])
+	pgn, err2 := strconv.Atoi(fields[2])
+	src, err3 := strconv.Atoi(fields[3])
+	if err1 != nil || err2 != nil || err3 != nil {
+		//nolint:errcheck
+		logger.Error("Error reading abbreviated message header from %s", string(msg))
+		if !showJSON {
+			fmt.Fprintf(logger.writer, "%s", string(msg))
+		}
+		return 2
+	}
+
+	data := fields[4:]
+	if len(data) > 8 {
+		// This is not PLAIN format but FAST format
+		return -1
+	}
+	for i, hex := range data {
+		n, err := strconv.ParseUint(hex, 16, 8)
+		if err != nil {
+			//nolint:errcheck
+			logger.Error("Error reading message, data byte %d '%s' from %s", i, hex, string(msg))
+			if !showJSON {
+				fmt.Fprintf(logger.writer, "%s", string(msg))
+			}
+			return 2
+		}
+		m.Data[i] = byte(n)
+	}
+
+	m.Timestamp = fields[0]
+	return setParsedValues(m, prio, pgn, int(defaultDst), src, len(data))
+}
+
+// isTimestampless tells whether the fields are a full PLAIN line without the timestamp.
+// A priority (0-7) where the timestamp should be cannot be a timestamp in any format we
+// read, as those have a date, a time or a fraction.
+func isTimestampless(fields []string) bool {
+	if len(fields) < 5 {
+		return false
+	}
+	prio, err := strconv.Atoi(fields[0])
+	if err != nil || prio < 0 || prio > 7 {
+		return false
+	}
+	dataLen, err := strconv.Atoi(fields[4])
+	return err == nil && dataLen == len(fields)-5
+}
+
 func setParsedValues(m *RawMessage, prio, pgn, dst, src, dataLen int) int {
 	m.Prio = uint8(prio)
 	m.PGN = uint32(pgn)
@@ -136,7 +309,7 @@ func setParsedValues(m *RawMessage, prio, pgn, dst, src, dataLen int) int {
 
 // ParseRawFormatFast parses FAST messages.
 func ParseRawFormatFast(msg []byte, m *RawMessage, showJSON bool, logger *Logger) int {
-	var prio, pgn, dst, src, dataLen, r int
+	var prio, pgn, dst, src, dataLen int
 
 	pIdx := findOccurrence(msg, ',', 1)
 	if pIdx == -1 {
@@ -146,8 +319,8 @@ func ParseRawFormatFast(msg []byte, m *RawMessage, showJSON bool, logger *Logger
 
 	m.Timestamp = string(msg[:pIdx])
 
-	r, _ = fmt.Sscanf(string(msg[pIdx:]), ",%d,%d,%d,%d,%d ", &prio, &pgn, &src, &dst, &dataLen)
-	if r < 5 {
+	s := NewScanner(msg[pIdx:])
+	if r := ScanInts(&s, &prio, &pgn, &src, &dst, &dataLen); r < 5 {
 		//nolint:errcheck
 		logger.Error("Error reading message, scanned %d from %s", r, string(msg))
 		if !showJSON {
@@ -166,28 +339,17 @@ func ParseRawFormatFast(msg []byte, m *RawMessage, showJSON bool, logger *Logger
 		return 2
 	}
 	pIdx += nextIdx
-	for i := 0; i < dataLen; i++ {
-		advancedBy, ok := scanHex(msg[pIdx:], &m.Data[i])
-		if !ok {
-			//nolint:errcheck
-			logger.Error("Error reading message, scanned %d bytes from %s/%s, index %d", pIdx, string(msg), string(msg[pIdx:]), i)
-			if !showJSON {
-				fmt.Fprintf(logger.writer, "%s", string(msg))
-			}
-			return 2
-		}
-		pIdx += advancedBy
-		if i < dataLen && pIdx < len(msg) {
-			if msg[pIdx] != ',' && !unicode.IsSpace(rune(msg[pIdx])) {
-				//nolint:errcheck
-				logger.Error("Error reading message, scanned %d bytes from %s", pIdx, string(msg))
-				if !showJSON {
-					fmt.Fprintf(logger.writer, "%s", string(msg))
-				}
-				return 2
-			}
-			pIdx++
+	n, err := parseHexData(m, msg[pIdx:])
+	if err == nil && n < dataLen {
+		err = fmt.Errorf("only %d of %d data bytes", n, dataLen)
+	}
+	if err != nil {
+		//nolint:errcheck
+		logger.Error("Error reading message data (%v) from %s", err, string(msg))
+		if !showJSON {
+			fmt.Fprintf(logger.writer, "%s", string(msg))
 		}
+		return 2
 	}
 
 	return setParsedValues(m, prio, pgn, dst, src, dataLen)
@@ -206,32 +368,24 @@ func scanNibble(c byte) byte {
 	return 16
 }
 
-func scanHex(p []byte, m *byte) (int, bool) {
-	var hi, lo byte
-
-	if p[0] == 0 || p[1] == 0 {
-		return 0, false
-	}
+var tiden int
 
-	hi = scanNibble(p[0])
-	if hi > 15 {
-		return 0, false
-	}
-	lo = scanNibble(p[1])
-	if lo > 15 {
-		return 0, false
+// fractionToMillis turns the digits after the decimal point of a number of seconds into
+// milliseconds, so "5" is 500 and "050" is 50. Digits beyond milliseconds are dropped.
+func fractionToMillis(fraction string) int {
+	digits := countLeading([]byte(fraction), isDecimalDigit)
+	millis := 0
+	for i := 0; i < 3; i++ {
+		millis *= 10
+		if i < digits {
+			millis += int(fraction[i] - '0')
+		}
 	}
-	*m = hi<<4 | lo
-	/* printf("(b=%02X,p=%p) ", *m, *p); */
-	return 2, true
+	return millis
 }
 
-var tiden int
-
 // ParseRawFormatActisenseN2KAscii parses Actisense N2K ASCII messages.
 func ParseRawFormatActisenseN2KAscii(msg []byte, m *RawMessage, showJSON bool, logger *Logger) int {
-	scanned := 0
-
 	// parse timestamp. Actisense doesn't give us date so let's figure it out ourself
 	splitBySpaces := strings.Split(string(msg), " ")
 	if len(splitBySpaces) == 1 || splitBySpaces[0][0] != 'A' {
@@ -240,24 +394,24 @@ func ParseRawFormatActisenseN2KAscii(msg []byte, m *RawMessage, showJSON bool, l
 		return -1
 	}
 
-	var secs, millis int
-	r, _ := fmt.Sscanf(splitBySpaces[0][1:], "%d.%d", &secs, &millis)
+	var secs int
+	secsField, fraction, _ := strings.Cut(splitBySpaces[0][1:], ".")
+	r, _ := fmt.Sscanf(secsField, "%d", &secs)
 	if r < 1 {
 		return -1
 	}
+	millis := fractionToMillis(fraction)
 
 	if tiden == 0 {
 		tiden = int(logger.Now().Unix()) - secs
 	}
 	now := tiden + secs
 
-	//nolint:gosmopolitan
-	tm := time.Unix(int64(now), 0).Local()
+	tm := time.Unix(int64(now), 0).In(logger.Location())
 	m.Timestamp = tm.Format("2006-01-02T15:04:05")
 	m.Timestamp = fmt.Sprintf("%s,%3.3d", m.Timestamp, millis)
 
 	// parse <SRC><DST><P>
-	scanned += len(splitBySpaces[0]) + 1
 	splitBySpaces = splitBySpaces[1:]
 	if len(splitBySpaces) == 0 {
 		return -1
@@ -269,7 +423,6 @@ func ParseRawFormatActisenseN2KAscii(msg []byte, m *RawMessage, showJSON bool, l
 	m.Src = uint8((n >> 12) & 0xff)
 
 	// parse <PGN>
-	scanned += len(splitBySpaces[0]) + 1
 	splitBySpaces = splitBySpaces[1:]
 	if len(splitBySpaces) == 0 {
 		//nolint:errcheck
@@ -283,35 +436,35 @@ func ParseRawFormatActisenseN2KAscii(msg []byte, m *RawMessage, showJSON bool, l
 	n, _ = strconv.ParseInt(splitBySpaces[0], 16, 64)
 	m.PGN = uint32(n)
 
-	// parse DATA
-	scanned += len(splitBySpaces[0]) + 1
-	p := []byte(strings.Join(splitBySpaces[1:], " "))
-	var i uint8
-	for i = 0; i < FastPacketMaxSize; i++ {
-		if len(p) == 0 || unicode.IsSpace(rune(p[0])) {
-			break
-		}
-		advancedBy, ok := scanHex(p, &m.Data[i])
-		if !ok {
-			//nolint:errcheck
-			logger.Error("Error reading message, scanned %d bytes from %s/%s, index %d", len(msg)-scanned, string(msg), string(p), i)
-			if !showJSON {
-				fmt.Fprintf(logger.writer, "%s", msg)
-			}
-			return 2
+	// parse DATA, which ends at the next space
+	var data []byte
+	if len(splitBySpaces) > 1 {
+		data = []byte(splitBySpaces[1])
+	}
+	dataLen, err := parseHexData(m, data)
+	if err != nil {
+		//nolint:errcheck
+		logger.Error("Error reading message data (%v) from %s", err, string(msg))
+		if !showJSON {
+			fmt.Fprintf(logger.writer, "%s", msg)
 		}
-		scanned += advancedBy
-		p = p[advancedBy:]
+		return 2
 	}
-	m.Len = i
+	m.Len = uint8(dataLen)
 
 	return 0
 }
 
 // ParseRawFormatAirmar parses Airmar messages.
-// Note(UNTESTED): See README.md.
+// <timestamp> - <pgn> [<canid>] <data>
+//
+// # Key
+//
+// <pgn> = PGN number in decimal
+// <canid> = CAN identifier in hex; when present it determines the PGN, priority and addresses
+// <data> = Data bytes in hex, optionally separated by commas or spaces
 func ParseRawFormatAirmar(msg []byte, m *RawMessage, showJSON bool, logger *Logger) int {
-	var prio, pgn, dst, src, dataLen uint
+	var prio, pgn, dst, src uint
 	var id uint
 
 	pIdx := findOccurrence(msg, ' ', 1)
@@ -320,10 +473,11 @@ func ParseRawFormatAirmar(msg []byte, m *RawMessage, showJSON bool, logger *Logg
 	}
 
 	m.Timestamp = string(msg[:pIdx-1])
-	pIdx += 3
+	for pIdx < len(msg) && (msg[pIdx] == ' ' || msg[pIdx] == '-') {
+		pIdx++
+	}
 
-	r, _ := fmt.Sscanf(string(msg[pIdx:]), "%d", &pgn)
-	if r != 1 {
+	reportError := func() int {
 		//nolint:errcheck
 		logger.Error("Error reading message, scanned %d bytes from %s", pIdx, string(msg))
 		if !showJSON {
@@ -331,64 +485,74 @@ func ParseRawFormatAirmar(msg []byte, m *RawMessage, showJSON bool, logger *Logg
 		}
 		return 2
 	}
-	pIdx += len(strconv.FormatUint(uint64(pgn), 10))
-	if msg[pIdx] == ' ' {
-		pIdx++
 
-		r, _ := fmt.Sscanf(string(msg[pIdx:]), "%x", &id)
-		if r != 1 {
-			//nolint:errcheck
-			logger.Error("Error reading message, scanned %d bytes from %s", pIdx, string(msg))
-			if !showJSON {
-				fmt.Fprintf(logger.writer, "%s", msg)
-			}
-			return 2
+	pgnLen := countLeading(msg[pIdx:], isDecimalDigit)
+	if pgnLen == 0 {
+		return reportError()
+	}
+	pgn64, err := strconv.ParseUint(string(msg[pIdx:pIdx+pgnLen]), 10, 32)
+	if err != nil {
+		return reportError()
+	}
+	pgn = uint(pgn64)
+	pIdx += pgnLen
+	if pIdx >= len(msg) || msg[pIdx] != ' ' {
+		return reportError()
+	}
+	pIdx++
+
+	dst = 255
+	// A CAN id is followed by a space, where data bytes are followed by a separator or the end.
+	if idLen := countLeading(msg[pIdx:], isHexDigit); idLen > 2 && pIdx+idLen < len(msg) && msg[pIdx+idLen] == ' ' {
+		id64, err := strconv.ParseUint(string(msg[pIdx:pIdx+idLen]), 16, 32)
+		if err != nil {
+			return reportError()
 		}
-		pIdx += len(strconv.FormatUint(uint64(id), 16))
+		id = uint(id64)
+		getISO11783BitsFromCanID(id, &prio, &pgn, &src, &dst)
+		pIdx += idLen + 1
 	}
-	if msg[pIdx] != ' ' {
+
+	dataLen, err := parseHexData(m, msg[pIdx:])
+	if err != nil {
 		//nolint:errcheck
-		logger.Error("Error reading message, scanned %d bytes from %s", pIdx, string(msg))
+		logger.Error("Error reading message data (%v) from %s", err, string(msg))
 		if !showJSON {
 			fmt.Fprintf(logger.writer, "%s", msg)
 		}
 		return 2
 	}
 
-	getISO11783BitsFromCanID(id, &prio, &pgn, &src, &dst)
+	return setParsedValues(m, int(prio), int(pgn), int(dst), int(src), dataLen)
+}
 
-	pIdx++
-	dataLen = uint(len(msg[pIdx:]) / 2)
-	for i := uint(0); i < dataLen; i++ {
-		advancedBy, ok := scanHex(msg[pIdx:], &m.Data[i])
-		if !ok {
-			//nolint:errcheck
-			logger.Error("Error reading message, scanned %d bytes from %s/%s, index %d", pIdx, string(msg), string(msg[pIdx:]), i)
-			if !showJSON {
-				fmt.Fprintf(logger.writer, "%s", msg)
-			}
-			return 2
-		}
-		pIdx += advancedBy
-		if i < dataLen {
-			if msg[pIdx] != ',' && msg[pIdx] != ' ' {
-				//nolint:errcheck
-				logger.Error("Error reading message, scanned %d bytes from %s", pIdx, string(msg))
-				if !showJSON {
-					fmt.Fprintf(logger.writer, "%s", msg)
-				}
-				return 2
-			}
-			pIdx++
-		}
-	}
+func isDecimalDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
 
-	return setParsedValues(m, int(prio), int(pgn), int(dst), int(src), int(dataLen))
+func isHexDigit(c byte) bool {
+	return scanNibble(c) <= 15
+}
+
+// countLeading returns how many bytes at the start of p satisfy accept.
+func countLeading(p []byte, accept func(c byte) bool) int {
+	n := 0
+	for n < len(p) && accept(p[n]) {
+		n++
+	}
+	return n
 }
 
-// ParseRawFormatChetco parses Chetco messages.
+// ParseRawFormatChetco parses Chetco messages. The format does not carry a destination
+// so all messages are addressed to the broadcast address, 255.
 // Note(UNTESTED): See README.md.
 func ParseRawFormatChetco(msg []byte, m *RawMessage, showJSON bool, logger *Logger) int {
+	return ParseRawFormatChetcoWithDst(msg, m, showJSON, 255, logger)
+}
+
+// ParseRawFormatChetcoWithDst is like ParseRawFormatChetco but addresses all messages to
+// defaultDst.
+func ParseRawFormatChetcoWithDst(msg []byte, m *RawMessage, showJSON bool, defaultDst uint8, logger *Logger) int {
 	var pgn, src uint
 	var tstamp uint
 
@@ -406,28 +570,32 @@ func ParseRawFormatChetco(msg []byte, m *RawMessage, showJSON bool, logger *Logg
 	}
 
 	t := int(tstamp / 1000)
-	//nolint:gosmopolitan
-	tm := time.Unix(int64(t), 0).Local()
+	tm := time.Unix(int64(t), 0).In(logger.Location())
 	m.Timestamp = tm.Format("2006-01-02T15:04:05")
 	m.Timestamp = fmt.Sprintf("%s,%3.3d", m.Timestamp, tstamp%1000)
 
 	pIdx := len("$PCDIN,01FD07,089C77D!,03,") // Fixed length where data bytes start;
 
-	var i uint
-	for i = 0; msg[pIdx] != '*'; i++ {
-		advancedBy, ok := scanHex(msg[pIdx:], &m.Data[i])
-		if !ok {
-			//nolint:errcheck
-			logger.Error("Error reading message, scanned %d bytes from %s/%s, index %d", pIdx, string(msg), string(msg[pIdx:]), i)
-			if !showJSON {
-				fmt.Fprintf(logger.writer, "%s", msg)
-			}
-			return 2
+	dataEnd := bytes.IndexByte(msg, '*')
+	if dataEnd < pIdx {
+		//nolint:errcheck
+		logger.Error("Error reading Chetco message: %s", msg)
+		if !showJSON {
+			fmt.Fprintf(logger.writer, "%s", msg)
 		}
-		pIdx += advancedBy
+		return 2
+	}
+	dataLen, err := parseHexData(m, msg[pIdx:dataEnd])
+	if err != nil {
+		//nolint:errcheck
+		logger.Error("Error reading message data (%v) from %s", err, string(msg))
+		if !showJSON {
+			fmt.Fprintf(logger.writer, "%s", msg)
+		}
+		return 2
 	}
 
-	return setParsedValues(m, 0, int(pgn), 255, int(src), int(i+1))
+	return setParsedValues(m, 0, int(pgn), int(defaultDst), int(src), dataLen)
 }
 
 /*
@@ -485,8 +653,7 @@ func ParseRawFormatGarminCSV(msg []byte, m *RawMessage, showJSON, absolute bool,
 		}
 
 		t = int(tstamp / 1000)
-		//nolint:gosmopolitan
-		tm = time.Unix(int64(t), 0).Local()
+		tm = time.Unix(int64(t), 0).In(logger.Location())
 		m.Timestamp = tm.Format("2006-01-02T15:04:05")
 		m.Timestamp = fmt.Sprintf("%s,%3.3d", m.Timestamp, tstamp%1000)
 
@@ -503,7 +670,9 @@ func ParseRawFormatGarminCSV(msg []byte, m *RawMessage, showJSON, absolute bool,
 	}
 
 	var restOfData string
-	if r, _ := fmt.Sscanf(string(msg[pIdx:]), "%d,%d,%d,%d,%d,0x%s", &src, &dst, &prio, &single, &count, &restOfData); r < 5 {
+	r, _ := fmt.Sscanf(string(msg[pIdx:]), "%d,%d,%d,%d,%d,0x%s", &src, &dst, &prio, &single, &count, &restOfData)
+	dataIdx := bytes.Index(msg[pIdx:], []byte(",0x"))
+	if r < 5 || dataIdx == -1 {
 		//nolint:errcheck
 		logger.Error("Error reading Garmin CSV message: %s", msg)
 		if !showJSON {
@@ -511,23 +680,19 @@ func ParseRawFormatGarminCSV(msg []byte, m *RawMessage, showJSON, absolute bool,
 		}
 		return 3
 	}
-	pIdx += strings.Index(string(msg[pIdx:]), ",0x") + 3
+	pIdx += dataIdx + 1
 
-	var i uint
-	for i = 0; len(msg[pIdx:]) != 0 && i < count; i++ {
-		advancedBy, ok := scanHex(msg[pIdx:], &m.Data[i])
-		if !ok {
-			//nolint:errcheck
-			logger.Error("Error reading message, scanned %d bytes from %s/%s, index %d", pIdx, string(msg), string(msg[pIdx:]), i)
-			if !showJSON {
-				fmt.Fprintf(logger.writer, "%s", msg)
-			}
-			return 2
+	dataLen, err := parseHexData(m, msg[pIdx:])
+	if err != nil {
+		//nolint:errcheck
+		logger.Error("Error reading message data (%v) from %s", err, string(msg))
+		if !showJSON {
+			fmt.Fprintf(logger.writer, "%s", msg)
 		}
-		pIdx += advancedBy
+		return 2
 	}
 
-	return setParsedValues(m, int(prio), int(pgn), int(dst), int(src), int(i+1))
+	return setParsedValues(m, int(prio), int(pgn), int(dst), int(src), Min(dataLen, int(count)))
 }
 
 //nolint:dupword
@@ -538,6 +703,10 @@ Yacht Digital, YDWG-02
 
    Example output: 00:17:55.475 R 0DF50B23 FF FF FF FF FF 00 00 FF
 
+The RAW ASCII output of Actisense gateways (W2K-1, NGX) has the same layout:
+time of day, R or T for received or transmitted, CAN id and data bytes. It is
+parsed and detected as YDWG02 too.
+
    Example usage:
 
 pi@yacht:~/canboat/analyzer $ netcat 192.168.3.2 1457 | analyzer -json
@@ -572,8 +741,7 @@ func ParseRawFormatYDWG02(msg []byte, m *RawMessage, logger *Logger) int {
 		return -1
 	}
 	tiden := logger.Now().Unix()
-	//nolint:gosmopolitan
-	tm := time.Unix(tiden, 0).Local()
+	tm := time.Unix(tiden, 0).In(logger.Location())
 	m.Timestamp = tm.Format("2006-01-02T")
 	m.Timestamp = fmt.Sprintf("%s%s", m.Timestamp, splitBySpaces[0])
 
@@ -627,9 +795,24 @@ func ParseRawFormatYDWG02(msg []byte, m *RawMessage, logger *Logger) int {
 // <pgn_data> = The binary payload of the PGN encoded in Base64.
 func ParseRawFormatNavLink2(msg []byte, m *RawMessage, logger *Logger) int {
 	var pgn, prio, src, dst int
+	s := NewScanner(msg)
+	r := 0
+	if s.Literal("!PDGY") {
+		r = ScanInts(&s, &pgn, &prio, &src, &dst)
+	}
 	var timer float64
-	var pgnData string
-	r, _ := fmt.Sscanf(string(msg), "!PDGY,%d,%d,%d,%d,%f,%s ", &pgn, &prio, &src, &dst, &timer, &pgnData)
+	var pgnData []byte
+	if r == 4 && s.Literal(",") {
+		var ok bool
+		if timer, ok = s.Float(); ok {
+			r++
+			if s.Literal(",") {
+				if pgnData, ok = s.Word(); ok {
+					r++
+				}
+			}
+		}
+	}
 	if r != 6 {
 		//nolint:errcheck
 		logger.Error("wrong amount of fields in message: %d", r)
@@ -638,13 +821,108 @@ func ParseRawFormatNavLink2(msg []byte, m *RawMessage, logger *Logger) int {
 
 	m.Timestamp = strconv.FormatFloat(timer, 'f', 2, 64)
 
-	decoded, err := base64.RawStdEncoding.DecodeString(pgnData)
+	dataLen, err := decodeNavLink2Data(m.Data[:], pgnData)
 	if err != nil {
 		//nolint:errcheck
 		logger.Error("error decoding base64 data: %s", err)
 		return -1
 	}
-	copy(m.Data[:], decoded)
 
-	return setParsedValues(m, prio, pgn, dst, src, len(decoded))
+	return setParsedValues(m, prio, pgn, dst, src, dataLen)
+}
+
+// decodeNavLink2Data decodes the base64 payload into data and returns its length, which
+// may be more than what fits in data.
+func decodeNavLink2Data(data, pgnData []byte) (int, error) {
+	if base64.RawStdEncoding.DecodedLen(len(pgnData)) <= len(data) {
+		return base64.RawStdEncoding.Decode(data, pgnData)
+	}
+	decoded := make([]byte, base64.RawStdEncoding.DecodedLen(len(pgnData)))
+	n, err := base64.RawStdEncoding.Decode(decoded, pgnData)
+	copy(data, decoded[:n])
+	return n, err
+}
+
+// ParseRawFormatMiniPlex parses ShipModul MiniPlex NMEA 0183 encapsulated messages.
+// $MXPGN,<pgn>,<attr>,<data>*hh
+//
+// # Key
+//
+// <pgn> = PGN number in hex, 6 digits
+//
+// <attr> = 16 bit attribute word in hex. Bit 15 is set for messages sent by the gateway,
+// bits 14-12 are the priority, bits 11-8 the data length and bits 7-0 the source address
+// (or the destination address for sent messages).
+//
+// <data> = The data bytes in hex, most significant (last) byte first.
+//
+// hh = NMEA 0183 checksum, the XOR of all characters between '$' and '*'.
+//
+// Received messages do not carry a destination so they are addressed to defaultDst.
+func ParseRawFormatMiniPlex(msg []byte, m *RawMessage, showJSON bool, defaultDst uint8, logger *Logger) int {
+	line := string(msg)
+	if !strings.HasPrefix(line, "$MXPGN,") {
+		return -1
+	}
+
+	star := strings.IndexByte(line, '*')
+	if star == -1 || len(line) < star+3 {
+		//nolint:errcheck
+		logger.Error("MiniPlex message without checksum: %s\n", msg)
+		return 2
+	}
+	var checksum byte
+	for i := 1; i < star; i++ {
+		checksum ^= line[i]
+	}
+	expected, err := strconv.ParseUint(line[star+1:star+3], 16, 8)
+	if err != nil || byte(expected) != checksum {
+		//nolint:errcheck
+		logger.Error("MiniPlex message checksum mismatch, calculated %02X: %s\n", checksum, msg)
+		if !showJSON {
+			fmt.Fprintf(logger.writer, "%s", msg)
+		}
+		return 2
+	}
+
+	parts := strings.Split(line[len("$MXPGN,"):star], ",")
+	if len(parts) != 3 {
+		//nolint:errcheck
+		logger.Error("wrong amount of fields in message: %d\n", len(parts))
+		return 2
+	}
+	pgn, err1 := strconv.ParseUint(parts[0], 16, 32)
+	attr, err2 := strconv.ParseUint(parts[1], 16, 16)
+	if err1 != nil || err2 != nil {
+		//nolint:errcheck
+		logger.Error("Error reading MiniPlex message header: %s\n", msg)
+		return 2
+	}
+
+	dataLen := int((attr >> 8) & 0x0f)
+	hexData := parts[2]
+	if dataLen > 8 || len(hexData) != dataLen*2 {
+		//nolint:errcheck
+		logger.Error("MiniPlex data length %d does not match data '%s'\n", dataLen, hexData)
+		return 2
+	}
+	for i := 0; i < dataLen; i++ {
+		pos := len(hexData) - 2*(i+1)
+		n, err := strconv.ParseUint(hexData[pos:pos+2], 16, 8)
+		if err != nil {
+			//nolint:errcheck
+			logger.Error("Error reading MiniPlex data '%s'\n", hexData)
+			return 2
+		}
+		m.Data[i] = byte(n)
+	}
+
+	m.Timestamp = logger.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+
+	prio := int((attr >> 12) & 0x07)
+	src, dst := int(attr&0xff), int(defaultDst)
+	if attr&0x8000 != 0 {
+		src, dst = 0, int(attr&0xff)
+	}
+	return setParsedValues(m, prio, int(pgn), dst, src, dataLen)
 }
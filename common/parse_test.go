@@ -0,0 +1,158 @@
+package common
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestParseRawFormatActisenseN2KAsciiFraction(t *testing.T) {
+	logger := NewLogger(io.Discard)
+	for _, tc := range []struct {
+		secs   string
+		millis string
+	}{
+		{"A000123", ",000"},
+		{"A000123.5", ",500"},
+		{"A000123.05", ",050"},
+		{"A000123.050", ",050"},
+		{"A000123.0509", ",050"},
+	} {
+		t.Run(tc.secs, func(t *testing.T) {
+			var m RawMessage
+			line := tc.secs + " 0EFF2 1F112 00FFFF7FFF7FFFFD\n"
+			test.That(t, ParseRawFormatActisenseN2KAscii([]byte(line), &m, true, logger), test.ShouldEqual, 0)
+			test.That(t, m.Timestamp, test.ShouldEndWith, tc.millis)
+			test.That(t, m.PGN, test.ShouldEqual, 127250)
+			test.That(t, m.Src, test.ShouldEqual, 0x0e)
+		})
+	}
+}
+
+func TestParseRawFormatPlain(t *testing.T) {
+	logger := NewLogger(io.Discard)
+	for _, tc := range []struct {
+		line   string
+		result int
+		data   []byte
+	}{
+		{"2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff\n", 0,
+			[]byte{0x00, 0x51, 0xbf, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"2022-11-14T01:47:30.890Z,2,127251,14,255,3,0a,b,c\n", 0, []byte{0x0a, 0x0b, 0x0c}},
+		// Missing data bytes read as zero.
+		{"2022-11-14T01:47:30.890Z,2,127251,14,255,3,0a\n", 0, []byte{0x0a, 0x00, 0x00}},
+		{"2022-11-14T01:47:30.890Z,2,127251,14,255,9,00,01,02,03,04,05,06,07,08\n", -1, nil},
+		{"2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,01,02,03,04,05,06,07,08\n", -1, nil},
+		{"2022-11-14T01:47:30.890Z,2,127251,14\n", 2, nil},
+		{"no commas\n", 1, nil},
+	} {
+		t.Run(tc.line, func(t *testing.T) {
+			var m RawMessage
+			test.That(t, ParseRawFormatPlain([]byte(tc.line), &m, true, logger), test.ShouldEqual, tc.result)
+			if tc.result == 0 {
+				test.That(t, m.Timestamp, test.ShouldEqual, "2022-11-14T01:47:30.890Z")
+				test.That(t, m.Prio, test.ShouldEqual, 2)
+				test.That(t, m.PGN, test.ShouldEqual, 127251)
+				test.That(t, m.Src, test.ShouldEqual, 14)
+				test.That(t, m.Dst, test.ShouldEqual, 255)
+				test.That(t, m.Data[:m.Len], test.ShouldResemble, tc.data)
+			}
+		})
+	}
+}
+
+func TestParseRawFormatChetco(t *testing.T) {
+	logger := NewLogger(io.Discard)
+	line := []byte("$PCDIN,01F119,05265C7B,0F,2AAF00D1067414FF*59\n")
+
+	var m RawMessage
+	test.That(t, ParseRawFormatChetco(line, &m, true, logger), test.ShouldEqual, 0)
+	test.That(t, m.PGN, test.ShouldEqual, 127257)
+	test.That(t, m.Src, test.ShouldEqual, 0x0f)
+	test.That(t, m.Dst, test.ShouldEqual, 255)
+
+	m = RawMessage{}
+	test.That(t, ParseRawFormatChetcoWithDst(line, &m, true, 42, logger), test.ShouldEqual, 0)
+	test.That(t, m.PGN, test.ShouldEqual, 127257)
+	test.That(t, m.Dst, test.ShouldEqual, 42)
+}
+
+func TestParseRawFormatNavLink2(t *testing.T) {
+	logger := NewLogger(io.Discard)
+	var m RawMessage
+	test.That(t, ParseRawFormatNavLink2([]byte("!PDGY,127251,2,14,255,1234.56,AFG///////8\n"), &m, logger), test.ShouldEqual, 0)
+	test.That(t, m.Timestamp, test.ShouldEqual, "1234.56")
+	test.That(t, m.PGN, test.ShouldEqual, 127251)
+	test.That(t, m.Prio, test.ShouldEqual, 2)
+	test.That(t, m.Src, test.ShouldEqual, 14)
+	test.That(t, m.Dst, test.ShouldEqual, 255)
+	test.That(t, m.Data[:m.Len], test.ShouldResemble, []byte{0x00, 0x51, 0xbf, 0xff, 0xff, 0xff, 0xff, 0xff})
+
+	test.That(t, ParseRawFormatNavLink2([]byte("!PDGY,127251,2,14\n"), &m, logger), test.ShouldEqual, -1)
+	test.That(t, ParseRawFormatNavLink2([]byte("!PDGY,127251,2,14,255,1234.56,!!\n"), &m, logger), test.ShouldEqual, -1)
+}
+
+func BenchmarkParseRawFormatPlain(b *testing.B) {
+	line := []byte("2022-11-14T01:47:30.890Z,2,127251,14,255,8,00,51,bf,ff,ff,ff,ff,ff\n")
+	logger := NewLogger(io.Discard)
+	var m RawMessage
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseRawFormatPlain(line, &m, true, logger)
+	}
+}
+
+func BenchmarkParseRawFormatFast(b *testing.B) {
+	line := []byte("2022-11-14T01:47:30.890Z,6,127506,17,255,11,01,00,00,57,5f,7d,00,05,00,b4,00\n")
+	logger := NewLogger(io.Discard)
+	var m RawMessage
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseRawFormatFast(line, &m, true, logger)
+	}
+}
+
+func BenchmarkParseRawFormatNavLink2(b *testing.B) {
+	line := []byte("!PDGY,127251,2,14,255,1234.56,AFG///////8\n")
+	logger := NewLogger(io.Discard)
+	var m RawMessage
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseRawFormatNavLink2(line, &m, logger)
+	}
+}
+
+func TestRawMessageCanID(t *testing.T) {
+	logger := NewLogger(io.Discard)
+	for _, tc := range []struct {
+		line  string
+		canID uint32
+		pgn   uint32
+		dst   uint8
+	}{
+		// PDU1: ISO Request from 3 to 0x23
+		{"17:33:21.107 R 18EA2303 00 EE 00\n", 0x18ea2303, 59904, 0x23},
+		// PDU2: Position, Rapid Update from 3 to all
+		{"17:33:21.107 R 09F80103 2F 30 70 00 2F 30 70 00\n", 0x09f80103, 129025, 255},
+	} {
+		t.Run(tc.line, func(t *testing.T) {
+			var m RawMessage
+			test.That(t, ParseRawFormatYDWG02([]byte(tc.line), &m, logger), test.ShouldEqual, 0)
+			test.That(t, m.PGN, test.ShouldEqual, tc.pgn)
+			test.That(t, m.Dst, test.ShouldEqual, tc.dst)
+			test.That(t, m.CanID(), test.ShouldEqual, tc.canID)
+		})
+	}
+}
+
+func TestFieldSentinelJSON(t *testing.T) {
+	data, err := json.Marshal(map[string]interface{}{
+		"Missing": FieldNotPresent,
+		"Name":    "Not present",
+		"Speed":   FieldUnknown,
+	})
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, string(data), test.ShouldEqual, `{"Missing":{"notPresent":true},"Name":"Not present","Speed":"Unknown"}`)
+}
@@ -0,0 +1,139 @@
+package common
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A raw log is a sequence of records, one per RawMessage. Each record is
+//
+//	uint16 length of the rest of the record, little endian
+//	uint8  length of the timestamp, followed by the timestamp
+//	uint32 29 bit CAN ID, little endian
+//	uint8  fast-packet sequence id
+//	uint8  length of the data, followed by the data
+//
+// The timestamp is kept as text, so messages read back are identical to the ones
+// written, except that PDU2 PGNs always have the global destination like on the bus.
+const rawLogFixedSize = 1 + 4 + 1 + 1
+
+// ErrRawLogCorrupt is returned by RawLogReader.Read for records that cannot be decoded.
+var ErrRawLogCorrupt = errors.New("corrupt raw log record")
+
+// A RawLogWriter writes raw messages as records of a compact binary log.
+type RawLogWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewRawLogWriter returns a RawLogWriter writing to w. Each record is written with a
+// single call to w.Write.
+func NewRawLogWriter(w io.Writer) *RawLogWriter {
+	return &RawLogWriter{w: w}
+}
+
+// Write writes m as one record.
+func (lw *RawLogWriter) Write(m *RawMessage) error {
+	if len(m.Timestamp) > 0xff {
+		return fmt.Errorf("timestamp %q is too long for a raw log", m.Timestamp)
+	}
+	if int(m.Len) > len(m.Data) {
+		return fmt.Errorf("message length %d is more than %d", m.Len, len(m.Data))
+	}
+
+	size := rawLogFixedSize + len(m.Timestamp) + int(m.Len)
+	buf := lw.buf[:0]
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(size))
+	buf = append(buf, byte(len(m.Timestamp)))
+	buf = append(buf, m.Timestamp...)
+	canID := getCanIDFromISO11783Bits(uint(m.Prio), uint(m.PGN), uint(m.Src), uint(m.Dst))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(canID))
+	buf = append(buf, m.Sequence, m.Len)
+	buf = append(buf, m.Data[:m.Len]...)
+	lw.buf = buf
+
+	_, err := lw.w.Write(buf)
+	return err
+}
+
+// A RawLogReader reads raw messages back from a log written by a RawLogWriter.
+type RawLogReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// NewRawLogReader returns a RawLogReader reading from r.
+func NewRawLogReader(r io.Reader) *RawLogReader {
+	return &RawLogReader{r: bufio.NewReader(r)}
+}
+
+// Read reads the next record into m. It returns io.EOF at the end of the log and
+// io.ErrUnexpectedEOF when the log ends inside a record.
+func (lr *RawLogReader) Read(m *RawMessage) error {
+	var sizeBuf [2]byte
+	if _, err := io.ReadFull(lr.r, sizeBuf[:]); err != nil {
+		return err
+	}
+	size := int(binary.LittleEndian.Uint16(sizeBuf[:]))
+	if size < rawLogFixedSize {
+		return ErrRawLogCorrupt
+	}
+	if cap(lr.buf) < size {
+		lr.buf = make([]byte, size)
+	}
+	rec := lr.buf[:size]
+	if _, err := io.ReadFull(lr.r, rec); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	tsLen := int(rec[0])
+	if rawLogFixedSize+tsLen > size {
+		return ErrRawLogCorrupt
+	}
+	timestamp := rec[1 : 1+tsLen]
+	rec = rec[1+tsLen:]
+	canID := binary.LittleEndian.Uint32(rec)
+	seq := rec[4]
+	dataLen := int(rec[5])
+	data := rec[6:]
+	if dataLen != len(data) || dataLen > len(m.Data) {
+		return ErrRawLogCorrupt
+	}
+
+	var prio, pgn, src, dst uint
+	getISO11783BitsFromCanID(uint(canID), &prio, &pgn, &src, &dst)
+	*m = RawMessage{
+		Timestamp: string(timestamp),
+		Prio:      uint8(prio),
+		PGN:       uint32(pgn),
+		Dst:       uint8(dst),
+		Src:       uint8(src),
+		Len:       uint8(dataLen),
+		Sequence:  seq,
+	}
+	copy(m.Data[:], data)
+	return nil
+}
@@ -0,0 +1,67 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestRawLogRoundTrip(t *testing.T) {
+	newMsg := func(ts string, prio uint8, pgn uint32, src, dst, seq uint8, data ...byte) RawMessage {
+		m := RawMessage{Timestamp: ts, Prio: prio, PGN: pgn, Src: src, Dst: dst, Len: uint8(len(data)), Sequence: seq}
+		copy(m.Data[:], data)
+		return m
+	}
+	fastPacket := make([]byte, FastPacketMaxSize)
+	for i := range fastPacket {
+		fastPacket[i] = byte(i)
+	}
+	msgs := []RawMessage{
+		newMsg("2022-11-14T01:47:30.890Z", 2, 129025, 1, 255, 0, 0xd5, 0x6b, 0x70, 0x1f, 0x8e, 0xa4, 0x16, 0x03),
+		newMsg("2022-09-28-11:36:59.668", 6, 59904, 0, 3, 0, 0x14, 0xf0, 0x01),
+		newMsg("25631.18", 3, 129029, 7, 255, 5, fastPacket...),
+		newMsg("", 7, 130842, 35, 255, 0),
+	}
+
+	var buf bytes.Buffer
+	w := NewRawLogWriter(&buf)
+	for i := range msgs {
+		test.That(t, w.Write(&msgs[i]), test.ShouldBeNil)
+	}
+	log := buf.Bytes()
+
+	r := NewRawLogReader(bytes.NewReader(log))
+	for _, expected := range msgs {
+		var m RawMessage
+		test.That(t, r.Read(&m), test.ShouldBeNil)
+		test.That(t, m, test.ShouldResemble, expected)
+	}
+	var m RawMessage
+	test.That(t, r.Read(&m), test.ShouldEqual, io.EOF)
+
+	t.Run("truncated", func(t *testing.T) {
+		r := NewRawLogReader(bytes.NewReader(log[:len(log)-1]))
+		var err error
+		for err == nil {
+			err = r.Read(&m)
+		}
+		test.That(t, errors.Is(err, io.ErrUnexpectedEOF), test.ShouldBeTrue)
+	})
+
+	t.Run("corrupt", func(t *testing.T) {
+		r := NewRawLogReader(bytes.NewReader([]byte{3, 0, 9, 0, 0}))
+		test.That(t, r.Read(&m), test.ShouldEqual, ErrRawLogCorrupt)
+	})
+
+	t.Run("PDU2 destination", func(t *testing.T) {
+		var buf bytes.Buffer
+		sent := newMsg("0.1", 2, 127250, 1, 5, 0, 0xff)
+		test.That(t, NewRawLogWriter(&buf).Write(&sent), test.ShouldBeNil)
+		test.That(t, NewRawLogReader(&buf).Read(&m), test.ShouldBeNil)
+		test.That(t, m.PGN, test.ShouldEqual, 127250)
+		test.That(t, m.Dst, test.ShouldEqual, 255)
+	})
+}
@@ -0,0 +1,156 @@
+package common
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "strconv"
+
+// A Scanner reads numbers, words and literals from the start of a line without
+// allocating. It follows the rules fmt.Sscanf uses for the verbs of the same name, so
+// numbers and words may be preceded by spaces but literals must match exactly, and it
+// never reads past a newline.
+type Scanner struct {
+	data []byte
+	pos  int
+}
+
+// NewScanner returns a Scanner reading data.
+func NewScanner(data []byte) Scanner {
+	return Scanner{data: data}
+}
+
+// Literal reads lit if the data continues with it.
+func (s *Scanner) Literal(lit string) bool {
+	if len(s.data)-s.pos < len(lit) || string(s.data[s.pos:s.pos+len(lit)]) != lit {
+		return false
+	}
+	s.pos += len(lit)
+	return true
+}
+
+// Int reads a decimal number, like %d.
+func (s *Scanner) Int() (int, bool) {
+	return s.number(10)
+}
+
+// Hex reads a hexadecimal number, like %x.
+func (s *Scanner) Hex() (int, bool) {
+	return s.number(16)
+}
+
+// Float reads a decimal floating point number, like %f.
+func (s *Scanner) Float() (float64, bool) {
+	s.skipSpaces()
+	start := s.pos
+	s.acceptSign()
+	digits := s.acceptDigits(10)
+	if s.pos < len(s.data) && s.data[s.pos] == '.' {
+		s.pos++
+		digits += s.acceptDigits(10)
+	}
+	if digits == 0 {
+		s.pos = start
+		return 0, false
+	}
+	if s.pos < len(s.data) && (s.data[s.pos] == 'e' || s.data[s.pos] == 'E') {
+		s.pos++
+		s.acceptSign()
+		if s.acceptDigits(10) == 0 {
+			s.pos = start
+			return 0, false
+		}
+	}
+	value, err := strconv.ParseFloat(string(s.data[start:s.pos]), 64)
+	if err != nil {
+		s.pos = start
+		return 0, false
+	}
+	return value, true
+}
+
+// Word reads everything up to the next space, like %s.
+func (s *Scanner) Word() ([]byte, bool) {
+	s.skipSpaces()
+	start := s.pos
+	for s.pos < len(s.data) && !isScanSpace(s.data[s.pos]) && s.data[s.pos] != '\n' {
+		s.pos++
+	}
+	return s.data[start:s.pos], s.pos > start
+}
+
+// ScanInts reads comma separated decimal numbers into values, like fmt.Sscanf with a
+// format of ",%d,%d...", and returns how many it read.
+func ScanInts(s *Scanner, values ...*int) int {
+	for i, value := range values {
+		if !s.Literal(",") {
+			return i
+		}
+		v, ok := s.Int()
+		if !ok {
+			return i
+		}
+		*value = v
+	}
+	return len(values)
+}
+
+func (s *Scanner) number(base int) (int, bool) {
+	s.skipSpaces()
+	start := s.pos
+	negative := s.acceptSign()
+	digitsStart := s.pos
+	if s.acceptDigits(base) == 0 {
+		s.pos = start
+		return 0, false
+	}
+	value := 0
+	for _, c := range s.data[digitsStart:s.pos] {
+		value = value*base + int(scanNibble(c))
+	}
+	if negative {
+		value = -value
+	}
+	return value, true
+}
+
+// acceptSign reads an optional sign and tells whether it was a minus.
+func (s *Scanner) acceptSign() bool {
+	if s.pos < len(s.data) && (s.data[s.pos] == '+' || s.data[s.pos] == '-') {
+		s.pos++
+		return s.data[s.pos-1] == '-'
+	}
+	return false
+}
+
+func (s *Scanner) acceptDigits(base int) int {
+	start := s.pos
+	for s.pos < len(s.data) && int(scanNibble(s.data[s.pos])) < base {
+		s.pos++
+	}
+	return s.pos - start
+}
+
+func (s *Scanner) skipSpaces() {
+	for s.pos < len(s.data) && isScanSpace(s.data[s.pos]) {
+		s.pos++
+	}
+}
+
+// isScanSpace tells whether c is a space that fmt.Sscanf skips before a verb.
+func isScanSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\v' || c == '\f'
+}
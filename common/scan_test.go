@@ -0,0 +1,65 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"go.viam.com/test"
+)
+
+func TestScannerMatchesSscanf(t *testing.T) {
+	for _, input := range []string{
+		",2,127251,14,255,8",
+		", 2, 127251,14,255,8",
+		",+2,-127251,14,255,8",
+		",2,,14,255,8",
+		",2;127251,14,255,8",
+		",2,127251\n,14,255,8",
+		",2,127251,14",
+		",x,127251,14,255,8",
+		"",
+	} {
+		t.Run(input, func(t *testing.T) {
+			var want, got [5]int
+			wantR, _ := fmt.Sscanf(input, ",%d,%d,%d,%d,%d", &want[0], &want[1], &want[2], &want[3], &want[4])
+			s := NewScanner([]byte(input))
+			gotR := ScanInts(&s, &got[0], &got[1], &got[2], &got[3], &got[4])
+			test.That(t, gotR, test.ShouldEqual, wantR)
+			test.That(t, got, test.ShouldResemble, want)
+		})
+	}
+
+	for _, input := range []string{"ff", "0A", " 7f,", "-1f", "g1", "1fz"} {
+		t.Run("hex "+input, func(t *testing.T) {
+			var want int
+			wantR, _ := fmt.Sscanf(input, "%x", &want)
+			s := NewScanner([]byte(input))
+			got, ok := s.Hex()
+			test.That(t, ok, test.ShouldEqual, wantR == 1)
+			test.That(t, got, test.ShouldEqual, want)
+		})
+	}
+
+	for _, input := range []string{"12.5,", "3,", ".5 ", "-1e3,", "1e,", "x"} {
+		t.Run("float "+input, func(t *testing.T) {
+			var want float64
+			wantR, _ := fmt.Sscanf(input, "%f", &want)
+			s := NewScanner([]byte(input))
+			got, ok := s.Float()
+			test.That(t, ok, test.ShouldEqual, wantR == 1)
+			test.That(t, got, test.ShouldEqual, want)
+		})
+	}
+}
+
+func TestScannerWord(t *testing.T) {
+	s := NewScanner([]byte(" AQID rest\n"))
+	word, ok := s.Word()
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, string(word), test.ShouldEqual, "AQID")
+	word, ok = s.Word()
+	test.That(t, ok, test.ShouldBeTrue)
+	test.That(t, string(word), test.ShouldEqual, "rest")
+	_, ok = s.Word()
+	test.That(t, ok, test.ShouldBeFalse)
+}
@@ -0,0 +1,79 @@
+// Package pgns holds typed structs for a selection of PGNs, as an alternative to the
+// field maps of the analyzer. The structs are generated by cmd/pgngen and decode through
+// the analyzer, so they hold the same values as its field maps.
+package pgns
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:generate go run ../cmd/pgngen -o pgns_gen.go
+
+import (
+	"io"
+	"sync"
+
+	"github.com/erh/gonmea/analyzer"
+	"github.com/erh/gonmea/common"
+)
+
+var (
+	decodeMu  sync.Mutex
+	decodeAna *analyzer.Analyzer
+)
+
+// decode decodes the payload of a PGN into its field map.
+func decode(pgn uint32, data []byte) (map[string]interface{}, error) {
+	decodeMu.Lock()
+	defer decodeMu.Unlock()
+
+	if decodeAna == nil {
+		ana, err := analyzer.NewAnalyzer(analyzer.NewConfigForLibrary(common.NewLogger(io.Discard)))
+		if err != nil {
+			return nil, err
+		}
+		decodeAna = ana
+	}
+
+	rawMsg := &common.RawMessage{PGN: pgn, Dst: 255}
+	rawMsg.Len = uint8(copy(rawMsg.Data[:], data))
+	msg, err := decodeAna.ConvertRawMessageAs(rawMsg, pgn)
+	if err != nil {
+		return nil, err
+	}
+	return msg.Fields, nil
+}
+
+func intField(fields map[string]interface{}, name string) *int {
+	if value, ok := fields[name].(int); ok {
+		return &value
+	}
+	return nil
+}
+
+func floatField(fields map[string]interface{}, name string) *float64 {
+	if value, ok := fields[name].(float64); ok {
+		return &value
+	}
+	return nil
+}
+
+func stringField(fields map[string]interface{}, name string) *string {
+	if value, ok := fields[name].(string); ok {
+		return &value
+	}
+	return nil
+}
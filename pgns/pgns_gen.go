@@ -0,0 +1,356 @@
+// Code generated by pgngen; DO NOT EDIT.
+
+package pgns
+
+// Originally from https://github.com/canboat/canboat (Apache License, Version 2.0)
+// (C) 2009-2023, Kees Verruijt, Harlingen, The Netherlands.
+
+// This file is part of CANboat.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Rudder is PGN 127245, Rudder. Fields that are not available are nil.
+type Rudder struct {
+	Instance       *int
+	DirectionOrder *string
+	AngleOrder     *float64 // deg
+	Position       *float64 // deg
+}
+
+// PGN returns 127245.
+func (*Rudder) PGN() uint32 {
+	return 127245
+}
+
+// Unmarshal decodes the payload of a PGN 127245 message into m.
+func (m *Rudder) Unmarshal(data []byte) error {
+	fields, err := decode(127245, data)
+	if err != nil {
+		return err
+	}
+	*m = Rudder{
+		Instance:       intField(fields, "Instance"),
+		DirectionOrder: stringField(fields, "Direction Order"),
+		AngleOrder:     floatField(fields, "Angle Order"),
+		Position:       floatField(fields, "Position"),
+	}
+	return nil
+}
+
+// VesselHeading is PGN 127250, Vessel Heading. Fields that are not available are nil.
+type VesselHeading struct {
+	SID       *int
+	Heading   *float64 // deg
+	Deviation *float64 // deg
+	Variation *float64 // deg
+	Reference *string
+}
+
+// PGN returns 127250.
+func (*VesselHeading) PGN() uint32 {
+	return 127250
+}
+
+// Unmarshal decodes the payload of a PGN 127250 message into m.
+func (m *VesselHeading) Unmarshal(data []byte) error {
+	fields, err := decode(127250, data)
+	if err != nil {
+		return err
+	}
+	*m = VesselHeading{
+		SID:       intField(fields, "SID"),
+		Heading:   floatField(fields, "Heading"),
+		Deviation: floatField(fields, "Deviation"),
+		Variation: floatField(fields, "Variation"),
+		Reference: stringField(fields, "Reference"),
+	}
+	return nil
+}
+
+// RateOfTurn is PGN 127251, Rate of Turn. Fields that are not available are nil.
+type RateOfTurn struct {
+	SID  *int
+	Rate *float64 // deg/s
+}
+
+// PGN returns 127251.
+func (*RateOfTurn) PGN() uint32 {
+	return 127251
+}
+
+// Unmarshal decodes the payload of a PGN 127251 message into m.
+func (m *RateOfTurn) Unmarshal(data []byte) error {
+	fields, err := decode(127251, data)
+	if err != nil {
+		return err
+	}
+	*m = RateOfTurn{
+		SID:  intField(fields, "SID"),
+		Rate: floatField(fields, "Rate"),
+	}
+	return nil
+}
+
+// Attitude is PGN 127257, Attitude. Fields that are not available are nil.
+type Attitude struct {
+	SID   *int
+	Yaw   *float64 // deg
+	Pitch *float64 // deg
+	Roll  *float64 // deg
+}
+
+// PGN returns 127257.
+func (*Attitude) PGN() uint32 {
+	return 127257
+}
+
+// Unmarshal decodes the payload of a PGN 127257 message into m.
+func (m *Attitude) Unmarshal(data []byte) error {
+	fields, err := decode(127257, data)
+	if err != nil {
+		return err
+	}
+	*m = Attitude{
+		SID:   intField(fields, "SID"),
+		Yaw:   floatField(fields, "Yaw"),
+		Pitch: floatField(fields, "Pitch"),
+		Roll:  floatField(fields, "Roll"),
+	}
+	return nil
+}
+
+// EngineParametersRapidUpdate is PGN 127488, Engine Parameters, Rapid Update. Fields that are not available are nil.
+type EngineParametersRapidUpdate struct {
+	Instance      *string
+	Speed         *float64 // rpm
+	BoostPressure *float64 // bar
+	TiltTrim      *int
+}
+
+// PGN returns 127488.
+func (*EngineParametersRapidUpdate) PGN() uint32 {
+	return 127488
+}
+
+// Unmarshal decodes the payload of a PGN 127488 message into m.
+func (m *EngineParametersRapidUpdate) Unmarshal(data []byte) error {
+	fields, err := decode(127488, data)
+	if err != nil {
+		return err
+	}
+	*m = EngineParametersRapidUpdate{
+		Instance:      stringField(fields, "Instance"),
+		Speed:         floatField(fields, "Speed"),
+		BoostPressure: floatField(fields, "Boost Pressure"),
+		TiltTrim:      intField(fields, "Tilt/Trim"),
+	}
+	return nil
+}
+
+// BatteryStatus is PGN 127508, Battery Status. Fields that are not available are nil.
+type BatteryStatus struct {
+	Instance    *int
+	Voltage     *float64 // V
+	Current     *float64 // A
+	Temperature *float64 // C
+	SID         *int
+}
+
+// PGN returns 127508.
+func (*BatteryStatus) PGN() uint32 {
+	return 127508
+}
+
+// Unmarshal decodes the payload of a PGN 127508 message into m.
+func (m *BatteryStatus) Unmarshal(data []byte) error {
+	fields, err := decode(127508, data)
+	if err != nil {
+		return err
+	}
+	*m = BatteryStatus{
+		Instance:    intField(fields, "Instance"),
+		Voltage:     floatField(fields, "Voltage"),
+		Current:     floatField(fields, "Current"),
+		Temperature: floatField(fields, "Temperature"),
+		SID:         intField(fields, "SID"),
+	}
+	return nil
+}
+
+// Speed is PGN 128259, Speed. Fields that are not available are nil.
+type Speed struct {
+	SID                      *int
+	SpeedWaterReferenced     *float64 // m/s
+	SpeedGroundReferenced    *float64 // m/s
+	SpeedWaterReferencedType *string
+	SpeedDirection           *int
+}
+
+// PGN returns 128259.
+func (*Speed) PGN() uint32 {
+	return 128259
+}
+
+// Unmarshal decodes the payload of a PGN 128259 message into m.
+func (m *Speed) Unmarshal(data []byte) error {
+	fields, err := decode(128259, data)
+	if err != nil {
+		return err
+	}
+	*m = Speed{
+		SID:                      intField(fields, "SID"),
+		SpeedWaterReferenced:     floatField(fields, "Speed Water Referenced"),
+		SpeedGroundReferenced:    floatField(fields, "Speed Ground Referenced"),
+		SpeedWaterReferencedType: stringField(fields, "Speed Water Referenced Type"),
+		SpeedDirection:           intField(fields, "Speed Direction"),
+	}
+	return nil
+}
+
+// WaterDepth is PGN 128267, Water Depth. Fields that are not available are nil.
+type WaterDepth struct {
+	SID    *int
+	Depth  *float64 // m
+	Offset *float64 // m
+	Range  *float64 // m
+}
+
+// PGN returns 128267.
+func (*WaterDepth) PGN() uint32 {
+	return 128267
+}
+
+// Unmarshal decodes the payload of a PGN 128267 message into m.
+func (m *WaterDepth) Unmarshal(data []byte) error {
+	fields, err := decode(128267, data)
+	if err != nil {
+		return err
+	}
+	*m = WaterDepth{
+		SID:    intField(fields, "SID"),
+		Depth:  floatField(fields, "Depth"),
+		Offset: floatField(fields, "Offset"),
+		Range:  floatField(fields, "Range"),
+	}
+	return nil
+}
+
+// PositionRapidUpdate is PGN 129025, Position, Rapid Update. Fields that are not available are nil.
+type PositionRapidUpdate struct {
+	Latitude  *float64 // deg
+	Longitude *float64 // deg
+}
+
+// PGN returns 129025.
+func (*PositionRapidUpdate) PGN() uint32 {
+	return 129025
+}
+
+// Unmarshal decodes the payload of a PGN 129025 message into m.
+func (m *PositionRapidUpdate) Unmarshal(data []byte) error {
+	fields, err := decode(129025, data)
+	if err != nil {
+		return err
+	}
+	*m = PositionRapidUpdate{
+		Latitude:  floatField(fields, "Latitude"),
+		Longitude: floatField(fields, "Longitude"),
+	}
+	return nil
+}
+
+// COGSOGRapidUpdate is PGN 129026, COG & SOG, Rapid Update. Fields that are not available are nil.
+type COGSOGRapidUpdate struct {
+	SID          *int
+	COGReference *string
+	COG          *float64 // deg
+	SOG          *float64 // m/s
+}
+
+// PGN returns 129026.
+func (*COGSOGRapidUpdate) PGN() uint32 {
+	return 129026
+}
+
+// Unmarshal decodes the payload of a PGN 129026 message into m.
+func (m *COGSOGRapidUpdate) Unmarshal(data []byte) error {
+	fields, err := decode(129026, data)
+	if err != nil {
+		return err
+	}
+	*m = COGSOGRapidUpdate{
+		SID:          intField(fields, "SID"),
+		COGReference: stringField(fields, "COG Reference"),
+		COG:          floatField(fields, "COG"),
+		SOG:          floatField(fields, "SOG"),
+	}
+	return nil
+}
+
+// WindData is PGN 130306, Wind Data. Fields that are not available are nil.
+type WindData struct {
+	SID       *int
+	WindSpeed *float64 // m/s
+	WindAngle *float64 // deg
+	Reference *string
+}
+
+// PGN returns 130306.
+func (*WindData) PGN() uint32 {
+	return 130306
+}
+
+// Unmarshal decodes the payload of a PGN 130306 message into m.
+func (m *WindData) Unmarshal(data []byte) error {
+	fields, err := decode(130306, data)
+	if err != nil {
+		return err
+	}
+	*m = WindData{
+		SID:       intField(fields, "SID"),
+		WindSpeed: floatField(fields, "Wind Speed"),
+		WindAngle: floatField(fields, "Wind Angle"),
+		Reference: stringField(fields, "Reference"),
+	}
+	return nil
+}
+
+// Temperature is PGN 130312, Temperature. Fields that are not available are nil.
+type Temperature struct {
+	SID               *int
+	Instance          *int
+	Source            *string
+	ActualTemperature *float64 // C
+	SetTemperature    *float64 // C
+}
+
+// PGN returns 130312.
+func (*Temperature) PGN() uint32 {
+	return 130312
+}
+
+// Unmarshal decodes the payload of a PGN 130312 message into m.
+func (m *Temperature) Unmarshal(data []byte) error {
+	fields, err := decode(130312, data)
+	if err != nil {
+		return err
+	}
+	*m = Temperature{
+		SID:               intField(fields, "SID"),
+		Instance:          intField(fields, "Instance"),
+		Source:            stringField(fields, "Source"),
+		ActualTemperature: floatField(fields, "Actual Temperature"),
+		SetTemperature:    floatField(fields, "Set Temperature"),
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package pgns
+
+import (
+	"testing"
+
+	"go.viam.com/test"
+
+	"github.com/erh/gonmea/analyzer"
+	"github.com/erh/gonmea/common"
+)
+
+func parseLine(t *testing.T, line string) *common.Message {
+	t.Helper()
+	msg, _, err := analyzer.ParseMessage([]byte(line))
+	test.That(t, err, test.ShouldBeNil)
+	test.That(t, msg, test.ShouldNotBeNil)
+	return msg
+}
+
+func rawData(t *testing.T, line string) []byte {
+	t.Helper()
+	rawMsg, _, err := analyzer.ParseRawMessage([]byte(line))
+	test.That(t, err, test.ShouldBeNil)
+	return rawMsg.Data[:rawMsg.Len]
+}
+
+// checkField checks that a struct field holds what the analyzer put in its field map.
+func checkField[T comparable](t *testing.T, got *T, fields map[string]interface{}, name string) {
+	t.Helper()
+	want, ok := fields[name]
+	if !ok {
+		test.That(t, got, test.ShouldBeNil)
+		return
+	}
+	test.That(t, got, test.ShouldNotBeNil)
+	test.That(t, *got, test.ShouldEqual, want)
+}
+
+func TestWaterDepth(t *testing.T) {
+	// Depth 12.34 m, offset 0.5 m, no range.
+	const line = "2023-01-01T00:00:00.000Z,3,128267,1,255,8,01,d2,04,00,00,f4,01,ff"
+	msg := parseLine(t, line)
+
+	var depth WaterDepth
+	test.That(t, depth.PGN(), test.ShouldEqual, uint32(128267))
+	test.That(t, depth.Unmarshal(rawData(t, line)), test.ShouldBeNil)
+	checkField(t, depth.SID, msg.Fields, "SID")
+	checkField(t, depth.Depth, msg.Fields, "Depth")
+	checkField(t, depth.Offset, msg.Fields, "Offset")
+	checkField(t, depth.Range, msg.Fields, "Range")
+	test.That(t, *depth.Depth, test.ShouldAlmostEqual, 12.34, 1e-9)
+	test.That(t, depth.Range, test.ShouldBeNil)
+}
+
+func TestTemperature(t *testing.T) {
+	// Outside temperature of 293.35 K.
+	const line = "2023-01-01T00:00:00.000Z,5,130312,1,255,8,01,00,01,97,72,ff,ff,ff"
+	msg := parseLine(t, line)
+
+	var temp Temperature
+	test.That(t, temp.Unmarshal(rawData(t, line)), test.ShouldBeNil)
+	checkField(t, temp.SID, msg.Fields, "SID")
+	checkField(t, temp.Instance, msg.Fields, "Instance")
+	checkField(t, temp.Source, msg.Fields, "Source")
+	checkField(t, temp.ActualTemperature, msg.Fields, "Actual Temperature")
+	checkField(t, temp.SetTemperature, msg.Fields, "Set Temperature")
+	test.That(t, *temp.Source, test.ShouldEqual, "Outside Temperature")
+	test.That(t, temp.SetTemperature, test.ShouldBeNil)
+}
+
+func TestCOGSOGRapidUpdate(t *testing.T) {
+	const line = "2023-01-01T00:00:00.000Z,2,129026,1,255,8,03,fc,5c,3d,f4,01,ff,ff"
+	msg := parseLine(t, line)
+
+	var cogSog COGSOGRapidUpdate
+	test.That(t, cogSog.Unmarshal(rawData(t, line)), test.ShouldBeNil)
+	checkField(t, cogSog.SID, msg.Fields, "SID")
+	checkField(t, cogSog.COGReference, msg.Fields, "COG Reference")
+	checkField(t, cogSog.COG, msg.Fields, "COG")
+	checkField(t, cogSog.SOG, msg.Fields, "SOG")
+	test.That(t, *cogSog.SID, test.ShouldEqual, 3)
+}
+
+func TestVesselHeadingEmpty(t *testing.T) {
+	const line = "2023-01-01T00:00:00.000Z,2,127250,1,255,8,ff,ff,ff,ff,7f,ff,7f,ff"
+	msg := parseLine(t, line)
+
+	var heading VesselHeading
+	test.That(t, heading.Unmarshal(rawData(t, line)), test.ShouldBeNil)
+	checkField(t, heading.SID, msg.Fields, "SID")
+	checkField(t, heading.Heading, msg.Fields, "Heading")
+	checkField(t, heading.Deviation, msg.Fields, "Deviation")
+	checkField(t, heading.Variation, msg.Fields, "Variation")
+	test.That(t, heading.Heading, test.ShouldBeNil)
+}